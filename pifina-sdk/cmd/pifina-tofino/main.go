@@ -12,12 +12,14 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/thushjandan/pifina/pkg/controller"
 	"github.com/thushjandan/pifina/pkg/debugserver"
+	"github.com/thushjandan/pifina/pkg/model"
 )
 
 var (
@@ -37,8 +39,19 @@ func main() {
 	version_flag := flag.Bool("version", false, "show version")
 	connect_timeout := flag.Uint("connect-timeout", 5, "Connect timeout for the GRPC connection to the switch.")
 	sample_interval := flag.Uint("sample-interval-ms", 50, "Sample interval in ms. Default 100ms")
-	lpf_time_constant_int := flag.Uint("lpf-time-ns", 80, "LPF time constant for computing moving average of the ingress jitter value.")
+	tm_sample_interval := flag.Uint("tm-sample-interval-ms", 1000, "Sample interval in ms of the traffic manager counters per port and pipeline.")
+	lpf_time_constant_int := flag.Uint("lpf-time-ns", 80, "Default LPF time constant in ns for computing moving average of the ingress jitter value. Can be changed per selector rule through the API.")
 	pipeline_count := flag.Uint("pipe-count", 4, "Amount of pipeline existing on the tofino. Used to retrieve TrafficManager metrics per pipeline.")
+	delta_sampling := flag.Bool("delta-sampling", false, "Never reset the counters in the dataplane. The increments are computed from the previous sample instead, so no packets are lost between read and reset.")
+	hw_sync := flag.Bool("hw-sync", false, "Sync the counters and registers of all probes from the hardware before each collection cycle. All probes of a cycle share the same timestamp.")
+	read_only := flag.Bool("read-only", false, "Never write to the dataplane. Existing selector rules are only read and the counters are sampled with delta sampling. API requests changing the dataplane are rejected.")
+	lease_interval := flag.Uint("lease-interval", 5, "Interval in seconds, in which a follower tries to take over the single-writer lease of the switch. 0 disables the lease and always writes to the switch.")
+	monitored_tables := flag.String("tables", "", "Comma separated list of P4 tables, whose occupancy is monitored in addition to the PIFINA selector tables. e.g. pipe.SwitchIngress.forward")
+	discovery_interval := flag.Uint("discovery-interval", 10, "Interval in seconds, in which new flows reported by the flow discovery digest are ranked and reported again, if they are still active.")
+	discovery_auto_selectors := flag.Uint("discovery-auto-selectors", 0, "Amount of top new flows per discovery interval, for which a selector rule is created automatically. 0 only proposes selector rules through the API.")
+	discovery_max_selectors := flag.Uint("discovery-max-selectors", 8, "Upper bound of concurrent selector rules created automatically for discovered flows.")
+	discovery_ttl := flag.Uint("discovery-ttl", 60, "Duration in seconds of the measurement of a selector rule created automatically for a discovered flow.")
+	measurement_file := flag.String("measurement-file", "pifina-measurements.json", "File, in which scheduled and running measurements are kept, so time-boxed selector rules are removed at their stop time after a restart. Empty disables it.")
 
 	flag.Parse()
 
@@ -69,6 +82,35 @@ func main() {
 		os.Exit(1)
 	}
 
+	monitoredTables := make([]string, 0)
+	for _, tblName := range strings.Split(*monitored_tables, ",") {
+		if tblName = strings.TrimSpace(tblName); tblName != "" {
+			monitoredTables = append(monitoredTables, tblName)
+		}
+	}
+
+	// Syncing from the hardware is a write request
+	if *hw_sync && *read_only {
+		logger.Error("Hardware sync cannot be used in read-only mode. Remove either -hw-sync or -read-only")
+		os.Exit(1)
+	}
+
+	// Sample intervals are used as ticker periods, which need to be positive
+	if *sample_interval == 0 {
+		logger.Error("Invalid sample interval. Needs to be at least 1 ms")
+		os.Exit(1)
+	}
+
+	if *tm_sample_interval == 0 {
+		logger.Error("Invalid traffic manager sample interval. Needs to be at least 1 ms")
+		os.Exit(1)
+	}
+
+	if *discovery_interval == 0 {
+		logger.Error("Invalid discovery interval. Needs to be at least 1 second")
+		os.Exit(1)
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 	var wg sync.WaitGroup
@@ -90,9 +132,22 @@ func main() {
 		P4name:                  *p4_name,
 		CollectorServerEndpoint: *collector_server,
 		SampleInterval:          int(*sample_interval),
+		TMSampleInterval:        int(*tm_sample_interval),
 		APIPort:                 *api_port,
 		LpfTimeConst:            float32(*lpf_time_constant_int),
 		PipelineCount:           int(*pipeline_count),
+		DeltaSampling:           *delta_sampling,
+		HardwareSync:            *hw_sync,
+		ReadOnly:                *read_only,
+		LeaseInterval:           int(*lease_interval),
+		MonitoredTables:         monitoredTables,
+		DiscoveryInterval:       int(*discovery_interval),
+		DiscoveryPolicy: &model.FlowDiscoveryPolicy{
+			AutoSelectors: uint32(*discovery_auto_selectors),
+			MaxSelectors:  uint32(*discovery_max_selectors),
+			TTL:           uint32(*discovery_ttl),
+		},
+		MeasurementFile: *measurement_file,
 	}
 
 	controller := controller.NewTofinoController(options)
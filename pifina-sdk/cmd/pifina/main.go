@@ -23,7 +23,7 @@ var (
 	version        = "dev"
 	commit         = "none"
 	date           = time.Now().Format(time.RFC3339)
-	P4_MATCH_TYPES = []string{"exact", "ternary", "lpm"}
+	P4_MATCH_TYPES = []string{"exact", "ternary", "lpm", "range", "optional"}
 )
 
 func main() {
@@ -46,8 +46,10 @@ func main() {
 				Usage:   `Example: pifina generate -k hdr.ipv4.protocol:exact -k hdr.ipv4.dstAddr:ternary -k hdr.ipv4.srcAddr:ternary -o src/myP4app/include `,
 				Description: `Creates customized Pifina P4 source code with user defined match fields. 
 Use for every match key the flag -key and define the name of the field together with its match type delimited by a colon (:) like field1:matchType
+Additional selector tables with a different set of keys can be defined with the flag -selector like name:priority=field1:matchType,field2:matchType
+Use the flag -hdr-file with your P4 header definitions to validate the keys and their bit width before any file is generated
 In addition the output directory for the generated P4 source code files needs to be defined with flag -o
-Following match types can be used: exact, ternary, lpm`,
+Following match types can be used: exact, ternary, lpm, range, optional`,
 				Flags: []cli.Flag{
 					&cli.StringSliceFlag{
 						Name:     "key",
@@ -55,6 +57,11 @@ Following match types can be used: exact, ternary, lpm`,
 						Required: true,
 						Usage:    "which P4 header fields to match => Table keys for PIFINA",
 					},
+					&cli.StringSliceFlag{
+						Name:     "selector",
+						Required: false,
+						Usage:    "Additional selector table with its own keys as <name>[:<priority>]=<key>:<type>,<key>:<type>. Tables are applied in order of their priority and only the first match is measured. E.g. roce:1=hdr.bth.dstQP:exact",
+					},
 					&cli.StringFlag{
 						Name:    "output",
 						Aliases: []string{"o"},
@@ -66,11 +73,66 @@ Following match types can be used: exact, ternary, lpm`,
 						Value: "ingress_headers_t",
 						Usage: "Name of your ingress header struct.",
 					},
+					&cli.StringSliceFlag{
+						Name:  "hdr-file",
+						Usage: "Optional P4 file with your header definitions. The keys will be validated against the ingress header struct before generation. This flag can be used multiple times.",
+					},
 					&cli.StringFlag{
 						Name:  "eg-hdr",
 						Value: "egress_headers_t",
 						Usage: "Name of your egress header struct.",
 					},
+					&cli.UintFlag{
+						Name:  "session-width",
+						Value: console.SESSION_ID_WIDTH,
+						Usage: "Width of the sessionId in bits. Defines the size of the selector tables and registers. 7 bits allows 127 concurrent selector rules",
+					},
+					&cli.IntSliceFlag{
+						Name:  "pkt-size-hist",
+						Usage: "Upper boundaries in bytes of the buckets for the optional packet size histogram probe. E.g. 64,128,256,512,1024,1518. Packets larger than the last boundary are counted in an additional bucket",
+					},
+					&cli.BoolFlag{
+						Name:  "drop-probe",
+						Value: false,
+						Usage: "If true, probes counting the dropped packets per selector rule in ingress and egress will be generated.",
+					},
+					&cli.UintFlag{
+						Name:  "drop-reason-width",
+						Value: 0,
+						Usage: "Width of the drop reason in bits passed by the application to the drop probes. The dropped packets are counted per reason in addition. 0 disables the breakdown",
+					},
+					&cli.StringSliceFlag{
+						Name:  "hh-key",
+						Usage: "Header fields used as flow key for the optional heavy hitter probe. Needs --hdr-file. E.g. --hh-key hdr.ipv4.src_addr --hh-key hdr.ipv4.dst_addr",
+					},
+					&cli.UintFlag{
+						Name:  "hh-sketch-width",
+						Value: 10,
+						Usage: "Width of the hash in bits for a row of the Count-Min sketch in the heavy hitter probe. Each row has 2^width counters per selector rule",
+					},
+					&cli.UintFlag{
+						Name:  "hh-slot-width",
+						Value: 3,
+						Usage: "Width of the hash in bits for the top flow slots in the heavy hitter probe. At most 2^width top flows are reported per selector rule",
+					},
+					&cli.UintFlag{
+						Name:  "burst-threshold",
+						Value: 0,
+						Usage: "If set, a microburst probe will be generated, which tracks the peak egress queue depth and counts the packets above this queue depth in cells per selector rule. The threshold can be changed at runtime",
+					},
+					&cli.StringSliceFlag{
+						Name:  "discovery-key",
+						Usage: "Header fields sent as flow key in the optional flow discovery digest, which reports the first packet of flows not matched by any selector rule. Needs --hdr-file. E.g. --discovery-key hdr.ipv4.src_addr --discovery-key hdr.ipv4.dst_addr",
+					},
+					&cli.StringFlag{
+						Name:  "discovery-filter",
+						Usage: "Coarse filter as P4 condition, which a packet needs to fulfill to be reported by the flow discovery digest. Defaults to the validity of the headers of the discovery keys. E.g. \"hdr.ipv4.isValid() && hdr.ipv4.protocol == 17\"",
+					},
+					&cli.UintFlag{
+						Name:  "discovery-filter-width",
+						Value: 16,
+						Usage: "Width of the hash in bits for the filter of already reported flows in the flow discovery digest. The filter has 2^width entries",
+					},
 					&cli.IntFlag{
 						Name:  "ig-probe",
 						Value: 0,
@@ -144,6 +206,54 @@ Following match types can be used: exact, ternary, lpm`,
 							},
 						},
 					},
+					{
+						Name:        "daemon",
+						Aliases:     []string{"d"},
+						Usage:       "How to run: pifina nic daemon -s pifina-collector.local:8654",
+						Description: `Runs the NIC collector as a daemon. Monitored devices and counters can be added or removed at runtime through the REST API. Use -d to define the devices to monitor at startup`,
+						Action:      console.StartNICDaemonCliAction,
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:     "dev",
+								Aliases:  []string{"d"},
+								Required: false,
+								Usage:    "Dev-UID, ibdevice name or iface name to collect the metrics at startup. This flag can be used multiple times to collect counter from multiple NICs.",
+							},
+							&cli.StringFlag{
+								Name:     "server",
+								Aliases:  []string{"s"},
+								Value:    "127.0.0.1:8654",
+								Required: false,
+								Usage:    "PIFINA collector server address as 'host:port'. E.g. pifina-collector.local:8654",
+							},
+							&cli.UintFlag{
+								Name:     "group-id",
+								Value:    1,
+								Required: false,
+								Usage:    "Group Identifier for PIFINA collector server. Used to group multiple probes together. Needs to be a positive number",
+							},
+							&cli.IntFlag{
+								Name:     "sample-interval",
+								Aliases:  []string{"i"},
+								Value:    10,
+								Required: false,
+								Usage:    "Sample interval in seconds.",
+							},
+							&cli.BoolFlag{
+								Name:     "disable-neohost",
+								Value:    false,
+								Required: false,
+								Usage:    "Do not collect metrics from NEO Host SDK",
+							},
+							&cli.StringFlag{
+								Name:     "port",
+								Aliases:  []string{"p"},
+								Value:    ":8656",
+								Required: false,
+								Usage:    "Listen address for the REST API.",
+							},
+						},
+					},
 				},
 				Flags: []cli.Flag{
 					&cli.StringFlag{
@@ -200,7 +310,7 @@ Following match types can be used: exact, ternary, lpm`,
 						Name:     "probe-port",
 						Value:    8656,
 						Required: false,
-						Usage:    "Default PIFINA tofino probe API port to proxy",
+						Usage:    "Default API port to proxy for probes, which do not announce their API port",
 					},
 					&cli.StringFlag{
 						Name:     "key",
@@ -35,6 +35,26 @@ export const PIFINA_PROBE_CHART_CFG: PIFINA_CHART_CONFIG = {
         yAxisName: pb.Y_AXIS_NAME_BYTE_RATE,
         title: "End egress byte counter"
     },
+    [pb.PROBE_INGRESS_DROP_PKTS]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_RATE,
+        title: "Packets dropped in ingress"
+    },
+    [pb.PROBE_TM_DROP_PKTS]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_RATE,
+        title: "Packets dropped in traffic manager"
+    },
+    [pb.PROBE_EGRESS_DROP_PKTS]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_RATE,
+        title: "Packets dropped in egress"
+    },
+    [pb.PROBE_EGRESS_BURST_PKTS]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_RATE,
+        title: "Packets enqueued above the burst threshold"
+    },
+    [pb.PROBE_EGRESS_BURST_MAX_QDEPTH]: {
+        yAxisName: pb.Y_AXIS_NAME_CELL_COUNT,
+        title: "Peak egress queue depth"
+    },
     [pb.PROBE_INGRESS_JITTER]: {
         yAxisName: pb.Y_AXIS_NAME_TIME_SEC,
         title: "Ingress inter packet arrival average rate",
@@ -150,6 +170,78 @@ export const PIFINA_PROBE_CHART_CFG: PIFINA_CHART_CONFIG = {
         yAxisName: pb.Y_AXIS_NAME_EVENTS_COUNT,
         title: "Out of buffer events for RX"
     },
+    [pb.PROBE_ETHTOOL_TX_PAUSE_STORM_WARN]: {
+        yAxisName: pb.Y_AXIS_NAME_EVENTS_COUNT,
+        title: "TX pause storm warning events"
+    },
+    [pb.PROBE_ETHTOOL_TX_PAUSE_STORM_ERR]: {
+        yAxisName: pb.Y_AXIS_NAME_EVENTS_COUNT,
+        title: "TX pause storm error events"
+    },
+    [pb.PROBE_ETHTOOL_RX_QUEUE_PKT]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_COUNT,
+        title: "RX packets per queue"
+    },
+    [pb.PROBE_ETHTOOL_RX_QUEUE_BYTE]: {
+        yAxisName: pb.Y_AXIS_NAME_BYTE_COUNT,
+        title: "RX bytes per queue"
+    },
+    [pb.PROBE_ETHTOOL_RX_QUEUE_DROP]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_COUNT,
+        title: "RX drops per queue"
+    },
+    [pb.PROBE_ETHTOOL_TX_QUEUE_PKT]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_COUNT,
+        title: "TX packets per queue"
+    },
+    [pb.PROBE_ETHTOOL_TX_QUEUE_BYTE]: {
+        yAxisName: pb.Y_AXIS_NAME_BYTE_COUNT,
+        title: "TX bytes per queue"
+    },
+    [pb.PROBE_ETHTOOL_TX_QUEUE_DROP]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_COUNT,
+        title: "TX drops per queue"
+    },
+    [pb.PROBE_ETHTOOL_RX_PRIO_PKT]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_COUNT,
+        title: "RX packets per priority"
+    },
+    [pb.PROBE_ETHTOOL_RX_PRIO_BYTE]: {
+        yAxisName: pb.Y_AXIS_NAME_BYTE_COUNT,
+        title: "RX bytes per priority"
+    },
+    [pb.PROBE_ETHTOOL_RX_PRIO_DISCARD]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_COUNT,
+        title: "RX discards per priority"
+    },
+    [pb.PROBE_ETHTOOL_TX_PRIO_PKT]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_COUNT,
+        title: "TX packets per priority"
+    },
+    [pb.PROBE_ETHTOOL_TX_PRIO_BYTE]: {
+        yAxisName: pb.Y_AXIS_NAME_BYTE_COUNT,
+        title: "TX bytes per priority"
+    },
+    [pb.PROBE_ETHTOOL_RX_PRIO_PAUSE]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_COUNT,
+        title: "PFC pause frames received per priority"
+    },
+    [pb.PROBE_ETHTOOL_TX_PRIO_PAUSE]: {
+        yAxisName: pb.Y_AXIS_NAME_PKT_COUNT,
+        title: "PFC pause frames sent per priority"
+    },
+    [pb.PROBE_ETHTOOL_RX_PRIO_PAUSE_DURATION]: {
+        yAxisName: pb.Y_AXIS_NAME_TIME_US,
+        title: "PFC pause duration received per priority"
+    },
+    [pb.PROBE_ETHTOOL_TX_PRIO_PAUSE_DURATION]: {
+        yAxisName: pb.Y_AXIS_NAME_TIME_US,
+        title: "PFC pause duration sent per priority"
+    },
+    [pb.PROBE_ETHTOOL_RX_PRIO_PAUSE_TRANSITION]: {
+        yAxisName: pb.Y_AXIS_NAME_EVENTS_COUNT,
+        title: "PFC pause transitions received per priority"
+    },
 }
 
 export const getPifinaChartConfigByMetricName = (metricName: string): PIFINA_CHART_CONF_ITEM  => {
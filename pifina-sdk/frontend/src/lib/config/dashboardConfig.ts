@@ -17,7 +17,7 @@ export const PIFINA_DASHBOARD_CONF: PIFINA_DASHBOARD_CONF_TYPE = {
         },
         {
             key: "APP_REG_CHARTS",
-            title: "Application owned registers",
+            title: "Application owned objects",
             type: "list",
             groupName: "appRegister",
             disableSessionFilter: true
@@ -29,13 +29,36 @@ export const PIFINA_DASHBOARD_CONF: PIFINA_DASHBOARD_CONF_TYPE = {
             groupName: "extraProbes",
             disableSessionFilter: false
         },
+        {
+            key: "PKT_SIZE_HIST_CHARTS",
+            title: "Packet sizes",
+            type: "histogram",
+            groupName: "pktSizeHistogram",
+            disableSessionFilter: false
+        },
         {
             key: "TM_CHARTS",
             title: "Traffic Manager",
             type: "static",
             charts: PIFINA_TM_CHART_ORDER,
             disableSessionFilter: true
-        }        
+        },
+        {
+            key: "PORT_CHARTS",
+            title: "Ports",
+            type: "list",
+            groupName: "portMetrics",
+            listTitleSuffix: "(port)",
+            disableSessionFilter: true
+        },
+        {
+            key: "TABLE_CHARTS",
+            title: "Tables",
+            type: "list",
+            groupName: "tableMetrics",
+            listTitleSuffix: "(table)",
+            disableSessionFilter: true
+        }
     ],
     HOSTTYPE_NIC: [
         {
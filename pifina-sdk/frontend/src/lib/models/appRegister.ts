@@ -6,4 +6,7 @@
 export interface AppRegisterModel {
     name: string
     index: number
+    indexEnd?: number
+    all?: boolean
+    aggregation?: string
 }
\ No newline at end of file
@@ -9,6 +9,7 @@ export interface PIFINA_DASHBOARD_CONF_ITEM {
     type: string
     charts?: (string[]| string)[]
     groupName?: string
+    listTitleSuffix?: string
     disableSessionFilter: boolean
 }
 
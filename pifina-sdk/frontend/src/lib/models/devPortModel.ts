@@ -6,4 +6,13 @@
 export interface DevPortModel {
     name: string
     portId?: number
+    status?: PortStatusModel
+    stats?: {[counterName: string]: number}
+}
+
+export interface PortStatusModel {
+    operUp: boolean
+    speed: string
+    speedGbps: number
+    fec: string
 }
\ No newline at end of file
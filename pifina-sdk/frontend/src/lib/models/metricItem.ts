@@ -12,6 +12,7 @@ export interface MetricItem {
     timestamp: Date
     value: number
     type: string
+    label?: string
 }
 
 export interface DTOPifinaMetricItem {
@@ -19,6 +20,7 @@ export interface DTOPifinaMetricItem {
     metricName: string
     type: string
     value: number
+    label?: string
     timestamp: string
 }
 
@@ -15,6 +15,11 @@ export const PROBE_EGRESS_START_CNT_BYTE = `${PifinaMetricName.EGRESS_START_CNT}
 export const PROBE_EGRESS_START_CNT_PKTS = `${PifinaMetricName.EGRESS_START_CNT}${MetricTypes.PKTS}`
 export const PROBE_EGRESS_END_CNT_BYTE = `${PifinaMetricName.EGRESS_END_CNT}${MetricTypes.BYTES}`
 export const PROBE_INGRESS_JITTER = `${PifinaMetricName.INGRESS_JITTER_AVG}${MetricTypes.EXT_VALUE}`
+export const PROBE_INGRESS_DROP_PKTS = `${PifinaMetricName.INGRESS_DROP_CNT}${MetricTypes.PKTS}`
+export const PROBE_TM_DROP_PKTS = `${PifinaMetricName.TM_DROP_CNT}${MetricTypes.PKTS}`
+export const PROBE_EGRESS_DROP_PKTS = `${PifinaMetricName.EGRESS_DROP_CNT}${MetricTypes.PKTS}`
+export const PROBE_EGRESS_BURST_PKTS = `${PifinaMetricName.EGRESS_BURST_CNT}${MetricTypes.PKTS}`
+export const PROBE_EGRESS_BURST_MAX_QDEPTH = `${PifinaMetricName.EGRESS_BURST_MAX_QDEPTH}${MetricTypes.EXT_VALUE}`
 export const PROBE_EXTRA_PREFIX = "PF_EXTRA"
 export const PROBE_TM_INGRESS_DROP_PKT = `PF_TM_ig_port_drop_count_packets`;
 export const PROBE_TM_EGRESS_DROP_PKT = `PF_TM_eg_port_drop_count_packets`;
@@ -43,12 +48,34 @@ export const PROBE_ETHTOOL_TX_DISCARD = "tx_discards_phy"
 export const PROBE_ETHTOOL_RX_PAUSE = "rx_pause_ctrl_phy"
 export const PROBE_ETHTOOL_TX_PAUSE = "tx_pause_ctrl_phy"
 export const PROBE_ETHTOOL_RX_OOB = "rx_out_of_buffer"
+export const PROBE_ETHTOOL_TX_PAUSE_STORM_WARN = "tx_pause_storm_warning_events"
+export const PROBE_ETHTOOL_TX_PAUSE_STORM_ERR = "tx_pause_storm_error_events"
+// Per queue counters. SessionId is the queue index
+export const PROBE_ETHTOOL_RX_QUEUE_PKT = "rx_queue_packets"
+export const PROBE_ETHTOOL_RX_QUEUE_BYTE = "rx_queue_bytes"
+export const PROBE_ETHTOOL_RX_QUEUE_DROP = "rx_queue_drops"
+export const PROBE_ETHTOOL_TX_QUEUE_PKT = "tx_queue_packets"
+export const PROBE_ETHTOOL_TX_QUEUE_BYTE = "tx_queue_bytes"
+export const PROBE_ETHTOOL_TX_QUEUE_DROP = "tx_queue_drops"
+// Per priority counters. SessionId is the priority
+export const PROBE_ETHTOOL_RX_PRIO_PKT = "rx_prio_packets"
+export const PROBE_ETHTOOL_RX_PRIO_BYTE = "rx_prio_bytes"
+export const PROBE_ETHTOOL_RX_PRIO_DISCARD = "rx_prio_discards"
+export const PROBE_ETHTOOL_TX_PRIO_PKT = "tx_prio_packets"
+export const PROBE_ETHTOOL_TX_PRIO_BYTE = "tx_prio_bytes"
+export const PROBE_ETHTOOL_RX_PRIO_PAUSE = "rx_prio_pause"
+export const PROBE_ETHTOOL_TX_PRIO_PAUSE = "tx_prio_pause"
+export const PROBE_ETHTOOL_RX_PRIO_PAUSE_DURATION = "rx_prio_pause_duration"
+export const PROBE_ETHTOOL_TX_PRIO_PAUSE_DURATION = "tx_prio_pause_duration"
+export const PROBE_ETHTOOL_RX_PRIO_PAUSE_TRANSITION = "rx_prio_pause_transition"
 
 export const Y_AXIS_NAME_BYTE_RATE = "byte/sec"
 export const Y_AXIS_NAME_PKT_RATE = "pkts/sec"
 export const Y_AXIS_NAME_TIME_MS = "ms"
 export const Y_AXIS_NAME_TIME_SEC = "sec"
+export const Y_AXIS_NAME_TIME_US = "us"
 export const Y_AXIS_NAME_PKT_COUNT = "pkts"
+export const Y_AXIS_NAME_BYTE_COUNT = "bytes"
 export const Y_AXIS_NAME_CELL_COUNT = "cells"
 export const Y_AXIS_NAME_EVENTS_COUNT = "events"
 export const Y_AXIS_NAME_EVENTS_RATE = "events/sec"
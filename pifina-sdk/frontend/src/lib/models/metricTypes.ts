@@ -9,7 +9,12 @@ export enum PifinaMetricName {
     INGRESS_END_HDR = "PF_INGRESS_END_HDR_SIZE",
     EGRESS_START_CNT = "PF_EGRESS_START_CNT",
     EGRESS_END_CNT = "PF_EGRESS_END_CNT",
-    INGRESS_JITTER_AVG = "PF_INGRESS_JITTER_AVG"
+    INGRESS_JITTER_AVG = "PF_INGRESS_JITTER_AVG",
+    INGRESS_DROP_CNT = "PF_INGRESS_DROP_CNT",
+    TM_DROP_CNT = "PF_TM_DROP_CNT",
+    EGRESS_DROP_CNT = "PF_EGRESS_DROP_CNT",
+    EGRESS_BURST_CNT = "PF_EGRESS_BURST_CNT",
+    EGRESS_BURST_MAX_QDEPTH = "PF_EGRESS_BURST_MAX_QDEPTH"
 }
 
 export enum MetricTypes {
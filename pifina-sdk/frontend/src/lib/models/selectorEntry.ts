@@ -5,7 +5,11 @@
 
 export interface SelectorEntry {
     sessionId: number
+    table?: string
     keys: SelectorKey[]
+    ttl?: number
+    start?: string
+    stop?: string
 }
 
 export interface SelectorKey {
@@ -14,4 +18,7 @@ export interface SelectorKey {
     matchType: string
     valueMask?: string
     prefixLength?: number
+    low?: string
+    high?: string
+    isValid?: boolean
 }
\ No newline at end of file
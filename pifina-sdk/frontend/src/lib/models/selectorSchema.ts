@@ -14,4 +14,6 @@ export interface SelectorSchema {
 export const FIELD_MATCH_PRIORITY = "$MATCH_PRIORITY"
 export const MATCH_TYPE_EXACT   = "Exact"
 export const MATCH_TYPE_TERNARY = "Ternary"
-export const MATCH_TYPE_LPM     = "LPM"
\ No newline at end of file
+export const MATCH_TYPE_LPM     = "LPM"
+export const MATCH_TYPE_RANGE   = "Range"
+export const MATCH_TYPE_OPTIONAL = "Optional"
\ No newline at end of file
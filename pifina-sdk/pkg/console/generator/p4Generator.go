@@ -9,6 +9,7 @@ import (
 	"embed"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/hashicorp/go-hclog"
@@ -25,6 +26,24 @@ const (
 	P4_SKELETON_FILE_NAME = "p4SkeletonApp.tpl"
 )
 
+// Helper functions to generate nested if blocks for selector tables in P4 app template
+var p4AppTemplateFuncs = template.FuncMap{
+	"indent": func(level int) string {
+		return strings.Repeat("    ", level)
+	},
+	"isLast": func(i int, tables []*model.P4CodeTemplateSelectorTable) bool {
+		return i == len(tables)-1
+	},
+	// Returns the nesting levels of the if blocks in reverse order to close them
+	"closingLevels": func(tables []*model.P4CodeTemplateSelectorTable) []int {
+		levels := make([]int, 0, len(tables))
+		for i := len(tables) - 2; i >= 0; i-- {
+			levels = append(levels, i)
+		}
+		return levels
+	},
+}
+
 // Generate a basic skeleton P4 file in a new folder
 func GenerateSkeleton(logger hclog.Logger, templateOptions *model.P4CodeTemplate, outputDir string) error {
 	// Create new app folder
@@ -95,7 +114,7 @@ func GenerateP4App(logger hclog.Logger, templateOptions *model.P4CodeTemplate, o
 	}
 	defer pfAppFileHandle.Close()
 
-	p4AppTemplate, err := template.New(P4_APP_FILE_NAME).ParseFS(
+	p4AppTemplate, err := template.New(P4_APP_FILE_NAME).Funcs(p4AppTemplateFuncs).ParseFS(
 		p4CodeTemplate,
 		filepath.Join(P4_TEMPLATE_DIR, P4_APP_FILE_NAME),
 	)
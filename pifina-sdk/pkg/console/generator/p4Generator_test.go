@@ -0,0 +1,145 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// The bridge header needs to be initialized with a value for every field including the padding.
+func TestGenerateP4AppBridgePadding(t *testing.T) {
+	tests := []struct {
+		sessionIdWidth   uint
+		sessionIdPadding uint
+		headerField      string
+		igInit           string
+		egInit           string
+	}{
+		{
+			sessionIdWidth:   15,
+			sessionIdPadding: 0,
+			igInit:           "meta = {{false, 0}, 0, 0}",
+			egInit:           "meta = {{false, 0}, 0}; \\\n",
+		},
+		{
+			sessionIdWidth:   10,
+			sessionIdPadding: 5,
+			headerField:      "bit<5> pfPadding;",
+			igInit:           "meta = {{false, 0, 0}, 0, 0}",
+			egInit:           "meta = {{false, 0, 0}, 0}; \\\n",
+		},
+	}
+
+	for _, test := range tests {
+		outputDir := t.TempDir()
+		templateOptions := &model.P4CodeTemplate{
+			SessionIdWidth:   test.sessionIdWidth,
+			SessionIdPadding: test.sessionIdPadding,
+			BridgeHeaderSize: (1 + test.sessionIdWidth + test.sessionIdPadding) / 8,
+			SelectorTables: []*model.P4CodeTemplateSelectorTable{
+				{
+					ControlPlaneName: "PF_INGRESS_MATCH_CNT",
+					MatchKeys:        []*model.P4CodeTemplateKey{{Name: "hdr.ipv4.src_addr", MatchType: "exact"}},
+				},
+			},
+			IngressHeaderType: "ingress_headers_t",
+			EgressHeaderType:  "egress_headers_t",
+		}
+		if err := GenerateP4App(hclog.NewNullLogger(), templateOptions, outputDir); err != nil {
+			t.Fatalf("width %d: cannot generate P4 app: %v", test.sessionIdWidth, err)
+		}
+
+		header, err := os.ReadFile(filepath.Join(outputDir, "pifina_headers.p4"))
+		if err != nil {
+			t.Fatalf("width %d: cannot read header file: %v", test.sessionIdWidth, err)
+		}
+		hasPadding := strings.Contains(string(header), "pfPadding")
+		if test.headerField != "" && !strings.Contains(string(header), test.headerField) {
+			t.Errorf("width %d: expected header field %q", test.sessionIdWidth, test.headerField)
+		}
+		if test.headerField == "" && hasPadding {
+			t.Errorf("width %d: unexpected padding field in header", test.sessionIdWidth)
+		}
+
+		app, err := os.ReadFile(filepath.Join(outputDir, "pifina_probes.p4"))
+		if err != nil {
+			t.Fatalf("width %d: cannot read probe file: %v", test.sessionIdWidth, err)
+		}
+		if !strings.Contains(string(app), "pifina_ig_parser_init(meta) "+test.igInit) {
+			t.Errorf("width %d: expected ingress initializer %q", test.sessionIdWidth, test.igInit)
+		}
+		if !strings.Contains(string(app), "pifina_eg_parser_init(packet,meta) "+test.egInit) {
+			t.Errorf("width %d: expected egress initializer %q", test.sessionIdWidth, test.egInit)
+		}
+	}
+}
+
+// The drop probes take the drop reason of the application only, if the drops are counted per reason.
+func TestGenerateP4AppDropReason(t *testing.T) {
+	tests := []struct {
+		dropReasonWidth uint
+		signature       string
+		reasonCounter   bool
+	}{
+		{
+			dropReasonWidth: 0,
+			signature:       "control PfIngressDropProbe(in pf_ingress_metadata_t meta, in ingress_intrinsic_metadata_for_deparser_t ig_dprsr_md)",
+		},
+		{
+			dropReasonWidth: 3,
+			signature:       "control PfIngressDropProbe(in pf_ingress_metadata_t meta, in ingress_intrinsic_metadata_for_deparser_t ig_dprsr_md, in pf_drop_reason_t dropReason)",
+			reasonCounter:   true,
+		},
+	}
+
+	for _, test := range tests {
+		outputDir := t.TempDir()
+		templateOptions := &model.P4CodeTemplate{
+			SessionIdWidth:   15,
+			BridgeHeaderSize: 2,
+			SelectorTables: []*model.P4CodeTemplateSelectorTable{
+				{
+					ControlPlaneName: "PF_INGRESS_MATCH_CNT",
+					MatchKeys:        []*model.P4CodeTemplateKey{{Name: "hdr.ipv4.src_addr", MatchType: "exact"}},
+				},
+			},
+			IngressHeaderType: "ingress_headers_t",
+			EgressHeaderType:  "egress_headers_t",
+			DropProbe:         true,
+			DropReasonWidth:   test.dropReasonWidth,
+		}
+		if err := GenerateP4App(hclog.NewNullLogger(), templateOptions, outputDir); err != nil {
+			t.Fatalf("width %d: cannot generate P4 app: %v", test.dropReasonWidth, err)
+		}
+
+		header, err := os.ReadFile(filepath.Join(outputDir, "pifina_headers.p4"))
+		if err != nil {
+			t.Fatalf("width %d: cannot read header file: %v", test.dropReasonWidth, err)
+		}
+		if hasReasonWidth := strings.Contains(string(header), "#define PF_DROP_REASON_WIDTH 3"); hasReasonWidth != test.reasonCounter {
+			t.Errorf("width %d: expected drop reason width definition: %t", test.dropReasonWidth, test.reasonCounter)
+		}
+
+		app, err := os.ReadFile(filepath.Join(outputDir, "pifina_probes.p4"))
+		if err != nil {
+			t.Fatalf("width %d: cannot read probe file: %v", test.dropReasonWidth, err)
+		}
+		if !strings.Contains(string(app), test.signature) {
+			t.Errorf("width %d: expected drop probe %q", test.dropReasonWidth, test.signature)
+		}
+		for _, counterName := range []string{"PF_INGRESS_DROP_REASON_CNT", "PF_EGRESS_DROP_REASON_CNT"} {
+			if hasCounter := strings.Contains(string(app), counterName); hasCounter != test.reasonCounter {
+				t.Errorf("width %d: expected counter %s: %t", test.dropReasonWidth, counterName, test.reasonCounter)
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package generator
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Minimal parser for P4 header and struct definitions.
+// It is only used to validate the match keys given to pifina generate and does not understand the full P4_16 grammar.
+type P4HeaderDefinitions struct {
+	// type name => member name => member type
+	compositeTypes map[string]map[string]string
+	// Tracks which composite types are headers. Needed to allow isValid() as key
+	headerTypes map[string]struct{}
+	// typedef, enum or constant name => bit width or value
+	widths map[string]uint
+}
+
+var (
+	p4BlockCommentRegex   = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	p4LineCommentRegex    = regexp.MustCompile(`//[^\n]*`)
+	p4DefineRegex         = regexp.MustCompile(`#define\s+(\w+)\s+(\d+)`)
+	p4ConstRegex          = regexp.MustCompile(`\bconst\s+bit\s*<\s*\w+\s*>\s+(\w+)\s*=\s*(\d+)\s*;`)
+	p4TypedefBitRegex     = regexp.MustCompile(`\b(?:typedef|type)\s+(?:bit|int)\s*<\s*(\w+)\s*>\s+(\w+)\s*;`)
+	p4TypedefAliasRegex   = regexp.MustCompile(`\b(?:typedef|type)\s+(\w+)\s+(\w+)\s*;`)
+	p4EnumRegex           = regexp.MustCompile(`\benum\s+bit\s*<\s*(\w+)\s*>\s+(\w+)\s*\{`)
+	p4CompositeTypeRegex  = regexp.MustCompile(`\b(header|header_union|struct)\s+(\w+)\s*\{([^}]*)\}`)
+	p4AnnotationRegex     = regexp.MustCompile(`@\w+(\([^)]*\))?`)
+	p4MemberRegex         = regexp.MustCompile(`^(bit|int|varbit)\s*<\s*(\w+)\s*>\s+(\w+)$|^(\w+)\s+(\w+)$|^(\w+)\s*\[\s*\w+\s*\]\s+(\w+)$`)
+	p4IsValidMethodSuffix = "isValid()"
+)
+
+// Parses all header, struct and typedef definitions of the given P4 files.
+func ParseP4HeaderFiles(filePaths []string) (*P4HeaderDefinitions, error) {
+	definitions := &P4HeaderDefinitions{
+		compositeTypes: make(map[string]map[string]string),
+		headerTypes:    make(map[string]struct{}),
+		widths:         map[string]uint{"bool": 1},
+	}
+	for _, filePath := range filePaths {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := definitions.parse(string(content)); err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+	}
+
+	return definitions, nil
+}
+
+func (d *P4HeaderDefinitions) parse(content string) error {
+	// Remove all comments
+	content = p4BlockCommentRegex.ReplaceAllString(content, "")
+	content = p4LineCommentRegex.ReplaceAllString(content, "")
+
+	// Constants can be used as width in bit<>
+	for _, match := range p4DefineRegex.FindAllStringSubmatch(content, -1) {
+		value, _ := strconv.ParseUint(match[2], 10, 32)
+		d.widths[match[1]] = uint(value)
+	}
+	for _, match := range p4ConstRegex.FindAllStringSubmatch(content, -1) {
+		value, _ := strconv.ParseUint(match[2], 10, 32)
+		d.widths[match[1]] = uint(value)
+	}
+	for _, match := range p4TypedefBitRegex.FindAllStringSubmatch(content, -1) {
+		width, err := d.resolveWidth(match[1])
+		if err != nil {
+			return err
+		}
+		d.widths[match[2]] = width
+	}
+	for _, match := range p4EnumRegex.FindAllStringSubmatch(content, -1) {
+		width, err := d.resolveWidth(match[1])
+		if err != nil {
+			return err
+		}
+		d.widths[match[2]] = width
+	}
+	// Aliases of an other typedef like "typedef mac_addr_t src_mac_t;"
+	for _, match := range p4TypedefAliasRegex.FindAllStringSubmatch(content, -1) {
+		if width, ok := d.widths[match[1]]; ok {
+			d.widths[match[2]] = width
+		}
+	}
+
+	for _, match := range p4CompositeTypeRegex.FindAllStringSubmatch(content, -1) {
+		kind, typeName, body := match[1], match[2], match[3]
+		members := make(map[string]string)
+		for _, member := range strings.Split(body, ";") {
+			member = strings.TrimSpace(p4AnnotationRegex.ReplaceAllString(member, ""))
+			if member == "" {
+				continue
+			}
+			memberMatch := p4MemberRegex.FindStringSubmatch(member)
+			if memberMatch == nil {
+				return fmt.Errorf("cannot parse member '%s' of %s %s", member, kind, typeName)
+			}
+			switch {
+			case memberMatch[1] != "":
+				// bit<N>, int<N> or varbit<N>
+				if memberMatch[1] == "varbit" {
+					members[memberMatch[3]] = "varbit"
+					continue
+				}
+				width, err := d.resolveWidth(memberMatch[2])
+				if err != nil {
+					return err
+				}
+				members[memberMatch[3]] = fmt.Sprintf("bit<%d>", width)
+			case memberMatch[4] != "":
+				// Named type like a typedef, header or struct
+				members[memberMatch[5]] = memberMatch[4]
+			default:
+				// Header stacks cannot be used as key
+				members[memberMatch[7]] = "stack"
+			}
+		}
+		d.compositeTypes[typeName] = members
+		if kind == "header" {
+			d.headerTypes[typeName] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// Resolves a width given as number or as constant
+func (d *P4HeaderDefinitions) resolveWidth(width string) (uint, error) {
+	if value, err := strconv.ParseUint(width, 10, 32); err == nil {
+		return uint(value), nil
+	}
+	if value, ok := d.widths[width]; ok {
+		return value, nil
+	}
+	return 0, fmt.Errorf("cannot resolve width %s", width)
+}
+
+// Returns true if the given type has been found in the parsed files.
+func (d *P4HeaderDefinitions) HasType(typeName string) bool {
+	_, ok := d.compositeTypes[typeName]
+	return ok
+}
+
+// Resolves a key path like hdr.ipv4.dst_addr starting from the given root type and returns its bit width.
+// The first path element is the name of the parameter, which has the root type. e.g. hdr
+func (d *P4HeaderDefinitions) GetKeyWidth(rootType string, keyPath string) (uint, error) {
+	pathElements := strings.Split(keyPath, ".")
+	if len(pathElements) < 2 {
+		return 0, fmt.Errorf("%s is not a valid key. A key needs to be a field of a header like hdr.ipv4.dst_addr", keyPath)
+	}
+	currentType := rootType
+	// Skip the parameter name
+	for i, element := range pathElements[1:] {
+		currentPath := strings.Join(pathElements[:i+2], ".")
+		members, ok := d.compositeTypes[currentType]
+		if !ok {
+			return 0, fmt.Errorf("%s cannot be resolved. %s is not a header or struct", keyPath, strings.Join(pathElements[:i+1], "."))
+		}
+		// Header validity can be used as 1 bit key
+		if element == p4IsValidMethodSuffix {
+			if _, isHeader := d.headerTypes[currentType]; isHeader && i == len(pathElements)-2 {
+				return 1, nil
+			}
+			return 0, fmt.Errorf("%s cannot be resolved. isValid() can only be applied on a header", keyPath)
+		}
+		memberType, ok := members[element]
+		if !ok {
+			return 0, fmt.Errorf("%s cannot be resolved. %s has no member %s", keyPath, currentType, element)
+		}
+		switch {
+		case memberType == "varbit" || memberType == "stack":
+			return 0, fmt.Errorf("%s cannot be used as key. varbit fields and header stacks are not supported", currentPath)
+		case strings.HasPrefix(memberType, "bit<"):
+			if i != len(pathElements)-2 {
+				return 0, fmt.Errorf("%s cannot be resolved. %s is a field and not a header or struct", keyPath, currentPath)
+			}
+			width, _ := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(memberType, "bit<"), ">"), 10, 32)
+			return uint(width), nil
+		}
+		if width, ok := d.widths[memberType]; ok {
+			if i != len(pathElements)-2 {
+				return 0, fmt.Errorf("%s cannot be resolved. %s is a field and not a header or struct", keyPath, currentPath)
+			}
+			return width, nil
+		}
+		currentType = memberType
+	}
+
+	return 0, fmt.Errorf("%s is a header or struct and not a field. Define a field of it as key", keyPath)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package generator
+
+import "testing"
+
+const testP4Headers = `
+#define VLAN_ID_WIDTH 12
+typedef bit<48> mac_addr_t;
+typedef bit<32> ipv4_addr_t;
+
+header ethernet_h {
+    mac_addr_t dst_addr;
+    mac_addr_t src_addr;
+    bit<16> ether_type;
+}
+
+header vlan_h {
+    bit<3> pcp;
+    bit<1> cfi;
+    bit<VLAN_ID_WIDTH> vid;
+}
+
+/* IPv4 header without options */
+header ipv4_h {
+    bit<4> version;
+    bit<4> ihl;
+    bit<8> diffserv;
+    bit<16> total_len;
+    bit<16> identification;
+    bit<3> flags;
+    bit<13> frag_offset;
+    bit<8> ttl;
+    bit<8> protocol;
+    bit<16> hdr_checksum;
+    ipv4_addr_t src_addr;
+    ipv4_addr_t dst_addr;
+    varbit<320> options;
+}
+
+struct ingress_headers_t {
+    ethernet_h ethernet;
+    vlan_h[2] vlan;
+    @pa_no_overlay("ingress", "hdr.ipv4.ttl") ipv4_h ipv4; // with annotation
+}
+`
+
+func TestGetKeyWidth(t *testing.T) {
+	definitions := &P4HeaderDefinitions{
+		compositeTypes: make(map[string]map[string]string),
+		headerTypes:    make(map[string]struct{}),
+		widths:         map[string]uint{"bool": 1},
+	}
+	if err := definitions.parse(testP4Headers); err != nil {
+		t.Fatalf("Cannot parse headers: %v", err)
+	}
+	if !definitions.HasType("ingress_headers_t") {
+		t.Fatalf("ingress_headers_t has not been parsed")
+	}
+
+	tests := []struct {
+		key   string
+		width uint
+		valid bool
+	}{
+		{"hdr.ipv4.protocol", 8, true},
+		{"hdr.ipv4.dst_addr", 32, true},
+		{"hdr.ethernet.src_addr", 48, true},
+		{"hdr.ipv4.isValid()", 1, true},
+		{"hdr.ipv4.dstAddr", 0, false},
+		{"hdr.ipv4.options", 0, false},
+		{"hdr.vlan.vid", 0, false},
+		{"hdr.ipv4", 0, false},
+		{"hdr.ipv4.ttl.foo", 0, false},
+		{"hdr", 0, false},
+	}
+	for _, test := range tests {
+		width, err := definitions.GetKeyWidth("ingress_headers_t", test.key)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", test.key, err)
+			continue
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected an error, got width %d", test.key, width)
+			continue
+		}
+		if width != test.width {
+			t.Errorf("%s: expected width %d, got %d", test.key, test.width, width)
+		}
+	}
+}
@@ -10,18 +10,19 @@
 */
 
 // Initialize pifina meta data fields to default values.
+{{- if gt .SessionIdPadding 0 }}
+#define pifina_ig_parser_init(meta) meta = {{"{{"}}false, 0, 0}, 0, 0}
+#define pifina_eg_parser_init(packet,meta) meta = {{"{{"}}false, 0, 0}, 0}; \
+{{- else }}
 #define pifina_ig_parser_init(meta) meta = {{"{{"}}false, 0}, 0, 0}
 #define pifina_eg_parser_init(packet,meta) meta = {{"{{"}}false, 0}, 0}; \
+{{- end }}
                                     packet.extract(meta.pfControl)
 
 /**
 * Pifina Ingress Start probe
 */
 control PfIngressStartProbe(in {{ .IngressHeaderType }} hdr, inout pf_ingress_metadata_t meta) {
-    // Counter attached to Match action. 
-    // Spec: 2 entries per RAM word, 28 bit packet counter width & 36 bit byte counter width
-    DirectCounter<bit<36>>(CounterType_t.PACKETS_AND_BYTES) pfIngressStartCounter;
-
     // Header byte counter before TM
     @name("PF_INGRESS_START_HDR_SIZE")
     Register<bit<32>, pf_stats_width_t>(PF_TABLE_SIZE, 0) pfIngressStartByteRegister; 
@@ -32,36 +33,56 @@ control PfIngressStartProbe(in {{ .IngressHeaderType }} hdr, inout pf_ingress_me
         }
     };
 
-    // sessionId is a number from 0-127 as table contains only 128 entries. Needs to be unique.
-    action pf_start_ingress_measure(pf_stats_width_t sessionId) {
+    action pf_count_ingress_start_hdr() {
+        // Trigger header size counter
+        pfIngressStartByteRegisterAction.execute(meta.pfControl.pfSessionId);
+    }
+{{ range .SelectorTables }}
+    // Counter attached to Match action. 
+    // Spec: 2 entries per RAM word, 28 bit packet counter width & 36 bit byte counter width
+    DirectCounter<bit<36>>(CounterType_t.PACKETS_AND_BYTES) pfIngressStartCounter{{ if .Name }}_{{ .Name }}{{ end }};
+
+    // sessionId is a number from 1 to PF_TABLE_SIZE-1 as table contains only PF_TABLE_SIZE entries. Needs to be unique across all selector tables.
+    action pf_start_ingress_measure{{ if .Name }}_{{ .Name }}{{ end }}(pf_stats_width_t sessionId) {
         // Trigger direct counter
-        pfIngressStartCounter.count();
+        pfIngressStartCounter{{ if .Name }}_{{ .Name }}{{ end }}.count();
         // Flag the packet for further processing.
         meta.pfControl.setValid();
         meta.pfControl.pfIsMatch = true;
         meta.pfControl.pfSessionId = sessionId;
-        // Trigger header size counter
-        pfIngressStartByteRegisterAction.execute(sessionId);
     }
 
-    @name("PF_INGRESS_MATCH_CNT")
-    table pf_ig_start_selector {
+    @name("{{ .ControlPlaneName }}")
+    table pf_ig_start_selector{{ if .Name }}_{{ .Name }}{{ end }} {
         // Keys are dynamically generated by pifina-cli
         key = {
             {{ range .MatchKeys }}{{ .Name }}: {{ .MatchType }};
             {{end}}
         }
         actions = {
-            pf_start_ingress_measure;
+            pf_start_ingress_measure{{ if .Name }}_{{ .Name }}{{ end }};
         }
         // Attach direct packet and byte counter
-        counters = pfIngressStartCounter;
+        counters = pfIngressStartCounter{{ if .Name }}_{{ .Name }}{{ end }};
         size = PF_TABLE_SIZE;
     }
-
+{{ end }}
     apply {
         // Start Ingress measurement
-        pf_ig_start_selector.apply();
+        // Selector tables are applied in order of their priority. Only the first matching table measures the packet.
+        {{- range $i, $tbl := .SelectorTables }}
+        {{- if isLast $i $.SelectorTables }}
+        {{ indent $i }}pf_ig_start_selector{{ if $tbl.Name }}_{{ $tbl.Name }}{{ end }}.apply();
+        {{- else }}
+        {{ indent $i }}if (!pf_ig_start_selector{{ if $tbl.Name }}_{{ $tbl.Name }}{{ end }}.apply().hit) {
+        {{- end }}
+        {{- end }}
+        {{- range closingLevels .SelectorTables }}
+        {{ indent . }}}
+        {{- end }}
+        if (meta.pfControl.pfIsMatch == true) {
+            pf_count_ingress_start_hdr();
+        }
     }
 }
 
@@ -140,8 +161,8 @@ control PfEgressStartProbe(in {{ .EgressHeaderType }} hdr, inout pf_egress_metad
     Counter<bit<36>, pf_stats_width_t>(PF_TABLE_SIZE, CounterType_t.PACKETS_AND_BYTES) pfEgressStartCounter;
 
     action pf_start_egress_measure() {
-        // Decrement 1 byte overhead from bridge header
-        pfEgressStartCounter.count(meta.pfControl.pfSessionId, 1);
+        // Decrement overhead from bridge header
+        pfEgressStartCounter.count(meta.pfControl.pfSessionId, PF_BRIDGE_HDR_SIZE);
     }
     
     apply {
@@ -178,6 +199,329 @@ control PfEgressEndProbe(in {{ .EgressHeaderType }} hdr, inout pf_egress_metadat
         }
     }
 }
+{{- if .PacketSizeBuckets }}
+
+/**
+* Pifina Egress packet size histogram probe
+*/
+control PfEgressPktSizeProbe(in pf_egress_metadata_t meta, in egress_intrinsic_metadata_t eg_intr_md) {
+    bit<16> pfPacketSize = 0;
+    pf_pkt_size_bucket_t pfBucketId = 0;
+
+    // Packet counter per sessionId and packet size bucket
+    @name("PF_EGRESS_PKT_SIZE_HIST")
+    Register<bit<32>, pf_pkt_size_hist_index_t>(PF_PKT_SIZE_HIST_SIZE, 0) pfPktSizeHistRegister; 
+    RegisterAction<bit<32>, pf_pkt_size_hist_index_t, void>(pfPktSizeHistRegister) pfPktSizeHistRegisterAction = {
+        void apply(inout bit<32> pktCount) {
+            pktCount = pktCount + 1;
+        }
+    };
+
+    action pf_set_pkt_size_bucket(pf_pkt_size_bucket_t bucketId) {
+        pfBucketId = bucketId;
+    }
+
+    // Bucket boundaries are defined by pifina-cli. The control plane reads them from this table.
+    @name("PF_EGRESS_PKT_SIZE_BUCKET")
+    table pf_eg_pkt_size_bucket {
+        key = {
+            pfPacketSize: range;
+        }
+        actions = {
+            pf_set_pkt_size_bucket;
+        }
+        const entries = {
+            {{ range .PacketSizeBuckets }}{{ .Low }} .. {{ .High }} : pf_set_pkt_size_bucket({{ .Id }});
+            {{end}}
+        }
+        size = {{ len .PacketSizeBuckets }};
+    }
+
+    apply {
+        if (meta.pfControl.pfIsMatch == true) {
+            // Remove overhead from bridge header
+            pfPacketSize = eg_intr_md.pkt_length - PF_BRIDGE_HDR_SIZE;
+            pf_eg_pkt_size_bucket.apply();
+            pfPktSizeHistRegisterAction.execute(pfBucketId ++ meta.pfControl.pfSessionId);
+        }
+    }
+}
+{{- end }}
+{{- if .DropProbe }}
+
+/**
+* Pifina Ingress drop probe
+* Needs to be applied as last operation in ingress after drop_ctl has been set.
+{{- if .DropReasonWidth }}
+* dropReason is a code of the user application from 0 to 2^PF_DROP_REASON_WIDTH-1 describing why the packet has been dropped.
+{{- end }}
+*/
+control PfIngressDropProbe(in pf_ingress_metadata_t meta, in ingress_intrinsic_metadata_for_deparser_t ig_dprsr_md{{ if .DropReasonWidth }}, in pf_drop_reason_t dropReason{{ end }}) {
+    // Packets dropped by the ingress deparser
+    @name("PF_INGRESS_DROP_CNT")
+    Counter<bit<32>, pf_stats_width_t>(PF_TABLE_SIZE, CounterType_t.PACKETS) pfIngressDropCounter;
+{{- if .DropReasonWidth }}
+    // Packets dropped by the ingress deparser per drop reason
+    @name("PF_INGRESS_DROP_REASON_CNT")
+    Counter<bit<32>, pf_drop_reason_index_t>(PF_DROP_REASON_SIZE, CounterType_t.PACKETS) pfIngressDropReasonCounter;
+{{- end }}
+
+    action pf_count_ingress_drop() {
+        pfIngressDropCounter.count(meta.pfControl.pfSessionId);
+{{- if .DropReasonWidth }}
+        pfIngressDropReasonCounter.count(dropReason ++ meta.pfControl.pfSessionId);
+{{- end }}
+    }
+
+    apply {
+        if (meta.pfControl.pfIsMatch == true && ig_dprsr_md.drop_ctl[0:0] == 1) {
+            pf_count_ingress_drop();
+        }
+    }
+}
+
+/**
+* Pifina Egress drop probe
+* Needs to be applied as last operation in egress after drop_ctl has been set.
+{{- if .DropReasonWidth }}
+* dropReason is a code of the user application from 0 to 2^PF_DROP_REASON_WIDTH-1 describing why the packet has been dropped.
+{{- end }}
+*/
+control PfEgressDropProbe(in pf_egress_metadata_t meta, in egress_intrinsic_metadata_for_deparser_t eg_dprsr_md{{ if .DropReasonWidth }}, in pf_drop_reason_t dropReason{{ end }}) {
+    // Packets dropped by the egress deparser
+    @name("PF_EGRESS_DROP_CNT")
+    Counter<bit<32>, pf_stats_width_t>(PF_TABLE_SIZE, CounterType_t.PACKETS) pfEgressDropCounter;
+{{- if .DropReasonWidth }}
+    // Packets dropped by the egress deparser per drop reason
+    @name("PF_EGRESS_DROP_REASON_CNT")
+    Counter<bit<32>, pf_drop_reason_index_t>(PF_DROP_REASON_SIZE, CounterType_t.PACKETS) pfEgressDropReasonCounter;
+{{- end }}
+
+    action pf_count_egress_drop() {
+        pfEgressDropCounter.count(meta.pfControl.pfSessionId);
+{{- if .DropReasonWidth }}
+        pfEgressDropReasonCounter.count(dropReason ++ meta.pfControl.pfSessionId);
+{{- end }}
+    }
+
+    apply {
+        if (meta.pfControl.pfIsMatch == true && eg_dprsr_md.drop_ctl[0:0] == 1) {
+            pf_count_egress_drop();
+        }
+    }
+}
+{{- end }}
+{{- if .BurstThreshold }}
+
+/**
+* Pifina Egress microburst probe
+* Tracks the peak queue depth and counts packets, which have been enqueued above a threshold.
+*/
+control PfEgressBurstProbe(in pf_egress_metadata_t meta, in egress_intrinsic_metadata_t eg_intr_md) {
+    bit<19> pfBurstThreshold = 0;
+    bit<19> pfQdepthAboveThreshold = 0;
+
+    action pf_set_burst_threshold(bit<19> threshold) {
+        pfBurstThreshold = threshold;
+    }
+
+    // Threshold is set by the control plane as default action
+    @name("PF_EGRESS_BURST_THRESHOLD")
+    table pf_burst_threshold {
+        actions = {
+            pf_set_burst_threshold;
+        }
+        default_action = pf_set_burst_threshold(PF_BURST_THRESHOLD);
+        size = 1;
+    }
+
+    // Peak queue depth in cells
+    @name("PF_EGRESS_BURST_MAX_QDEPTH")
+    Register<bit<32>, pf_stats_width_t>(PF_TABLE_SIZE, 0) pfMaxQdepthRegister; 
+    RegisterAction<bit<32>, pf_stats_width_t, void>(pfMaxQdepthRegister) pfMaxQdepthRegisterAction = {
+        void apply(inout bit<32> value) {
+            if ((bit<32>) eg_intr_md.deq_qdepth > value) {
+                value = (bit<32>) eg_intr_md.deq_qdepth;
+            }
+        }
+    };
+
+    // Packet counter above threshold
+    @name("PF_EGRESS_BURST_CNT")
+    Register<bit<32>, pf_stats_width_t>(PF_TABLE_SIZE, 0) pfBurstCntRegister; 
+    RegisterAction<bit<32>, pf_stats_width_t, void>(pfBurstCntRegister) pfBurstCntRegisterAction = {
+        void apply(inout bit<32> value) {
+            value = value + 1;
+        }
+    };
+
+    apply {
+        if (meta.pfControl.pfIsMatch == true) {
+            pf_burst_threshold.apply();
+            // Saturating subtraction is 0 if the queue depth is below the threshold
+            pfQdepthAboveThreshold = eg_intr_md.deq_qdepth |-| pfBurstThreshold;
+            pfMaxQdepthRegisterAction.execute(meta.pfControl.pfSessionId);
+            if (pfQdepthAboveThreshold != 0) {
+                pfBurstCntRegisterAction.execute(meta.pfControl.pfSessionId);
+            }
+        }
+    }
+}
+{{- end }}
+{{- with .HeavyHitter }}
+
+/**
+* Pifina Ingress heavy hitter probe
+* Count-Min sketch with 3 rows per session. The flow with the highest estimate is stored in a slot selected by a separate hash.
+*/
+control PfIngressHeavyHitterProbe(in {{ $.IngressHeaderType }} hdr, in pf_ingress_metadata_t meta) {
+    bit<PF_HH_SKETCH_WIDTH> pfSketchIdx0 = 0;
+    bit<PF_HH_SKETCH_WIDTH> pfSketchIdx1 = 0;
+    bit<PF_HH_SKETCH_WIDTH> pfSketchIdx2 = 0;
+    bit<PF_HH_SLOT_WIDTH> pfSlotIdx = 0;
+    bit<32> pfEstimate0 = 0;
+    bit<32> pfEstimate1 = 0;
+    bit<32> pfEstimate2 = 0;
+    bit<32> pfEstimate = 0;
+    bit<1> pfIsTopFlow = 0;
+
+    // Independent hash functions for each sketch row and the slots
+    CRCPolynomial<bit<32>>(32w0x04C11DB7, true, false, false, 32w0xFFFFFFFF, 32w0xFFFFFFFF) pfCrc32;
+    CRCPolynomial<bit<32>>(32w0x1EDC6F41, true, false, false, 32w0xFFFFFFFF, 32w0xFFFFFFFF) pfCrc32c;
+    CRCPolynomial<bit<32>>(32w0x741B8CD7, true, false, false, 32w0xFFFFFFFF, 32w0xFFFFFFFF) pfCrc32k;
+    CRCPolynomial<bit<32>>(32w0x814141AB, true, false, false, 32w0xFFFFFFFF, 32w0xFFFFFFFF) pfCrc32q;
+    Hash<bit<PF_HH_SKETCH_WIDTH>>(HashAlgorithm_t.CUSTOM, pfCrc32) pfSketchHash0;
+    Hash<bit<PF_HH_SKETCH_WIDTH>>(HashAlgorithm_t.CUSTOM, pfCrc32c) pfSketchHash1;
+    Hash<bit<PF_HH_SKETCH_WIDTH>>(HashAlgorithm_t.CUSTOM, pfCrc32k) pfSketchHash2;
+    Hash<bit<PF_HH_SLOT_WIDTH>>(HashAlgorithm_t.CUSTOM, pfCrc32q) pfSlotHash;
+
+    @name("PF_HH_SKETCH_0")
+    Register<bit<32>, pf_hh_sketch_index_t>(PF_HH_SKETCH_SIZE, 0) pfSketchRow0; 
+    RegisterAction<bit<32>, pf_hh_sketch_index_t, bit<32>>(pfSketchRow0) pfSketchRow0Action = {
+        void apply(inout bit<32> value, out bit<32> result) {
+            value = value + 1;
+            result = value;
+        }
+    };
+
+    @name("PF_HH_SKETCH_1")
+    Register<bit<32>, pf_hh_sketch_index_t>(PF_HH_SKETCH_SIZE, 0) pfSketchRow1; 
+    RegisterAction<bit<32>, pf_hh_sketch_index_t, bit<32>>(pfSketchRow1) pfSketchRow1Action = {
+        void apply(inout bit<32> value, out bit<32> result) {
+            value = value + 1;
+            result = value;
+        }
+    };
+
+    @name("PF_HH_SKETCH_2")
+    Register<bit<32>, pf_hh_sketch_index_t>(PF_HH_SKETCH_SIZE, 0) pfSketchRow2; 
+    RegisterAction<bit<32>, pf_hh_sketch_index_t, bit<32>>(pfSketchRow2) pfSketchRow2Action = {
+        void apply(inout bit<32> value, out bit<32> result) {
+            value = value + 1;
+            result = value;
+        }
+    };
+
+    // Highest estimate seen in a slot
+    @name("PF_HH_SLOT_CNT")
+    Register<bit<32>, pf_hh_slot_index_t>(PF_HH_SLOT_SIZE, 0) pfSlotCount; 
+    RegisterAction<bit<32>, pf_hh_slot_index_t, bit<1>>(pfSlotCount) pfSlotCountAction = {
+        void apply(inout bit<32> value, out bit<1> result) {
+            result = 0;
+            if (pfEstimate > value) {
+                value = pfEstimate;
+                result = 1;
+            }
+        }
+    };
+{{ range .Keys }}
+    // Flow key {{ .Name }} of the flow with the highest estimate in a slot
+    @name("{{ .ControlPlaneName }}")
+    Register<bit<32>, pf_hh_slot_index_t>(PF_HH_SLOT_SIZE, 0) pfSlotKey{{ .ControlPlaneName }}; 
+    RegisterAction<bit<32>, pf_hh_slot_index_t, void>(pfSlotKey{{ .ControlPlaneName }}) pfSlotKey{{ .ControlPlaneName }}Action = {
+        void apply(inout bit<32> value) {
+            value = (bit<32>) {{ .Name }};
+        }
+    };
+{{ end }}
+    apply {
+        if (meta.pfControl.pfIsMatch == true) {
+            pfSketchIdx0 = pfSketchHash0.get({ {{ range $i, $k := .Keys }}{{ if $i }}, {{ end }}{{ $k.Name }}{{ end }} });
+            pfSketchIdx1 = pfSketchHash1.get({ {{ range $i, $k := .Keys }}{{ if $i }}, {{ end }}{{ $k.Name }}{{ end }} });
+            pfSketchIdx2 = pfSketchHash2.get({ {{ range $i, $k := .Keys }}{{ if $i }}, {{ end }}{{ $k.Name }}{{ end }} });
+            pfSlotIdx = pfSlotHash.get({ {{ range $i, $k := .Keys }}{{ if $i }}, {{ end }}{{ $k.Name }}{{ end }} });
+            pfEstimate0 = pfSketchRow0Action.execute(meta.pfControl.pfSessionId ++ pfSketchIdx0);
+            pfEstimate1 = pfSketchRow1Action.execute(meta.pfControl.pfSessionId ++ pfSketchIdx1);
+            pfEstimate2 = pfSketchRow2Action.execute(meta.pfControl.pfSessionId ++ pfSketchIdx2);
+            // Count-Min estimate is the minimum of all rows
+            pfEstimate = pfEstimate0;
+            if (pfEstimate1 < pfEstimate) {
+                pfEstimate = pfEstimate1;
+            }
+            if (pfEstimate2 < pfEstimate) {
+                pfEstimate = pfEstimate2;
+            }
+            pfIsTopFlow = pfSlotCountAction.execute(meta.pfControl.pfSessionId ++ pfSlotIdx);
+            // Store the flow key, if the current flow has the highest estimate in the slot
+            if (pfIsTopFlow == 1) {
+                {{- range .Keys }}
+                pfSlotKey{{ .ControlPlaneName }}Action.execute(meta.pfControl.pfSessionId ++ pfSlotIdx);
+                {{- end }}
+            }
+        }
+    }
+}
+{{- end }}
+{{- with .FlowDiscovery }}
+
+/**
+* Pifina Ingress flow discovery probe
+* Marks the first packet of a flow, which is not matched by any selector rule, to be reported as digest.
+* The filter of already reported flows is cleared by the controller in every discovery interval.
+*/
+control PfIngressFlowDiscoveryProbe(in {{ $.IngressHeaderType }} hdr, in pf_ingress_metadata_t meta, inout ingress_intrinsic_metadata_for_deparser_t ig_dprsr_md) {
+    pf_discovery_filter_index_t pfFilterIdx = 0;
+    bit<1> pfIsReported = 0;
+
+    CRCPolynomial<bit<32>>(32w0x04C11DB7, true, false, false, 32w0xFFFFFFFF, 32w0xFFFFFFFF) pfCrc32;
+    Hash<pf_discovery_filter_index_t>(HashAlgorithm_t.CUSTOM, pfCrc32) pfFilterHash;
+
+    // Flows, which have been reported in the current discovery interval
+    @name("PF_DISCOVERY_FILTER")
+    Register<bit<1>, pf_discovery_filter_index_t>(PF_DISCOVERY_FILTER_SIZE, 0) pfDiscoveryFilter; 
+    RegisterAction<bit<1>, pf_discovery_filter_index_t, bit<1>>(pfDiscoveryFilter) pfDiscoveryFilterAction = {
+        void apply(inout bit<1> value, out bit<1> result) {
+            result = value;
+            value = 1;
+        }
+    };
+
+    apply {
+        if (meta.pfControl.pfIsMatch == false && {{ .Filter }}) {
+            pfFilterIdx = pfFilterHash.get({ {{ range $i, $k := .Keys }}{{ if $i }}, {{ end }}{{ $k.Name }}{{ end }} });
+            pfIsReported = pfDiscoveryFilterAction.execute(pfFilterIdx);
+            if (pfIsReported == 0) {
+                ig_dprsr_md.digest_type = PF_DIGEST_TYPE_NEW_FLOW;
+            }
+        }
+    }
+}
+
+/**
+* Pifina Ingress flow discovery digest
+* Sends the flow key of a new flow to the controller. Needs to be applied in the ingress deparser.
+*/
+control PfIngressFlowDigest(in {{ $.IngressHeaderType }} hdr, in ingress_intrinsic_metadata_for_deparser_t ig_dprsr_md) {
+    @name("PF_FLOW_DIGEST")
+    Digest<pf_flow_digest_t>() pfFlowDigest;
+
+    apply {
+        if (ig_dprsr_md.digest_type == PF_DIGEST_TYPE_NEW_FLOW) {
+            pfFlowDigest.pack({ {{ range $i, $k := .Keys }}{{ if $i }}, {{ end }}{{ $k.Name }}{{ end }} });
+        }
+    }
+}
+{{- end }}
 {{ range .ExtraProbeList }}
 /**
 * Pifina {{ .Type }} Extra probe {{ .Name }}
@@ -10,14 +10,72 @@
 */
 
 
-// Size of lookup table in bits. 7 bits = 127 entries
-#define PF_TABLE_SIZE_WIDTH 7
+// Size of lookup table in bits. {{ .SessionIdWidth }} bits = 2^{{ .SessionIdWidth }} entries. sessionId 0 is reserved.
+#define PF_TABLE_SIZE_WIDTH {{ .SessionIdWidth }}
 #define PF_TABLE_SIZE 1<<PF_TABLE_SIZE_WIDTH
+// Size of the bridge header pf_control_t in bytes
+#define PF_BRIDGE_HDR_SIZE {{ .BridgeHeaderSize }}
 
 typedef bit<PF_TABLE_SIZE_WIDTH> pf_stats_width_t;
+{{- if .PacketSizeBuckets }}
+
+// Packet size histogram with {{ len .PacketSizeBuckets }} buckets. The register is indexed by bucketId ++ sessionId
+#define PF_PKT_SIZE_BUCKET_WIDTH {{ .PacketSizeBucketWidth }}
+#define PF_PKT_SIZE_HIST_SIZE 1<<(PF_TABLE_SIZE_WIDTH + PF_PKT_SIZE_BUCKET_WIDTH)
+
+typedef bit<PF_PKT_SIZE_BUCKET_WIDTH> pf_pkt_size_bucket_t;
+typedef bit<(PF_TABLE_SIZE_WIDTH + PF_PKT_SIZE_BUCKET_WIDTH)> pf_pkt_size_hist_index_t;
+{{- end }}
+{{- if .DropReasonWidth }}
+
+// Drop reasons of the drop probes. The reason counters are indexed by dropReason ++ sessionId
+#define PF_DROP_REASON_WIDTH {{ .DropReasonWidth }}
+#define PF_DROP_REASON_SIZE 1<<(PF_TABLE_SIZE_WIDTH + PF_DROP_REASON_WIDTH)
+
+typedef bit<PF_DROP_REASON_WIDTH> pf_drop_reason_t;
+typedef bit<(PF_TABLE_SIZE_WIDTH + PF_DROP_REASON_WIDTH)> pf_drop_reason_index_t;
+{{- end }}
+{{- with .HeavyHitter }}
+
+// Heavy hitter probe. Registers are indexed by sessionId ++ hash
+#define PF_HH_SKETCH_WIDTH {{ .SketchWidth }}
+#define PF_HH_SKETCH_SIZE 1<<(PF_TABLE_SIZE_WIDTH + PF_HH_SKETCH_WIDTH)
+#define PF_HH_SLOT_WIDTH {{ .SlotWidth }}
+#define PF_HH_SLOT_SIZE 1<<(PF_TABLE_SIZE_WIDTH + PF_HH_SLOT_WIDTH)
+
+typedef bit<(PF_TABLE_SIZE_WIDTH + PF_HH_SKETCH_WIDTH)> pf_hh_sketch_index_t;
+typedef bit<(PF_TABLE_SIZE_WIDTH + PF_HH_SLOT_WIDTH)> pf_hh_slot_index_t;
+{{- end }}
+{{- if .BurstThreshold }}
+
+// Default queue depth threshold in cells of the microburst probe. Can be changed at runtime.
+#define PF_BURST_THRESHOLD {{ .BurstThreshold }}
+{{- end }}
+{{- with .FlowDiscovery }}
+
+// Flow discovery digest. The filter of already reported flows is indexed by a hash of the flow key
+#define PF_DISCOVERY_FILTER_WIDTH {{ .FilterWidth }}
+#define PF_DISCOVERY_FILTER_SIZE 1<<PF_DISCOVERY_FILTER_WIDTH
+// Change the digest type, if your application already uses it for its own digest
+#ifndef PF_DIGEST_TYPE_NEW_FLOW
+#define PF_DIGEST_TYPE_NEW_FLOW 1
+#endif
+
+typedef bit<PF_DISCOVERY_FILTER_WIDTH> pf_discovery_filter_index_t;
+
+// Flow key of a new flow sent to the controller
+struct pf_flow_digest_t {
+{{- range .Keys }}
+    bit<{{ .Width }}> {{ .ControlPlaneName }};
+{{- end }}
+}
+{{- end }}
 
 header pf_control_t {
     bool pfIsMatch;
+{{- if gt .SessionIdPadding 0 }}
+    bit<{{ .SessionIdPadding }}> pfPadding;
+{{- end }}
     pf_stats_width_t pfSessionId;
 }
 
@@ -148,6 +148,15 @@ control SwitchIngress(inout ingress_headers_t hdr,
     // PIFINA: Step 6: Initialize Pifina control blocks
     PfIngressStartProbe() pfIngressStartProbe;
     PfIngressEndProbe() pfIngressEndProbe;
+    {{- if .DropProbe }}
+    PfIngressDropProbe() pfIngressDropProbe;
+    {{- end }}
+    {{- if .HeavyHitter }}
+    PfIngressHeavyHitterProbe() pfIngressHeavyHitterProbe;
+    {{- end }}
+    {{- if .FlowDiscovery }}
+    PfIngressFlowDiscoveryProbe() pfIngressFlowDiscoveryProbe;
+    {{- end }}
     {{ range .ExtraProbeList }}
     {{- if eq .Type "INGRESS" }}
     PfIngressExtraProbe{{ .Name }}() pfIngressExtraProbe{{ .Name }};
@@ -157,6 +166,14 @@ control SwitchIngress(inout ingress_headers_t hdr,
     apply {
         // PIFINA: Step 7: Start Ingress measurement
         pfIngressStartProbe.apply(hdr, meta.pf_meta);
+        {{- if .HeavyHitter }}
+        // PIFINA: Estimate top flows of each session
+        pfIngressHeavyHitterProbe.apply(hdr, meta.pf_meta);
+        {{- end }}
+        {{- if .FlowDiscovery }}
+        // PIFINA: Report the first packet of flows without a selector rule
+        pfIngressFlowDiscoveryProbe.apply(hdr, meta.pf_meta, ig_dprsr_md);
+        {{- end }}
 
         // YOUR CODE COMES HERE
         {{ range .ExtraProbeList }}
@@ -167,6 +184,15 @@ control SwitchIngress(inout ingress_headers_t hdr,
 
         // PIFINA: Step 8: last Operation
         pfIngressEndProbe.apply(hdr, meta.pf_meta, ig_intr_md);
+        {{- if .DropProbe }}
+        // PIFINA: Count dropped packets after drop_ctl has been set
+        {{- if .DropReasonWidth }}
+        // Replace 0 by the drop reason of your application
+        pfIngressDropProbe.apply(meta.pf_meta, ig_dprsr_md, 0);
+        {{- else }}
+        pfIngressDropProbe.apply(meta.pf_meta, ig_dprsr_md);
+        {{- end }}
+        {{- end }}
     }
 }
 
@@ -185,6 +211,15 @@ control SwitchEgress(inout egress_headers_t hdr,
     // PIFINA: Step 9: Initialize PIFINA probes
     PfEgressStartProbe() pfEgressStartProbe;
     PfEgressEndProbe() pfEgressEndProbe;
+    {{- if .PacketSizeBuckets }}
+    PfEgressPktSizeProbe() pfEgressPktSizeProbe;
+    {{- end }}
+    {{- if .BurstThreshold }}
+    PfEgressBurstProbe() pfEgressBurstProbe;
+    {{- end }}
+    {{- if .DropProbe }}
+    PfEgressDropProbe() pfEgressDropProbe;
+    {{- end }}
     {{ range .ExtraProbeList }}
     {{- if eq .Type "EGRESS" }}
     PfEgressExtraProbe{{ .Name }}() pfEgressExtraProbe{{ .Name }};
@@ -194,6 +229,14 @@ control SwitchEgress(inout egress_headers_t hdr,
     apply {
         // PIFINA: Step 9: Start Egress measurement. Using count leaving TM
         pfEgressStartProbe.apply(hdr, meta.pf_meta, eg_intr_md);
+        {{- if .PacketSizeBuckets }}
+        // PIFINA: Count packet in the packet size histogram
+        pfEgressPktSizeProbe.apply(meta.pf_meta, eg_intr_md);
+        {{- end }}
+        {{- if .BurstThreshold }}
+        // PIFINA: Track queue depth for microburst detection
+        pfEgressBurstProbe.apply(meta.pf_meta, eg_intr_md);
+        {{- end }}
 
         // YOUR CODE COMES HERE
 
@@ -206,6 +249,15 @@ control SwitchEgress(inout egress_headers_t hdr,
 
         // PIFINA: Step 10: End Egress measurement. Using count from deparser
         pfEgressEndProbe.apply(hdr, meta.pf_meta);
+        {{- if .DropProbe }}
+        // PIFINA: Count dropped packets after drop_ctl has been set
+        {{- if .DropReasonWidth }}
+        // Replace 0 by the drop reason of your application
+        pfEgressDropProbe.apply(meta.pf_meta, eg_dprsr_md, 0);
+        {{- else }}
+        pfEgressDropProbe.apply(meta.pf_meta, eg_dprsr_md);
+        {{- end }}
+        {{- end }}
     }
 }
 
@@ -220,6 +272,9 @@ control SwitchIngressDeparser(packet_out packet,
                               in ingress_intrinsic_metadata_for_deparser_t ig_dprsr_md) {
 
     Checksum() checksumfct;
+    {{- if .FlowDiscovery }}
+    PfIngressFlowDigest() pfIngressFlowDigest;
+    {{- end }}
 
     apply {
         //Update IPv4 checksum
@@ -237,6 +292,12 @@ control SwitchIngressDeparser(packet_out packet,
             hdr.ipv4.dstAddr 
         });
 
+        {{- if .FlowDiscovery }}
+
+        // PIFINA: Send new flows to the controller
+        pfIngressFlowDigest.apply(hdr, ig_dprsr_md);
+        {{- end }}
+
         // PIFINA: Step 11: Emit Pifina bridge header
         packet.emit(meta.pf_meta.pfControl);
         packet.emit(hdr.ethernet);
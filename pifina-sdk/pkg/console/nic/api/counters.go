@@ -0,0 +1,81 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func (s *NicApiServer) HandleCountersReq(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.GetMonitoredCounters(rw, r)
+	case http.MethodPost:
+		s.AddCounter(rw, r)
+	case http.MethodDelete:
+		s.RemoveCounter(rw, r)
+	case http.MethodOptions:
+		rw.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *NicApiServer) GetMonitoredCounters(rw http.ResponseWriter, r *http.Request) {
+	counters := s.collector.GetMonitoredCounters()
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(counters)
+}
+
+func (s *NicApiServer) GetAvailableCounters(rw http.ResponseWriter, r *http.Request) {
+	counters := s.collector.GetAvailableCounters()
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(counters)
+}
+
+func (s *NicApiServer) AddCounter(rw http.ResponseWriter, r *http.Request) {
+	var counter model.NicCounter
+	err := json.NewDecoder(r.Body).Decode(&counter)
+	if err != nil || counter.Name == "" {
+		s.logger.Warn("Invalid request body for AddCounter API request", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid request body. Counter name is missing", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	err = s.collector.AddCounter(&counter)
+	if err != nil {
+		s.logger.Error("Adding new counter failed", "counter", counter.Name, "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+}
+
+func (s *NicApiServer) RemoveCounter(rw http.ResponseWriter, r *http.Request) {
+	var counter model.NicCounter
+	err := json.NewDecoder(r.Body).Decode(&counter)
+	if err != nil {
+		s.logger.Warn("Invalid request body for RemoveCounter API request", "err", err)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = s.collector.RemoveCounter(&counter)
+	if err != nil {
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func (s *NicApiServer) HandleDevicesReq(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.GetMonitoredDevices(rw, r)
+	case http.MethodPost:
+		s.AddDevice(rw, r)
+	case http.MethodDelete:
+		s.RemoveDevice(rw, r)
+	case http.MethodOptions:
+		rw.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *NicApiServer) GetMonitoredDevices(rw http.ResponseWriter, r *http.Request) {
+	devices := s.collector.GetMonitoredDevices()
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(devices)
+}
+
+func (s *NicApiServer) GetAvailableDevices(rw http.ResponseWriter, r *http.Request) {
+	devices, err := s.collector.GetAvailableDevices()
+	if err != nil {
+		s.logger.Error("Cannot retrieve available devices", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusInternalServerError}
+		rw.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(devices)
+}
+
+func (s *NicApiServer) AddDevice(rw http.ResponseWriter, r *http.Request) {
+	var device model.NicDevice
+	err := json.NewDecoder(r.Body).Decode(&device)
+	if err != nil || device.Name == "" {
+		s.logger.Warn("Invalid request body for AddDevice API request", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid request body. Device name is missing", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	err = s.collector.AddDevice(device.Name)
+	if err != nil {
+		s.logger.Error("Adding new device failed", "dev", device.Name, "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+}
+
+func (s *NicApiServer) RemoveDevice(rw http.ResponseWriter, r *http.Request) {
+	var device model.NicDevice
+	err := json.NewDecoder(r.Body).Decode(&device)
+	if err != nil {
+		s.logger.Warn("Invalid request body for RemoveDevice API request", "err", err)
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = s.collector.RemoveDevice(device.Name)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		var notFoundErr *model.ErrNameNotFound
+		if errors.As(err, &notFoundErr) {
+			statusCode = http.StatusNotFound
+		}
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: statusCode}
+		rw.WriteHeader(statusCode)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
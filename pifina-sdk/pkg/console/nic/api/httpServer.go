@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/thushjandan/pifina/pkg/console/nic/collector"
+)
+
+type NicApiServer struct {
+	logger    hclog.Logger
+	port      string
+	server    *http.Server
+	collector *collector.EndpointCollector
+}
+
+func NewNicApiServer(logger hclog.Logger, port string, collector *collector.EndpointCollector) *NicApiServer {
+	return &NicApiServer{
+		logger:    logger.Named("api"),
+		collector: collector,
+		port:      port,
+	}
+}
+
+func (s *NicApiServer) StartWebServer(ctx context.Context) {
+	// Create a new Mux and set the handler
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/devices", middlewareCORS(http.HandlerFunc(s.HandleDevicesReq)))
+	mux.Handle("/api/v1/devices/available", middlewareCORS(http.HandlerFunc(s.GetAvailableDevices)))
+	mux.Handle("/api/v1/counters", middlewareCORS(http.HandlerFunc(s.HandleCountersReq)))
+	mux.Handle("/api/v1/counters/available", middlewareCORS(http.HandlerFunc(s.GetAvailableCounters)))
+
+	s.server = &http.Server{
+		Addr:    s.port,
+		Handler: mux,
+	}
+
+	s.logger.Info("Starting API server")
+	if err := s.server.ListenAndServe(); err != http.ErrServerClosed {
+		s.logger.Error("Cannot start http server", "err", err)
+	}
+}
+
+func (s *NicApiServer) Shutdown() {
+	// The daemon can be stopped, before the API server has been started
+	if s.server == nil {
+		return
+	}
+	s.logger.Info("Stopping API server")
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.server.Shutdown(timeoutCtx); err != nil {
+		s.logger.Error("Webserver shutdown failed", "err", err)
+	}
+}
+
+func middlewareCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("Access-Control-Allow-Origin", "*")
+		rw.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE")
+		rw.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		next.ServeHTTP(rw, r)
+	})
+}
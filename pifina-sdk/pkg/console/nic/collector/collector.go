@@ -6,7 +6,12 @@
 package collector
 
 import (
+	"context"
+	"regexp"
+	"sync"
+
 	"github.com/hashicorp/go-hclog"
+	"github.com/safchain/ethtool"
 	"github.com/thushjandan/pifina/pkg/console/nic/dataplane/neohost"
 	"github.com/thushjandan/pifina/pkg/model"
 	"github.com/thushjandan/pifina/pkg/sink"
@@ -17,8 +22,20 @@ type EndpointCollector struct {
 	sampleInterval          int
 	metricSinkChan          chan *model.SinkEmitCommand
 	neohost                 *neohost.NeoHostDriver
+	disableNeoHost          bool
 	neoHostCounterNameCache map[string]empty
+	ethtoolCounterNameCache map[string]empty
+	availableCounterCache   map[string]map[string]empty // counter source => all counter names seen on the devices
+	counterCacheLock        sync.RWMutex
 	ethNameCache            map[string]string
+	ethNameCacheLock        sync.RWMutex
+	ethIndexedCounterCache  []*ethIndexedCounter
+	ethIndexedNameCache     map[string]empty // Enabled per-queue and per-priority counters by metric name
+	ethtoolHandle           *ethtool.Ethtool
+	monitoredDevices        map[string]context.CancelFunc
+	monitoredDevicesLock    sync.Mutex
+	ctx                     context.Context
+	wg                      *sync.WaitGroup
 	sink                    *sink.Sink
 }
 
@@ -29,11 +46,18 @@ type EndpointCollectorOptions struct {
 	SDKPath           string
 	NEOMode           string
 	NEOPort           int
+	DisableNeoHost    bool
 	TelemetryEndpoint string
 }
 
 type empty struct{}
 
+// Compiled version of model.EthtoolIndexedCounter
+type ethIndexedCounter struct {
+	pattern    *regexp.Regexp
+	metricName string
+}
+
 func NewEndpointCollector(options *EndpointCollectorOptions) *EndpointCollector {
 	neohost := neohost.NewNeoHostDriver(&neohost.NeoHostDriverOptions{
 		Logger:  options.Logger.Named("neohost"),
@@ -47,12 +71,43 @@ func NewEndpointCollector(options *EndpointCollectorOptions) *EndpointCollector
 	for _, counterName := range model.NEOHOST_COUNTERS {
 		counterNameCache[counterName] = empty{}
 	}
+	ethtoolCounterNameCache := make(map[string]empty)
+	for _, counterName := range model.ETHTOOL_COUNTERS {
+		ethtoolCounterNameCache[counterName] = empty{}
+	}
+	// Compile the patterns of per-queue and per-priority counters once. All of them are enabled by default.
+	indexedCounterCache := make([]*ethIndexedCounter, 0, len(model.ETHTOOL_INDEXED_COUNTERS))
+	indexedNameCache := make(map[string]empty)
+	for _, indexedCounter := range model.ETHTOOL_INDEXED_COUNTERS {
+		indexedCounterCache = append(indexedCounterCache, &ethIndexedCounter{
+			pattern:    regexp.MustCompile(indexedCounter.Pattern),
+			metricName: indexedCounter.MetricName,
+		})
+		indexedNameCache[indexedCounter.MetricName] = empty{}
+	}
+	// Counter names seen on the devices. Used to list counters, which can be monitored.
+	// The per-queue and per-priority counters are known upfront.
+	availableIndexedNames := make(map[string]empty)
+	for name := range indexedNameCache {
+		availableIndexedNames[name] = empty{}
+	}
+	availableCounterCache := map[string]map[string]empty{
+		model.NIC_COUNTER_SOURCE_ETHTOOL:         make(map[string]empty),
+		model.NIC_COUNTER_SOURCE_NEOHOST:         make(map[string]empty),
+		model.NIC_COUNTER_SOURCE_ETHTOOL_INDEXED: availableIndexedNames,
+	}
 	return &EndpointCollector{
 		logger:                  options.Logger.Named("endpoint-collector"),
 		sampleInterval:          options.SampleInterval,
 		neohost:                 neohost,
+		disableNeoHost:          options.DisableNeoHost,
 		neoHostCounterNameCache: counterNameCache,
+		ethtoolCounterNameCache: ethtoolCounterNameCache,
+		availableCounterCache:   availableCounterCache,
 		metricSinkChan:          options.MetricSinkChan,
 		ethNameCache:            make(map[string]string), // EthName <-> user defined Name
+		ethIndexedCounterCache:  indexedCounterCache,
+		ethIndexedNameCache:     indexedNameCache,
+		monitoredDevices:        make(map[string]context.CancelFunc),
 	}
 }
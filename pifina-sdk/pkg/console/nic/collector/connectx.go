@@ -72,7 +72,9 @@ func (c *EndpointCollector) StartMlxPerfCountersCollection(ctx context.Context,
 			return &model.ErrNameNotFound{Entity: targetDevice, Msg: "Device not found"}
 		}
 		ethNames = append(ethNames, ethName)
+		c.ethNameCacheLock.Lock()
 		c.ethNameCache[ethName] = targetDevice
+		c.ethNameCacheLock.Unlock()
 	}
 
 	for device, uid := range devUids {
@@ -134,8 +136,11 @@ func (c *EndpointCollector) getMlxPerformanceCountersHandler(targetDevice string
 func (c *EndpointCollector) transformNeoHostMetrics(perfCounters *model.NeoHostPerfCounterResult) []*model.MetricItem {
 	metrics := make([]*model.MetricItem, 0)
 	timeNow := time.Now()
+	c.counterCacheLock.Lock()
+	defer c.counterCacheLock.Unlock()
 	for i := range perfCounters.Counters {
 		counterName := perfCounters.Counters[i].Counter.Name
+		c.availableCounterCache[model.NIC_COUNTER_SOURCE_NEOHOST][counterName] = empty{}
 		if _, ok := c.neoHostCounterNameCache[counterName]; ok {
 			metrics = append(metrics, &model.MetricItem{
 				MetricName:  counterName,
@@ -148,6 +153,7 @@ func (c *EndpointCollector) transformNeoHostMetrics(perfCounters *model.NeoHostP
 	}
 	for i := range perfCounters.Analysis {
 		counterName := perfCounters.Analysis[i].AnalysisAttribute.Name
+		c.availableCounterCache[model.NIC_COUNTER_SOURCE_NEOHOST][counterName] = empty{}
 		if _, ok := c.neoHostCounterNameCache[counterName]; ok {
 			metrics = append(metrics, &model.MetricItem{
 				MetricName:  counterName,
@@ -0,0 +1,257 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/safchain/ethtool"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Starts the collector as a long running daemon.
+// Devices and counters can be added or removed at runtime afterwards.
+func (c *EndpointCollector) StartDaemon(ctx context.Context, wg *sync.WaitGroup, targetDevices []string) error {
+	ethtoolHandle, err := ethtool.NewEthtool()
+	if err != nil {
+		return err
+	}
+	c.ethtoolHandle = ethtoolHandle
+	c.ctx = ctx
+	c.wg = wg
+
+	for i := range targetDevices {
+		if err := c.AddDevice(targetDevices[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns true if metrics are collected from NEO-Host SDK in addition to ethtool
+func (c *EndpointCollector) isNeoHostEnabled() bool {
+	return !c.disableNeoHost && c.IsNeoSDKExists()
+}
+
+// Starts the collection of a new device.
+// Device can be a dev-uid, ibdevice name or iface name.
+func (c *EndpointCollector) AddDevice(targetDevice string) error {
+	if c.ctx == nil {
+		return &model.ErrNotReady{Msg: "Collector daemon has not been started"}
+	}
+
+	c.monitoredDevicesLock.Lock()
+	defer c.monitoredDevicesLock.Unlock()
+
+	// if device is already monitored, just ignore and exit already here.
+	if _, ok := c.monitoredDevices[targetDevice]; ok {
+		return nil
+	}
+
+	if c.isNeoHostEnabled() {
+		result, err := c.neohost.ListMlxNetworkCards()
+		if err != nil {
+			return err
+		}
+		uid, ok := c.findDevUid(result, targetDevice)
+		if !ok {
+			return &model.ErrNameNotFound{Entity: targetDevice, Msg: "Device not found"}
+		}
+		ethName, ok := c.findEthNameFromMlxDev(result, targetDevice)
+		if !ok {
+			return &model.ErrNameNotFound{Entity: targetDevice, Msg: "Device not found"}
+		}
+		c.ethNameCacheLock.Lock()
+		c.ethNameCache[ethName] = targetDevice
+		c.ethNameCacheLock.Unlock()
+
+		deviceCtx, cancel := context.WithCancel(c.ctx)
+		c.monitoredDevices[targetDevice] = cancel
+		c.wg.Add(2)
+		go c.GetMlxPerformanceCountersThread(deviceCtx, c.wg, targetDevice, uid)
+		go c.GetEthtoolStatsThread(deviceCtx, c.wg, c.ethtoolHandle, ethName)
+	} else {
+		// Just collect from ethtool
+		exists, err := c.IsEthInterfaceExists(targetDevice)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return &model.ErrNameNotFound{Entity: targetDevice, Msg: "Interface does not exists"}
+		}
+
+		deviceCtx, cancel := context.WithCancel(c.ctx)
+		c.monitoredDevices[targetDevice] = cancel
+		c.wg.Add(1)
+		go c.GetEthtoolStatsThread(deviceCtx, c.wg, c.ethtoolHandle, targetDevice)
+	}
+	c.logger.Info("Device has been added to the collection", "dev", targetDevice)
+
+	return nil
+}
+
+// Stops the collection of a monitored device
+func (c *EndpointCollector) RemoveDevice(targetDevice string) error {
+	c.monitoredDevicesLock.Lock()
+	defer c.monitoredDevicesLock.Unlock()
+
+	cancel, ok := c.monitoredDevices[targetDevice]
+	if !ok {
+		return &model.ErrNameNotFound{Entity: targetDevice, Msg: "Device is not monitored"}
+	}
+	// Terminate collector threads of the device
+	cancel()
+	delete(c.monitoredDevices, targetDevice)
+	c.logger.Info("Device has been removed from the collection", "dev", targetDevice)
+
+	return nil
+}
+
+// Returns all devices, which are currently monitored.
+func (c *EndpointCollector) GetMonitoredDevices() []*model.NicDevice {
+	c.monitoredDevicesLock.Lock()
+	defer c.monitoredDevicesLock.Unlock()
+
+	devices := make([]*model.NicDevice, 0, len(c.monitoredDevices))
+	for name := range c.monitoredDevices {
+		devices = append(devices, &model.NicDevice{Name: name})
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+
+	return devices
+}
+
+// Returns all devices on this machine, which can be monitored.
+// Mellanox NICs are listed through NEO-Host if available, otherwise all network interfaces are returned.
+func (c *EndpointCollector) GetAvailableDevices() ([]*model.NicDevice, error) {
+	devices := make([]*model.NicDevice, 0)
+	if c.isNeoHostEnabled() {
+		result, err := c.neohost.ListMlxNetworkCards()
+		if err != nil {
+			return nil, err
+		}
+		for i := range result.Results {
+			for _, port := range result.Results[i].Ports {
+				device := &model.NicDevice{
+					Name:     port.IbDevice,
+					UID:      port.UID,
+					IbDevice: port.IbDevice,
+				}
+				if len(port.PhysicalFunctions) > 0 && len(port.PhysicalFunctions[0].NetworkInterfaces) > 0 {
+					device.IfName = port.PhysicalFunctions[0].NetworkInterfaces[0]
+				}
+				devices = append(devices, device)
+			}
+		}
+		return devices, nil
+	}
+
+	allInterfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range allInterfaces {
+		devices = append(devices, &model.NicDevice{Name: allInterfaces[i].Name, IfName: allInterfaces[i].Name})
+	}
+
+	return devices, nil
+}
+
+// Returns all counters, which are currently collected.
+func (c *EndpointCollector) GetMonitoredCounters() []*model.NicCounter {
+	c.counterCacheLock.RLock()
+	defer c.counterCacheLock.RUnlock()
+
+	counters := make([]*model.NicCounter, 0, len(c.ethtoolCounterNameCache)+len(c.ethIndexedNameCache)+len(c.neoHostCounterNameCache))
+	for name := range c.ethtoolCounterNameCache {
+		counters = append(counters, &model.NicCounter{Name: name, Source: model.NIC_COUNTER_SOURCE_ETHTOOL})
+	}
+	for name := range c.ethIndexedNameCache {
+		counters = append(counters, &model.NicCounter{Name: name, Source: model.NIC_COUNTER_SOURCE_ETHTOOL_INDEXED})
+	}
+	for name := range c.neoHostCounterNameCache {
+		counters = append(counters, &model.NicCounter{Name: name, Source: model.NIC_COUNTER_SOURCE_NEOHOST})
+	}
+	sortNicCounters(counters)
+
+	return counters
+}
+
+// Returns all counters, which have been reported by the monitored devices so far.
+func (c *EndpointCollector) GetAvailableCounters() []*model.NicCounter {
+	c.counterCacheLock.RLock()
+	defer c.counterCacheLock.RUnlock()
+
+	counters := make([]*model.NicCounter, 0)
+	for source, names := range c.availableCounterCache {
+		for name := range names {
+			counters = append(counters, &model.NicCounter{Name: name, Source: source})
+		}
+	}
+	sortNicCounters(counters)
+
+	return counters
+}
+
+// Adds a counter to the collection of all monitored devices.
+func (c *EndpointCollector) AddCounter(counter *model.NicCounter) error {
+	c.counterCacheLock.Lock()
+	defer c.counterCacheLock.Unlock()
+
+	counterNameCache, err := c.getCounterNameCacheBySource(counter.Source)
+	if err != nil {
+		return err
+	}
+	// Check if counter exists on the device. Skip the check if no metrics have been collected yet.
+	availableCounters := c.availableCounterCache[counter.Source]
+	if _, ok := availableCounters[counter.Name]; !ok && len(availableCounters) > 0 {
+		return &model.ErrNameNotFound{Entity: counter.Name, Msg: "Counter has not been reported by any device"}
+	}
+	counterNameCache[counter.Name] = empty{}
+
+	return nil
+}
+
+// Removes a counter from the collection of all monitored devices.
+func (c *EndpointCollector) RemoveCounter(counter *model.NicCounter) error {
+	c.counterCacheLock.Lock()
+	defer c.counterCacheLock.Unlock()
+
+	counterNameCache, err := c.getCounterNameCacheBySource(counter.Source)
+	if err != nil {
+		return err
+	}
+	delete(counterNameCache, counter.Name)
+
+	return nil
+}
+
+// Caller needs to hold the counterCacheLock
+func (c *EndpointCollector) getCounterNameCacheBySource(source string) (map[string]empty, error) {
+	switch source {
+	case model.NIC_COUNTER_SOURCE_ETHTOOL:
+		return c.ethtoolCounterNameCache, nil
+	case model.NIC_COUNTER_SOURCE_NEOHOST:
+		return c.neoHostCounterNameCache, nil
+	case model.NIC_COUNTER_SOURCE_ETHTOOL_INDEXED:
+		return c.ethIndexedNameCache, nil
+	default:
+		return nil, &model.ErrNameNotFound{Entity: source, Msg: "Unknown counter source"}
+	}
+}
+
+func sortNicCounters(counters []*model.NicCounter) {
+	sort.Slice(counters, func(i, j int) bool {
+		if counters[i].Source != counters[j].Source {
+			return counters[i].Source < counters[j].Source
+		}
+		return counters[i].Name < counters[j].Name
+	})
+}
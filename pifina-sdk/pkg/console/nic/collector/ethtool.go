@@ -8,6 +8,7 @@ package collector
 import (
 	"context"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
@@ -56,7 +57,9 @@ func (c *EndpointCollector) getEthtoolStats(ethtoolHandle *ethtool.Ethtool, devi
 		c.logger.Warn("Cannot retrieve ethtool stats from NIC", "dev", deviceName, "err", err)
 		return
 	}
+	c.ethNameCacheLock.RLock()
 	friendlyName, ok := c.ethNameCache[deviceName]
+	c.ethNameCacheLock.RUnlock()
 	// If name has not been found in cache, then use the eth name
 	if !ok {
 		friendlyName = deviceName
@@ -71,10 +74,14 @@ func (c *EndpointCollector) getEthtoolStats(ethtoolHandle *ethtool.Ethtool, devi
 func (c *EndpointCollector) transformEthtoolMetrics(ethtoolStats map[string]uint64) []*model.MetricItem {
 	timeNow := time.Now()
 	metrics := make([]*model.MetricItem, 0)
-	for i := range model.ETHTOOL_COUNTERS {
-		if statVal, ok := ethtoolStats[model.ETHTOOL_COUNTERS[i]]; ok {
+	c.counterCacheLock.Lock()
+	for statName := range ethtoolStats {
+		c.availableCounterCache[model.NIC_COUNTER_SOURCE_ETHTOOL][statName] = empty{}
+	}
+	for counterName := range c.ethtoolCounterNameCache {
+		if statVal, ok := ethtoolStats[counterName]; ok {
 			metrics = append(metrics, &model.MetricItem{
-				MetricName:  model.ETHTOOL_COUNTERS[i],
+				MetricName:  counterName,
 				Value:       statVal,
 				LastUpdated: timeNow,
 				Type:        model.METRIC_EXT_VALUE,
@@ -82,6 +89,33 @@ func (c *EndpointCollector) transformEthtoolMetrics(ethtoolStats map[string]uint
 			})
 		}
 	}
+	// Per-queue and per-priority counters. The queue or priority index is used as SessionId
+	for statName, statVal := range ethtoolStats {
+		for _, indexedCounter := range c.ethIndexedCounterCache {
+			match := indexedCounter.pattern.FindStringSubmatch(statName)
+			if match == nil {
+				continue
+			}
+			// Counter has been disabled through the API
+			if _, ok := c.ethIndexedNameCache[indexedCounter.metricName]; !ok {
+				break
+			}
+			index, err := strconv.ParseUint(match[1], 10, 32)
+			if err != nil {
+				continue
+			}
+			metrics = append(metrics, &model.MetricItem{
+				MetricName:  indexedCounter.metricName,
+				Value:       statVal,
+				LastUpdated: timeNow,
+				Type:        model.METRIC_EXT_VALUE,
+				SessionId:   uint32(index),
+			})
+			break
+		}
+	}
+	c.counterCacheLock.Unlock()
+
 	return metrics
 }
 
@@ -14,6 +14,7 @@ import (
 	"syscall"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/thushjandan/pifina/pkg/console/nic/api"
 	"github.com/thushjandan/pifina/pkg/console/nic/collector"
 	"github.com/thushjandan/pifina/pkg/model"
 	"github.com/thushjandan/pifina/pkg/sink"
@@ -32,21 +33,9 @@ func ListMlxDevicesCliAction(cCtx *cli.Context) error {
 		return nil
 	}
 
-	neoMode := cCtx.String("neo-mode")
-	var neoPort int
-	switch neoMode {
-	case "shell":
-		neoMode = "--mode=shell"
-	case "socket":
-		neoMode = "--mode=socket"
-		neoPort = cCtx.Int("neo-port")
-		if neoPort == 0 {
-			logger.Error("Missing neo-port parameter.")
-			os.Exit(1)
-			return nil
-		}
-	default:
-		logger.Error("Invalid neo-mode parameter given. Needs to be either shell or socket", "mode", neoMode)
+	// Validate neo-mode parameter
+	neoMode, neoPort, ok := parseNeoModeFlags(cCtx, logger)
+	if !ok {
 		os.Exit(1)
 		return nil
 	}
@@ -81,22 +70,8 @@ func CollectNICPerfCounterCliAction(cCtx *cli.Context) error {
 	}
 
 	// Validate neo-mode parameter
-	neoMode := cCtx.String("neo-mode")
-	var neoPort int
-
-	switch neoMode {
-	case "shell":
-		neoMode = "--mode=shell"
-	case "socket":
-		neoMode = "--mode=socket"
-		neoPort = cCtx.Int("neo-port")
-		if neoPort == 0 {
-			logger.Error("Missing neo-port parameter.")
-			os.Exit(1)
-			return nil
-		}
-	default:
-		logger.Error("Invalid neo-mode parameter given. Needs to be either shell or socket", "mode", neoMode)
+	neoMode, neoPort, ok := parseNeoModeFlags(cCtx, logger)
+	if !ok {
 		os.Exit(1)
 		return nil
 	}
@@ -166,3 +141,104 @@ func CollectNICPerfCounterCliAction(cCtx *cli.Context) error {
 
 	return nil
 }
+
+func StartNICDaemonCliAction(cCtx *cli.Context) error {
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "PIFINA-cli",
+		Level: hclog.LevelFromString(cCtx.String("level")),
+		Color: hclog.AutoColor,
+	})
+
+	// Check if user is root
+	if os.Getuid() != 0 {
+		logger.Error("Need to be root. Please use sudo or run as root.")
+		os.Exit(1)
+		return nil
+	}
+
+	// Validate neo-mode parameter
+	neoMode, neoPort, ok := parseNeoModeFlags(cCtx, logger)
+	if !ok {
+		os.Exit(1)
+		return nil
+	}
+
+	if _, _, err := net.SplitHostPort(cCtx.String("server")); err != nil {
+		logger.Error("Given server address is invalid", "err", err)
+		return err
+	}
+	apiPort, err := sink.ParseApiPort(cCtx.String("port"))
+	if err != nil {
+		logger.Error("Given listen address for the REST API is invalid", "err", err)
+		return err
+	}
+
+	// init signal handler
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	metricSinkChan := make(chan *model.SinkEmitCommand)
+
+	// Init sink
+	sink := sink.NewSink(logger, model.HOSTTYPE_NIC, cCtx.String("server"), uint32(cCtx.Uint("group-id")))
+	// The pifina server proxies the API requests of the web frontend to the daemon
+	sink.SetApiPort(apiPort)
+	wg.Add(1)
+
+	logger.Info("Starting sink...")
+	go sink.StartSink(ctx, &wg, metricSinkChan)
+
+	collector := collector.NewEndpointCollector(&collector.EndpointCollectorOptions{
+		Logger:         logger,
+		MetricSinkChan: metricSinkChan,
+		SampleInterval: cCtx.Int("sample-interval"),
+		SDKPath:        cCtx.String("sdk"),
+		NEOMode:        neoMode,
+		NEOPort:        neoPort,
+		DisableNeoHost: cCtx.Bool("disable-neohost"),
+	})
+
+	err = collector.StartDaemon(ctx, &wg, cCtx.StringSlice("dev"))
+	if err != nil {
+		logger.Error("Cannot start NIC collector daemon", "err", err)
+		cancel()
+		wg.Wait()
+		return err
+	}
+
+	apiServer := api.NewNicApiServer(logger, cCtx.String("port"), collector)
+	go apiServer.StartWebServer(ctx)
+
+	<-ctx.Done()
+	apiServer.Shutdown()
+
+	// Wait until all threads have terminated gracefully
+	wg.Wait()
+
+	return nil
+}
+
+// Validates the neo-mode and neo-port parameter and returns the arguments for NEO-Host SDK
+func parseNeoModeFlags(cCtx *cli.Context, logger hclog.Logger) (string, int, bool) {
+	neoMode := cCtx.String("neo-mode")
+	var neoPort int
+
+	switch neoMode {
+	case "shell":
+		neoMode = "--mode=shell"
+	case "socket":
+		neoMode = "--mode=socket"
+		neoPort = cCtx.Int("neo-port")
+		if neoPort == 0 {
+			logger.Error("Missing neo-port parameter.")
+			return "", 0, false
+		}
+	default:
+		logger.Error("Invalid neo-mode parameter given. Needs to be either shell or socket", "mode", neoMode)
+		return "", 0, false
+	}
+
+	return neoMode, neoPort, true
+}
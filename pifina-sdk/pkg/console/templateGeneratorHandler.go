@@ -7,8 +7,12 @@ package console
 
 import (
 	"fmt"
+	"math/bits"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/go-hclog"
@@ -18,12 +22,44 @@ import (
 )
 
 const (
-	P4_MATCH_LPM     = "lpm"
-	SESSION_ID_WIDTH = 7
+	P4_MATCH_LPM               = "lpm"
+	P4_MATCH_EXACT             = "exact"
+	SESSION_ID_WIDTH           = 7
+	SELECTOR_TABLE_NAME_PREFIX = "PF_INGRESS_MATCH_CNT"
+	// Upper bound of the key width in bits for all non-exact match keys of a single table,
+	// which are placed in TCAM on Tofino. Wider keys exhaust the TCAM resources of a stage.
+	P4_MAX_TCAM_KEY_WIDTH = 528
+	// Memory resources of a single MAU stage on Tofino used to estimate the stage usage of the session tables.
+	// A SRAM block has 1024 words with 128 bits. A TCAM block has 512 entries with 44 bits.
+	P4_SRAM_BLOCK_BITS       = 1024 * 128
+	P4_SRAM_BLOCKS_PER_STAGE = 80
+	P4_TCAM_BLOCK_ENTRIES    = 512
+	P4_TCAM_BLOCK_WIDTH      = 44
+	P4_TCAM_BLOCKS_PER_STAGE = 24
+	// Widest register cell indexed by the sessionId in the probes. e.g. timestamps of the jitter probe
+	P4_MAX_REGISTER_CELL_WIDTH = 64
+	// Packet length in the dataplane is a 16 bit value
+	P4_MAX_PACKET_SIZE = 65535
+	// Width of a register cell of the packet size histogram
+	P4_PKT_SIZE_HIST_CELL_WIDTH = 32
+	// A packet counter takes 64 bits in SRAM
+	P4_DROP_REASON_CELL_WIDTH = 64
+	// Upper bound of the drop reason width. Each reason multiplies the size of the reason counters.
+	P4_MAX_DROP_REASON_WIDTH = 4
+	// Registers of the heavy hitter probe have 32 bit cells. Hence a flow key can have at most 32 bits.
+	P4_HH_CELL_WIDTH     = 32
+	P4_HH_MAX_KEY_WIDTH  = P4_HH_CELL_WIDTH
+	HH_KEY_REGISTER_NAME = "PF_HH_KEY"
+	// Queue depth in the egress intrinsic metadata is a 19 bit value in cells
+	P4_MAX_QUEUE_DEPTH = 1<<19 - 1
+	// Upper bound of the hash width of the filter of already reported flows in the flow discovery digest.
+	// A filter with 2^20 single bit cells needs 8 SRAM blocks.
+	DISCOVERY_MAX_FILTER_WIDTH = 20
 )
 
 var (
-	P4_MATCH_TYPES = []string{"exact", "ternary", "lpm"}
+	P4_MATCH_TYPES         = []string{"exact", "ternary", "lpm", "range", "optional"}
+	P4_SELECTOR_NAME_REGEX = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
 )
 
 func CreateTemplateCliAction(cCtx *cli.Context) error {
@@ -31,13 +67,171 @@ func CreateTemplateCliAction(cCtx *cli.Context) error {
 	if len(keys) < 1 {
 		return cli.Exit("Define at least one header field as key", 1)
 	}
+	templateKeys, err := parseTemplateMatchKeys(keys)
+	if err != nil {
+		return err
+	}
+	// Default selector table defined by the -k flags
+	selectorTables := []*model.P4CodeTemplateSelectorTable{
+		{
+			ControlPlaneName: SELECTOR_TABLE_NAME_PREFIX,
+			Priority:         0,
+			MatchKeys:        templateKeys,
+		},
+	}
+	// Additional selector tables with their own set of keys
+	selectorNameCache := make(map[string]struct{})
+	for i, selectorDefinition := range cCtx.StringSlice("selector") {
+		selectorTable, err := parseTemplateSelectorTable(selectorDefinition, i+1)
+		if err != nil {
+			return err
+		}
+		if _, ok := selectorNameCache[selectorTable.Name]; ok {
+			return cli.Exit(fmt.Sprintf("Selector table %s has been defined more than once", selectorTable.Name), 1)
+		}
+		selectorNameCache[selectorTable.Name] = struct{}{}
+		selectorTables = append(selectorTables, selectorTable)
+	}
+	// Tables are applied in order of their priority in the dataplane
+	sort.SliceStable(selectorTables, func(i, j int) bool { return selectorTables[i].Priority < selectorTables[j].Priority })
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "PIFINA-cli",
+		Level: hclog.LevelFromString("info"),
+		Color: hclog.AutoColor,
+	})
+
+	// Optional heavy hitter probe
+	heavyHitter, err := parseHeavyHitterKeys(cCtx.StringSlice("hh-key"), cCtx.Uint("hh-sketch-width"), cCtx.Uint("hh-slot-width"))
+	if err != nil {
+		return err
+	}
+
+	// Optional flow discovery digest
+	flowDiscovery, err := parseFlowDiscoveryKeys(cCtx.StringSlice("discovery-key"), cCtx.String("discovery-filter"), cCtx.Uint("discovery-filter-width"))
+	if err != nil {
+		return err
+	}
+
+	// Validate the keys against the header definitions of the user before any file is written.
+	if hdrFiles := cCtx.StringSlice("hdr-file"); len(hdrFiles) > 0 {
+		if err := validateTemplateKeys(logger, hdrFiles, cCtx.String("ig-hdr"), selectorTables, heavyHitter, flowDiscovery); err != nil {
+			return err
+		}
+	} else if heavyHitter != nil {
+		// Keys are stored in 32 bit registers. Wider keys would be truncated silently.
+		return cli.Exit("The heavy hitter probe needs the width of its keys. Use --hdr-file to define the P4 file with your header definitions", 1)
+	} else if flowDiscovery != nil {
+		// The fields of the digest need a fixed width
+		return cli.Exit("The flow discovery digest needs the width of its keys. Use --hdr-file to define the P4 file with your header definitions", 1)
+	}
+
+	sessionIdWidth := cCtx.Uint("session-width")
+	if err := validateSessionIdWidth(logger, sessionIdWidth, selectorTables); err != nil {
+		return err
+	}
+
+	// Optional packet size histogram probe
+	packetSizeBuckets, packetSizeBucketWidth, err := parsePacketSizeBuckets(cCtx.IntSlice("pkt-size-hist"), sessionIdWidth)
+	if err != nil {
+		return err
+	}
+	if len(packetSizeBuckets) > 0 {
+		logger.Info("Packet size histogram probe will be generated", "buckets", len(packetSizeBuckets))
+	}
+	if heavyHitter != nil {
+		if err := validateHeavyHitterSize(heavyHitter, sessionIdWidth); err != nil {
+			return err
+		}
+		logger.Info("Heavy hitter probe will be generated", "keys", len(heavyHitter.Keys), "sketchWidth", heavyHitter.SketchWidth, "slots", 1<<heavyHitter.SlotWidth)
+	}
+	dropReasonWidth, err := parseDropReasonWidth(cCtx.Bool("drop-probe"), cCtx.Uint("drop-reason-width"), sessionIdWidth)
+	if err != nil {
+		return err
+	}
+	if dropReasonWidth > 0 {
+		logger.Info("Drop probes will count the dropped packets per reason", "reasons", 1<<dropReasonWidth)
+	}
+	// Optional microburst probe
+	burstThreshold := cCtx.Uint("burst-threshold")
+	if burstThreshold > P4_MAX_QUEUE_DEPTH {
+		return cli.Exit(fmt.Sprintf("%d is an invalid burst threshold. Needs to be between 1 and %d cells", burstThreshold, P4_MAX_QUEUE_DEPTH), 1)
+	}
+	if burstThreshold > 0 {
+		logger.Info("Microburst probe will be generated", "threshold", burstThreshold)
+	}
+	if flowDiscovery != nil {
+		logger.Info("Flow discovery digest will be generated", "keys", len(flowDiscovery.Keys), "filter", flowDiscovery.Filter, "filterWidth", flowDiscovery.FilterWidth)
+	}
+
+	outputDir := filepath.Dir(cCtx.String("output"))
+
+	// Generate a list of requested additional header byte probes
+	extraProbes := make([]model.ExtraProbeTemplate, 0)
+	// additional ingress probes
+	for i := 1; i <= cCtx.Int("ig-probe"); i++ {
+		extraProbes = append(extraProbes, model.ExtraProbeTemplate{
+			Name: fmt.Sprintf("%02d", i),
+			Type: model.EXTRA_PROBE_TYPE_IG,
+		})
+	}
+	// additional egress probes
+	for i := 1; i <= cCtx.Int("eg-probe"); i++ {
+		extraProbes = append(extraProbes, model.ExtraProbeTemplate{
+			Name: fmt.Sprintf("%02d", i),
+			Type: model.EXTRA_PROBE_TYPE_EG,
+		})
+	}
+
+	// The bridge header contains a 1 bit flag and the sessionId and needs to be byte aligned.
+	bridgePadding := (8 - (1+sessionIdWidth)%8) % 8
+
+	templateOptions := &model.P4CodeTemplate{
+		SessionIdWidth:        sessionIdWidth,
+		SessionIdPadding:      bridgePadding,
+		BridgeHeaderSize:      (1 + sessionIdWidth + bridgePadding) / 8,
+		SelectorTables:        selectorTables,
+		IngressHeaderType:     cCtx.String("ig-hdr"),
+		EgressHeaderType:      cCtx.String("eg-hdr"),
+		ExtraProbeList:        extraProbes,
+		PacketSizeBuckets:     packetSizeBuckets,
+		PacketSizeBucketWidth: packetSizeBucketWidth,
+		DropProbe:             cCtx.Bool("drop-probe"),
+		DropReasonWidth:       dropReasonWidth,
+		HeavyHitter:           heavyHitter,
+		BurstThreshold:        burstThreshold,
+		FlowDiscovery:         flowDiscovery,
+	}
+
+	logger.Info("Generating files...")
+	// Generator template
+	if cCtx.Bool("gen-skeleton") {
+		// Generate a skeleton in a new folder
+		err = generator.GenerateSkeleton(logger, templateOptions, outputDir)
+	} else {
+		// Just generate pifina files.
+		err = generator.GenerateP4App(logger, templateOptions, outputDir)
+	}
+
+	if err != nil {
+		logger.Error("Error occured!", "err", err)
+		os.Exit(1)
+		return nil
+	}
+	logger.Info("All necessary PIFINA files have been generated. Include these files according to the manual in your P4 application source code.")
+
+	return nil
+}
+
+// Parses a list of header fields with their match type like hdr.ipv4.dstAddr:ternary
+func parseTemplateMatchKeys(keys []string) ([]*model.P4CodeTemplateKey, error) {
 	countLpm := 0
 	templateKeys := make([]*model.P4CodeTemplateKey, 0, len(keys))
 	for _, k := range keys {
 		keyObj := strings.Split(k, ":")
 		// key:matchtype => Exact two values after split
 		if len(keyObj) != 2 {
-			return cli.Exit(
+			return nil, cli.Exit(
 				fmt.Sprintf(
 					"%s is an invalid input. An header field needs be defined together with the corresponding match type (%s). Must be following format <key>:<type>",
 					k,
@@ -57,7 +251,7 @@ func CreateTemplateCliAction(cCtx *cli.Context) error {
 			}
 		}
 		if !validType {
-			return cli.Exit(
+			return nil, cli.Exit(
 				fmt.Sprintf(
 					"%s is an invalid match type. Following match types are supported %s",
 					matchType,
@@ -71,63 +265,341 @@ func CreateTemplateCliAction(cCtx *cli.Context) error {
 			countLpm++
 		}
 		if countLpm > 1 {
-			return cli.Exit("Cannot have more than one header field with match type LPM", 1)
+			return nil, cli.Exit("Cannot have more than one header field with match type LPM", 1)
 		}
 		templateKeys = append(templateKeys, &model.P4CodeTemplateKey{
 			Name:      matchKey,
 			MatchType: matchType,
 		})
 	}
-	logger := hclog.New(&hclog.LoggerOptions{
-		Name:  "PIFINA-cli",
-		Level: hclog.LevelFromString("info"),
-		Color: hclog.AutoColor,
-	})
 
-	outputDir := filepath.Dir(cCtx.String("output"))
+	return templateKeys, nil
+}
 
-	// Generate a list of requested additional header byte probes
-	extraProbes := make([]model.ExtraProbeTemplate, 0)
-	// additional ingress probes
-	for i := 1; i <= cCtx.Int("ig-probe"); i++ {
-		extraProbes = append(extraProbes, model.ExtraProbeTemplate{
-			Name: fmt.Sprintf("%02d", i),
-			Type: model.EXTRA_PROBE_TYPE_IG,
-		})
+// Parses an additional selector table definition.
+// Format: <name>[:<priority>]=<key>:<type>,<key>:<type>
+// e.g. roce:1=hdr.bth.dstQP:exact
+func parseTemplateSelectorTable(selectorDefinition string, defaultPriority int) (*model.P4CodeTemplateSelectorTable, error) {
+	selectorObj := strings.SplitN(selectorDefinition, "=", 2)
+	if len(selectorObj) != 2 || selectorObj[1] == "" {
+		return nil, cli.Exit(
+			fmt.Sprintf("%s is an invalid selector table definition. Must be following format <name>[:<priority>]=<key>:<type>,<key>:<type>", selectorDefinition), 1,
+		)
 	}
-	// additional egress probes
-	for i := 1; i <= cCtx.Int("eg-probe"); i++ {
-		extraProbes = append(extraProbes, model.ExtraProbeTemplate{
-			Name: fmt.Sprintf("%02d", i),
-			Type: model.EXTRA_PROBE_TYPE_EG,
+	selectorName := selectorObj[0]
+	priority := defaultPriority
+	if nameObj := strings.Split(selectorName, ":"); len(nameObj) == 2 {
+		selectorName = nameObj[0]
+		parsedPriority, err := strconv.Atoi(nameObj[1])
+		if err != nil || parsedPriority < 0 {
+			return nil, cli.Exit(fmt.Sprintf("%s is an invalid priority for selector table %s. Needs to be a positive number", nameObj[1], selectorName), 1)
+		}
+		priority = parsedPriority
+	}
+	if !P4_SELECTOR_NAME_REGEX.MatchString(selectorName) {
+		return nil, cli.Exit(
+			fmt.Sprintf("%s is an invalid selector table name. Only alphanumeric characters and underscores are allowed", selectorName), 1,
+		)
+	}
+	templateKeys, err := parseTemplateMatchKeys(strings.Split(selectorObj[1], ","))
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.P4CodeTemplateSelectorTable{
+		Name:             strings.ToLower(selectorName),
+		ControlPlaneName: fmt.Sprintf("%s_%s", SELECTOR_TABLE_NAME_PREFIX, strings.ToUpper(selectorName)),
+		Priority:         priority,
+		MatchKeys:        templateKeys,
+	}, nil
+}
+
+// Checks if every key exists in the header definitions of the user and if the key width can be placed in the dataplane.
+func validateTemplateKeys(logger hclog.Logger, hdrFiles []string, ingressHeaderType string, selectorTables []*model.P4CodeTemplateSelectorTable, heavyHitter *model.P4CodeTemplateHeavyHitter, flowDiscovery *model.P4CodeTemplateFlowDiscovery) error {
+	hdrDefinitions, err := generator.ParseP4HeaderFiles(hdrFiles)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Cannot parse P4 header file: %s", err), 1)
+	}
+	if !hdrDefinitions.HasType(ingressHeaderType) {
+		return cli.Exit(
+			fmt.Sprintf("Ingress header type %s cannot be found in %s. Use --ig-hdr to define the name of your ingress header struct", ingressHeaderType, strings.Join(hdrFiles, ", ")), 1,
+		)
+	}
+
+	for _, selectorTable := range selectorTables {
+		tcamKeyWidth := uint(0)
+		for _, key := range selectorTable.MatchKeys {
+			width, err := hdrDefinitions.GetKeyWidth(ingressHeaderType, key.Name)
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Invalid key in selector table %s: %s", selectorTable.ControlPlaneName, err), 1)
+			}
+			key.Width = width
+			if key.MatchType != P4_MATCH_EXACT {
+				tcamKeyWidth += width
+			}
+			logger.Info("Match key has been validated", "table", selectorTable.ControlPlaneName, "key", key.Name, "type", key.MatchType, "width", width)
+		}
+		if tcamKeyWidth > P4_MAX_TCAM_KEY_WIDTH {
+			return cli.Exit(
+				fmt.Sprintf(
+					"Selector table %s has %d bits of ternary, lpm, range or optional keys, but at most %d bits are supported. Use exact match for some keys or split them into multiple selector tables",
+					selectorTable.ControlPlaneName,
+					tcamKeyWidth,
+					P4_MAX_TCAM_KEY_WIDTH,
+				), 1,
+			)
+		}
+	}
+
+	if heavyHitter != nil {
+		for _, key := range heavyHitter.Keys {
+			width, err := hdrDefinitions.GetKeyWidth(ingressHeaderType, key.Name)
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Invalid heavy hitter key: %s", err), 1)
+			}
+			if width > P4_HH_MAX_KEY_WIDTH {
+				return cli.Exit(fmt.Sprintf("Heavy hitter key %s has %d bits, but at most %d bits are supported", key.Name, width, P4_HH_MAX_KEY_WIDTH), 1)
+			}
+			key.Width = width
+			logger.Info("Heavy hitter key has been validated", "key", key.Name, "width", width)
+		}
+	}
+
+	if flowDiscovery != nil {
+		for _, key := range flowDiscovery.Keys {
+			width, err := hdrDefinitions.GetKeyWidth(ingressHeaderType, key.Name)
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Invalid flow discovery key: %s", err), 1)
+			}
+			key.Width = width
+			logger.Info("Flow discovery key has been validated", "key", key.Name, "width", width)
+		}
+	}
+
+	return nil
+}
+
+// Checks if the registers and selector tables sized by the sessionId width fit into the memory of a stage.
+func validateSessionIdWidth(logger hclog.Logger, sessionIdWidth uint, selectorTables []*model.P4CodeTemplateSelectorTable) error {
+	if sessionIdWidth < 1 || sessionIdWidth > 32 {
+		return cli.Exit(fmt.Sprintf("%d is an invalid session width. Needs to be between 1 and 32 bits", sessionIdWidth), 1)
+	}
+	tableSize := uint64(1) << sessionIdWidth
+	// A register array cannot be split across stages.
+	registerBlocks := (tableSize*P4_MAX_REGISTER_CELL_WIDTH + P4_SRAM_BLOCK_BITS - 1) / P4_SRAM_BLOCK_BITS
+	if registerBlocks > P4_SRAM_BLOCKS_PER_STAGE {
+		return cli.Exit(
+			fmt.Sprintf(
+				"A session width of %d bits needs %d SRAM blocks for a single register, but a stage has only %d SRAM blocks. Reduce the session width",
+				sessionIdWidth,
+				registerBlocks,
+				P4_SRAM_BLOCKS_PER_STAGE,
+			), 1,
+		)
+	}
+	logger.Info("Session table size", "width", sessionIdWidth, "entries", tableSize, "maxSessions", tableSize-1, "sramBlocksPerRegister", registerBlocks)
+	if registerBlocks > P4_SRAM_BLOCKS_PER_STAGE/4 {
+		logger.Warn(
+			"Each register of the probes occupies a large share of the SRAM of a stage. The probes may need additional stages",
+			"sramBlocksPerRegister", registerBlocks,
+			"sramBlocksPerStage", P4_SRAM_BLOCKS_PER_STAGE,
+		)
+	}
+
+	for _, selectorTable := range selectorTables {
+		tcamKeyWidth := uint64(0)
+		for _, key := range selectorTable.MatchKeys {
+			if key.MatchType == P4_MATCH_EXACT {
+				continue
+			}
+			// Width is only known if the header file has been given. Assume that the key fits into a single block.
+			if key.Width == 0 {
+				tcamKeyWidth += P4_TCAM_BLOCK_WIDTH
+			} else {
+				tcamKeyWidth += uint64(key.Width)
+			}
+		}
+		if tcamKeyWidth == 0 {
+			continue
+		}
+		tcamBlocks := ((tableSize + P4_TCAM_BLOCK_ENTRIES - 1) / P4_TCAM_BLOCK_ENTRIES) * ((tcamKeyWidth + P4_TCAM_BLOCK_WIDTH - 1) / P4_TCAM_BLOCK_WIDTH)
+		if tcamBlocks > P4_TCAM_BLOCKS_PER_STAGE {
+			logger.Warn(
+				"Selector table does not fit into the TCAM of a single stage and will be split across multiple stages",
+				"table", selectorTable.ControlPlaneName,
+				"tcamBlocks", tcamBlocks,
+				"tcamBlocksPerStage", P4_TCAM_BLOCKS_PER_STAGE,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Creates the buckets of the packet size histogram from a list of upper boundaries like 64,128,1518.
+// An additional bucket is created for all packets larger than the last boundary.
+// Returns the buckets and the width of the bucket id in bits.
+func parsePacketSizeBuckets(boundaries []int, sessionIdWidth uint) ([]*model.P4CodeTemplatePacketSizeBucket, uint, error) {
+	if len(boundaries) == 0 {
+		return nil, 0, nil
+	}
+	buckets := make([]*model.P4CodeTemplatePacketSizeBucket, 0, len(boundaries)+1)
+	low := uint(0)
+	for i, boundary := range boundaries {
+		if boundary < 1 || boundary >= P4_MAX_PACKET_SIZE {
+			return nil, 0, cli.Exit(fmt.Sprintf("%d is an invalid packet size boundary. Needs to be between 1 and %d", boundary, P4_MAX_PACKET_SIZE-1), 1)
+		}
+		if uint(boundary) < low {
+			return nil, 0, cli.Exit("Packet size boundaries need to be in ascending order without duplicates", 1)
+		}
+		buckets = append(buckets, &model.P4CodeTemplatePacketSizeBucket{
+			Id:   uint(i),
+			Low:  low,
+			High: uint(boundary),
 		})
+		low = uint(boundary) + 1
 	}
+	// Bucket for all remaining packets
+	buckets = append(buckets, &model.P4CodeTemplatePacketSizeBucket{
+		Id:   uint(len(boundaries)),
+		Low:  low,
+		High: P4_MAX_PACKET_SIZE,
+	})
 
-	templateOptions := &model.P4CodeTemplate{
-		SessionIdWidth:    SESSION_ID_WIDTH,
-		MatchKeys:         templateKeys,
-		IngressHeaderType: cCtx.String("ig-hdr"),
-		EgressHeaderType:  cCtx.String("eg-hdr"),
-		ExtraProbeList:    extraProbes,
+	bucketWidth := uint(bits.Len(uint(len(buckets) - 1)))
+	// The histogram register is indexed by bucketId and sessionId together.
+	registerBlocks := ((uint64(1)<<(sessionIdWidth+bucketWidth))*P4_PKT_SIZE_HIST_CELL_WIDTH + P4_SRAM_BLOCK_BITS - 1) / P4_SRAM_BLOCK_BITS
+	if registerBlocks > P4_SRAM_BLOCKS_PER_STAGE {
+		return nil, 0, cli.Exit(
+			fmt.Sprintf(
+				"The packet size histogram with %d buckets needs %d SRAM blocks, but a stage has only %d SRAM blocks. Reduce the amount of buckets or the session width",
+				len(buckets),
+				registerBlocks,
+				P4_SRAM_BLOCKS_PER_STAGE,
+			), 1,
+		)
 	}
 
-	logger.Info("Generating files...")
-	// Generator template
-	var err error
-	if cCtx.Bool("gen-skeleton") {
-		// Generate a skeleton in a new folder
-		err = generator.GenerateSkeleton(logger, templateOptions, outputDir)
-	} else {
-		// Just generate pifina files.
-		err = generator.GenerateP4App(logger, templateOptions, outputDir)
+	return buckets, bucketWidth, nil
+}
+
+// Checks the width of the drop reason, which can only be used with the drop probes.
+// The reason counters are indexed by the drop reason and the sessionId together and need to fit into the SRAM of a stage.
+func parseDropReasonWidth(dropProbe bool, dropReasonWidth uint, sessionIdWidth uint) (uint, error) {
+	if dropReasonWidth == 0 {
+		return 0, nil
+	}
+	if !dropProbe {
+		return 0, cli.Exit("Drop reasons can only be counted by the drop probes. Use --drop-probe to generate them", 1)
+	}
+	if dropReasonWidth > P4_MAX_DROP_REASON_WIDTH {
+		return 0, cli.Exit(fmt.Sprintf("%d is an invalid drop reason width. Needs to be between 1 and %d bits", dropReasonWidth, P4_MAX_DROP_REASON_WIDTH), 1)
+	}
+	registerBlocks := ((uint64(1)<<(sessionIdWidth+dropReasonWidth))*P4_DROP_REASON_CELL_WIDTH + P4_SRAM_BLOCK_BITS - 1) / P4_SRAM_BLOCK_BITS
+	if registerBlocks > P4_SRAM_BLOCKS_PER_STAGE {
+		return 0, cli.Exit(
+			fmt.Sprintf(
+				"The drop reason counters with %d reasons need %d SRAM blocks, but a stage has only %d SRAM blocks. Reduce the drop reason width or the session width",
+				1<<dropReasonWidth,
+				registerBlocks,
+				P4_SRAM_BLOCKS_PER_STAGE,
+			), 1,
+		)
 	}
 
-	if err != nil {
-		logger.Error("Error occured!", "err", err)
-		os.Exit(1)
-		return nil
+	return dropReasonWidth, nil
+}
+
+// Parses the flow key of the heavy hitter probe like hdr.ipv4.src_addr,hdr.ipv4.dst_addr.
+// Returns nil if no key has been defined.
+func parseHeavyHitterKeys(keys []string, sketchWidth uint, slotWidth uint) (*model.P4CodeTemplateHeavyHitter, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if sketchWidth < 1 || sketchWidth > 16 {
+		return nil, cli.Exit(fmt.Sprintf("%d is an invalid sketch width. Needs to be between 1 and 16 bits", sketchWidth), 1)
+	}
+	if slotWidth < 1 || slotWidth > 8 {
+		return nil, cli.Exit(fmt.Sprintf("%d is an invalid slot width. Needs to be between 1 and 8 bits", slotWidth), 1)
+	}
+	heavyHitter := &model.P4CodeTemplateHeavyHitter{
+		Keys:        make([]*model.P4CodeTemplateHeavyHitterKey, 0, len(keys)),
+		SketchWidth: sketchWidth,
+		SlotWidth:   slotWidth,
+	}
+	for i, key := range keys {
+		pathElements := strings.Split(key, ".")
+		if len(pathElements) < 2 || strings.HasSuffix(key, "isValid()") {
+			return nil, cli.Exit(fmt.Sprintf("%s is an invalid heavy hitter key. A key needs to be a field of a header like hdr.ipv4.src_addr", key), 1)
+		}
+		// Register name without the parameter name. e.g. hdr.ipv4.src_addr => PF_HH_KEY_0_ipv4_src_addr
+		heavyHitter.Keys = append(heavyHitter.Keys, &model.P4CodeTemplateHeavyHitterKey{
+			Name:             key,
+			ControlPlaneName: fmt.Sprintf("%s_%d_%s", HH_KEY_REGISTER_NAME, i, strings.Join(pathElements[1:], "_")),
+		})
+	}
+
+	return heavyHitter, nil
+}
+
+// Checks if the sketch registers of the heavy hitter probe fit into the SRAM of a stage.
+func validateHeavyHitterSize(heavyHitter *model.P4CodeTemplateHeavyHitter, sessionIdWidth uint) error {
+	registerBlocks := ((uint64(1)<<(sessionIdWidth+heavyHitter.SketchWidth))*P4_HH_CELL_WIDTH + P4_SRAM_BLOCK_BITS - 1) / P4_SRAM_BLOCK_BITS
+	if registerBlocks > P4_SRAM_BLOCKS_PER_STAGE {
+		return cli.Exit(
+			fmt.Sprintf(
+				"A sketch row of the heavy hitter probe needs %d SRAM blocks, but a stage has only %d SRAM blocks. Reduce the sketch width or the session width",
+				registerBlocks,
+				P4_SRAM_BLOCKS_PER_STAGE,
+			), 1,
+		)
 	}
-	logger.Info("All necessary PIFINA files have been generated. Include these files according to the manual in your P4 application source code.")
 
 	return nil
 }
+
+// Parses the flow key of the flow discovery digest like hdr.ipv4.src_addr,hdr.ipv4.dst_addr.
+// Without a filter, all packets with valid headers of the keys are considered.
+// Returns nil if no key has been defined.
+func parseFlowDiscoveryKeys(keys []string, filter string, filterWidth uint) (*model.P4CodeTemplateFlowDiscovery, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if filterWidth < 1 || filterWidth > DISCOVERY_MAX_FILTER_WIDTH {
+		return nil, cli.Exit(fmt.Sprintf("%d is an invalid discovery filter width. Needs to be between 1 and %d bits", filterWidth, DISCOVERY_MAX_FILTER_WIDTH), 1)
+	}
+	flowDiscovery := &model.P4CodeTemplateFlowDiscovery{
+		Keys:        make([]*model.P4CodeTemplateFlowDiscoveryKey, 0, len(keys)),
+		Filter:      filter,
+		FilterWidth: filterWidth,
+	}
+	fieldNameCache := make(map[string]struct{})
+	validHeaders := make([]string, 0)
+	validHeaderCache := make(map[string]struct{})
+	for _, key := range keys {
+		pathElements := strings.Split(key, ".")
+		if len(pathElements) < 3 || strings.HasSuffix(key, "isValid()") {
+			return nil, cli.Exit(fmt.Sprintf("%s is an invalid flow discovery key. A key needs to be a field of a header like hdr.ipv4.src_addr", key), 1)
+		}
+		// Digest field name without the parameter name. e.g. hdr.ipv4.src_addr => ipv4_src_addr
+		fieldName := strings.Join(pathElements[1:], "_")
+		if _, ok := fieldNameCache[fieldName]; ok {
+			return nil, cli.Exit(fmt.Sprintf("Flow discovery key %s has been defined more than once", key), 1)
+		}
+		fieldNameCache[fieldName] = struct{}{}
+		flowDiscovery.Keys = append(flowDiscovery.Keys, &model.P4CodeTemplateFlowDiscoveryKey{
+			Name:             key,
+			ControlPlaneName: fieldName,
+		})
+		header := strings.Join(pathElements[:len(pathElements)-1], ".")
+		if _, ok := validHeaderCache[header]; !ok {
+			validHeaderCache[header] = struct{}{}
+			validHeaders = append(validHeaders, fmt.Sprintf("%s.isValid()", header))
+		}
+	}
+	if flowDiscovery.Filter == "" {
+		flowDiscovery.Filter = strings.Join(validHeaders, " && ")
+	}
+
+	return flowDiscovery, nil
+}
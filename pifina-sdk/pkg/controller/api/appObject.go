@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Handles the monitored counters, direct counters or meters of the user application given by the object type
+func (s *ControllerApiServer) HandleAppObjectReq(objectType string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s.getAppObjectProbes(rw, r, objectType)
+		case http.MethodPost:
+			s.createAppObjectProbe(rw, r, objectType)
+		case http.MethodDelete:
+			s.deleteAppObjectProbe(rw, r, objectType)
+		}
+	}
+}
+
+// Returns the names of all existing objects of the given type
+func (s *ControllerApiServer) GetAllAppObjectNames(objectType string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		objects := s.ts.GetAllAppObjectsOnDevice(objectType)
+		rw.WriteHeader(http.StatusOK)
+		json.NewEncoder(rw).Encode(objects)
+	}
+}
+
+// Returns configured objects of the given type to monitor
+func (s *ControllerApiServer) getAppObjectProbes(rw http.ResponseWriter, r *http.Request, objectType string) {
+	objects := s.ts.GetAppObjectProbes(objectType)
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(objects)
+}
+
+func (s *ControllerApiServer) createAppObjectProbe(rw http.ResponseWriter, r *http.Request, objectType string) {
+	var newEntry *model.AppObject
+	err := json.NewDecoder(r.Body).Decode(&newEntry)
+	if err != nil {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid json. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	if newEntry.Name == "" {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid name. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	newEntry.Type = objectType
+
+	err = s.ts.AddAppObjectProbe(newEntry)
+	if err != nil {
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+}
+
+func (s *ControllerApiServer) deleteAppObjectProbe(rw http.ResponseWriter, r *http.Request, objectType string) {
+	var newEntry *model.AppObject
+	err := json.NewDecoder(r.Body).Decode(&newEntry)
+	if err != nil {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid json. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	if newEntry.Name == "" {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid name. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	newEntry.Type = objectType
+
+	// Remove object from data collection
+	s.ts.RemoveAppObjectProbe(newEntry)
+
+	rw.WriteHeader(http.StatusNoContent)
+}
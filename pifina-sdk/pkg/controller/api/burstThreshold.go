@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func (s *ControllerApiServer) HandleBurstThresholdReq(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getBurstThreshold(rw, r)
+	case http.MethodPut:
+		s.updateBurstThreshold(rw, r)
+	}
+}
+
+// Returns the current queue depth threshold of the microburst probe
+func (s *ControllerApiServer) getBurstThreshold(rw http.ResponseWriter, r *http.Request) {
+	threshold, err := s.ts.GetBurstThreshold()
+	if err != nil {
+		s.writeBurstThresholdError(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(threshold)
+}
+
+func (s *ControllerApiServer) updateBurstThreshold(rw http.ResponseWriter, r *http.Request) {
+	var newThreshold *model.BurstThreshold
+	err := json.NewDecoder(r.Body).Decode(&newThreshold)
+	if err != nil || newThreshold == nil {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid json. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	if newThreshold.Threshold == 0 {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid threshold. Needs to be a positive number", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	err = s.ts.SetBurstThreshold(newThreshold)
+	if err != nil {
+		s.writeBurstThresholdError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(newThreshold)
+}
+
+// Microburst probe is optional. A missing probe is reported as not found.
+func (s *ControllerApiServer) writeBurstThresholdError(rw http.ResponseWriter, err error) {
+	errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+	var notFoundErr *model.ErrNameNotFound
+	if errors.As(err, &notFoundErr) {
+		errorMessage.Code = http.StatusNotFound
+	}
+	rw.WriteHeader(errorMessage.Code)
+	json.NewEncoder(rw).Encode(errorMessage)
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Returns the cycle and overrun statistics of every collection group
+func (s *ControllerApiServer) GetCollectorStats(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	json.NewEncoder(rw).Encode(s.mc.GetCollectorStats())
+}
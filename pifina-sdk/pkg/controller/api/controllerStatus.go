@@ -0,0 +1,20 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Returns the operating mode of the controller, e.g. if it is read-only
+func (s *ControllerApiServer) GetControllerStatus(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	json.NewEncoder(rw).Encode(s.mc.GetControllerStatus())
+}
@@ -31,15 +31,16 @@ func (s *ControllerApiServer) HandlePortsToMonitor(rw http.ResponseWriter, r *ht
 	}
 }
 
+// Returns the monitored ports with their link state and RMON counters
 func (s *ControllerApiServer) GetMonitoredPorts(rw http.ResponseWriter, r *http.Request) {
-	ports := s.ts.GetMonitoredPorts()
-	sort.Strings(ports)
-	transformedPorts := make([]*model.DevPort, 0, len(ports))
-	for i := range ports {
-		transformedPorts = append(transformedPorts, &model.DevPort{Name: ports[i]})
+	ports, err := s.mc.GetMonitoredPortDetails()
+	if err != nil {
+		// Return at least the names of the ports
+		s.logger.Warn("Cannot read link state and counters of monitored ports", "err", err)
 	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name })
 	rw.WriteHeader(http.StatusOK)
-	json.NewEncoder(rw).Encode(transformedPorts)
+	json.NewEncoder(rw).Encode(ports)
 }
 
 func (s *ControllerApiServer) AddPortToMonitor(rw http.ResponseWriter, r *http.Request) {
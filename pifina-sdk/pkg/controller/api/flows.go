@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Handles requests on the flows reported by the flow discovery digest
+func (s *ControllerApiServer) HandleDiscoveredFlowsReq(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		flows, err := s.mc.GetDiscoveredFlows()
+		if err != nil {
+			s.writeFlowDiscoveryError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		json.NewEncoder(rw).Encode(flows)
+	case http.MethodDelete:
+		s.mc.RemoveDiscoveredFlows()
+		rw.WriteHeader(http.StatusNoContent)
+	case http.MethodOptions:
+		rw.Header().Set("Allow", "GET, DELETE, OPTIONS")
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Handles requests on the limits for the automatic creation of selector rules for discovered flows
+func (s *ControllerApiServer) HandleFlowDiscoveryPolicyReq(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rw.WriteHeader(http.StatusOK)
+		json.NewEncoder(rw).Encode(s.mc.GetFlowDiscoveryPolicy())
+	case http.MethodPut:
+		var policy *model.FlowDiscoveryPolicy
+		err := json.NewDecoder(r.Body).Decode(&policy)
+		if err != nil || policy == nil {
+			errorMessage := &model.ApiErrorMessage{Message: "Invalid json. Check your input", Code: http.StatusBadRequest}
+			rw.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(rw).Encode(errorMessage)
+			return
+		}
+		err = s.mc.SetFlowDiscoveryPolicy(policy)
+		if err != nil {
+			errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+			rw.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(rw).Encode(errorMessage)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		json.NewEncoder(rw).Encode(policy)
+	case http.MethodOptions:
+		rw.Header().Set("Allow", "GET, PUT, OPTIONS")
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Flow discovery digest is optional. A missing digest is reported as not found.
+func (s *ControllerApiServer) writeFlowDiscoveryError(rw http.ResponseWriter, err error) {
+	errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusInternalServerError}
+	var notFoundErr *model.ErrNameNotFound
+	if errors.As(err, &notFoundErr) {
+		errorMessage.Code = http.StatusNotFound
+	}
+	rw.WriteHeader(errorMessage.Code)
+	json.NewEncoder(rw).Encode(errorMessage)
+}
@@ -7,12 +7,15 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/thushjandan/pifina/pkg/controller/bufferpool"
+	"github.com/thushjandan/pifina/pkg/controller/collector"
 	"github.com/thushjandan/pifina/pkg/controller/trafficselector"
+	"github.com/thushjandan/pifina/pkg/model"
 )
 
 type ControllerApiServer struct {
@@ -21,13 +24,15 @@ type ControllerApiServer struct {
 	server *http.Server
 	ts     *trafficselector.TrafficSelector
 	bp     *bufferpool.Bufferpool
+	mc     *collector.MetricCollector
 }
 
-func NewControllerApiServer(logger hclog.Logger, port string, ts *trafficselector.TrafficSelector, bp *bufferpool.Bufferpool) *ControllerApiServer {
+func NewControllerApiServer(logger hclog.Logger, port string, ts *trafficselector.TrafficSelector, bp *bufferpool.Bufferpool, mc *collector.MetricCollector) *ControllerApiServer {
 	return &ControllerApiServer{
 		logger: logger.Named("api"),
 		ts:     ts,
 		bp:     bp,
+		mc:     mc,
 		port:   port,
 	}
 }
@@ -35,12 +40,31 @@ func NewControllerApiServer(logger hclog.Logger, port string, ts *trafficselecto
 func (s *ControllerApiServer) StartWebServer(ctx context.Context) {
 	// Create a new Mux and set the handler
 	mux := http.NewServeMux()
-	mux.Handle("/api/v1/selectors", middlewareCORS(http.HandlerFunc(s.HandleSelectorReq)))
+	mux.Handle("/api/v1/selectors", middlewareCORS(s.middlewareReadOnly(http.HandlerFunc(s.HandleSelectorReq))))
+	mux.Handle("/api/v1/selectors:batch", middlewareCORS(s.middlewareReadOnly(http.HandlerFunc(s.AddSelectorBatch))))
+	mux.Handle("/api/v1/selectors/", middlewareCORS(s.middlewareReadOnly(http.HandlerFunc(s.HandleSelectorItemReq))))
+	mux.Handle("/api/v1/selectors/lpf", middlewareCORS(s.middlewareReadOnly(http.HandlerFunc(s.UpdateSelectorLPF))))
 	mux.Handle("/api/v1/schema", middlewareCORS(http.HandlerFunc(s.GetSelectorSchema)))
+	mux.Handle("/api/v1/schema/tables", middlewareCORS(http.HandlerFunc(s.GetAllSelectorTableSchemas)))
 	mux.Handle("/api/v1/app-registers", middlewareCORS(http.HandlerFunc(s.HandleAppRegisterReq)))
 	mux.Handle("/api/v1/app-registers/available", middlewareCORS(http.HandlerFunc(s.GetAllAppRegisterNames)))
+	mux.Handle("/api/v1/app-counters", middlewareCORS(s.HandleAppObjectReq(model.APP_OBJECT_TYPE_COUNTER)))
+	mux.Handle("/api/v1/app-counters/available", middlewareCORS(s.GetAllAppObjectNames(model.APP_OBJECT_TYPE_COUNTER)))
+	mux.Handle("/api/v1/app-direct-counters", middlewareCORS(s.HandleAppObjectReq(model.APP_OBJECT_TYPE_DIRECT_COUNTER)))
+	mux.Handle("/api/v1/app-direct-counters/available", middlewareCORS(s.GetAllAppObjectNames(model.APP_OBJECT_TYPE_DIRECT_COUNTER)))
+	mux.Handle("/api/v1/app-meters", middlewareCORS(s.HandleAppObjectReq(model.APP_OBJECT_TYPE_METER)))
+	mux.Handle("/api/v1/app-meters/available", middlewareCORS(s.GetAllAppObjectNames(model.APP_OBJECT_TYPE_METER)))
 	mux.Handle("/api/v1/ports", middlewareCORS(http.HandlerFunc(s.HandlePortsToMonitor)))
 	mux.Handle("/api/v1/ports/available", middlewareCORS(http.HandlerFunc(s.GetAllAvailablePorts)))
+	mux.Handle("/api/v1/tables", middlewareCORS(http.HandlerFunc(s.HandleTablesToMonitor)))
+	mux.Handle("/api/v1/tables/available", middlewareCORS(http.HandlerFunc(s.GetAllAvailableTables)))
+	mux.Handle("/api/v1/measurements", middlewareCORS(http.HandlerFunc(s.GetMeasurements)))
+	mux.Handle("/api/v1/measurements/", middlewareCORS(s.middlewareReadOnly(http.HandlerFunc(s.HandleMeasurementItemReq))))
+	mux.Handle("/api/v1/flows", middlewareCORS(http.HandlerFunc(s.HandleDiscoveredFlowsReq)))
+	mux.Handle("/api/v1/flows/policy", middlewareCORS(s.middlewareReadOnly(http.HandlerFunc(s.HandleFlowDiscoveryPolicyReq))))
+	mux.Handle("/api/v1/burst-threshold", middlewareCORS(s.middlewareReadOnly(http.HandlerFunc(s.HandleBurstThresholdReq))))
+	mux.Handle("/api/v1/collector/stats", middlewareCORS(http.HandlerFunc(s.GetCollectorStats)))
+	mux.Handle("/api/v1/status", middlewareCORS(http.HandlerFunc(s.GetControllerStatus)))
 
 	s.server = &http.Server{
 		Addr:    s.port,
@@ -63,11 +87,24 @@ func (s *ControllerApiServer) Shutdown() {
 	}
 }
 
+// Rejects requests, which would change the dataplane, if the controller is in read-only mode or a follower
+func (s *ControllerApiServer) middlewareReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if s.mc.IsReadOnly() && r.Method != http.MethodGet && r.Method != http.MethodOptions {
+			errorMessage := &model.ApiErrorMessage{Message: "The controller is read-only, because it runs in read-only mode or another client holds the lease of the switch. Changes to the dataplane are not allowed.", Code: http.StatusForbidden}
+			rw.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(rw).Encode(errorMessage)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
 func middlewareCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		rw.Header().Set("Content-Type", "application/json")
 		rw.Header().Set("Access-Control-Allow-Origin", "*")
-		rw.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE")
+		rw.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
 		rw.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 		next.ServeHTTP(rw, r)
 	})
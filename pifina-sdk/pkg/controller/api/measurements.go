@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Returns all scheduled, running and finished measurements of rules with a TTL or a schedule
+func (s *ControllerApiServer) GetMeasurements(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	json.NewEncoder(rw).Encode(s.mc.GetMeasurements())
+}
+
+// Handles requests on a single measurement /api/v1/measurements/{id}
+func (s *ControllerApiServer) HandleMeasurementItemReq(rw http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/v1/measurements/"), 10, 32)
+	if err != nil {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid measurement id in path. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		measurement, err := s.mc.GetMeasurement(uint32(id))
+		if err != nil {
+			s.writeMeasurementError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		json.NewEncoder(rw).Encode(measurement)
+	case http.MethodDelete:
+		err = s.mc.RemoveMeasurement(uint32(id))
+		if err != nil {
+			s.logger.Error("Removing measurement failed", "id", id, "err", err)
+			s.writeMeasurementError(rw, err)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	case http.MethodOptions:
+		rw.Header().Set("Allow", "GET, DELETE, OPTIONS")
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *ControllerApiServer) writeMeasurementError(rw http.ResponseWriter, err error) {
+	errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusInternalServerError}
+	var notFoundErr *model.ErrNameNotFound
+	if errors.As(err, &notFoundErr) {
+		errorMessage.Code = http.StatusNotFound
+	}
+	rw.WriteHeader(errorMessage.Code)
+	json.NewEncoder(rw).Encode(errorMessage)
+}
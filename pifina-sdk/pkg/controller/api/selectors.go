@@ -7,20 +7,41 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/thushjandan/pifina/pkg/model"
 )
 
 func (s *ControllerApiServer) GetSelectorSchema(rw http.ResponseWriter, r *http.Request) {
-	keys, err := s.ts.GetTrafficSelectorSchema()
+	// Optional query parameter to select a specific selector table. Defaults to the default selector table.
+	keys, err := s.ts.GetTrafficSelectorSchema(r.URL.Query().Get("table"))
 	if err != nil {
+		var notFoundErr *model.ErrNameNotFound
+		if errors.As(err, &notFoundErr) {
+			errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusNotFound}
+			rw.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(rw).Encode(errorMessage)
+			return
+		}
 		rw.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 	json.NewEncoder(rw).Encode(keys)
 }
 
+func (s *ControllerApiServer) GetAllSelectorTableSchemas(rw http.ResponseWriter, r *http.Request) {
+	tables, err := s.ts.GetAllTrafficSelectorSchemas()
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(rw).Encode(tables)
+}
+
 func (s *ControllerApiServer) GetSelectors(rw http.ResponseWriter, r *http.Request) {
 	matchSelectors := s.ts.GetTrafficSelectorCache()
 	json.NewEncoder(rw).Encode(matchSelectors)
@@ -38,6 +59,20 @@ func (s *ControllerApiServer) AddNewSelector(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
+	err = s.ts.CompileSelectorExpression(&matchSelectorEntry)
+	if err != nil {
+		s.logger.Warn("Invalid selector expression for AddNewSelector API request", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	shadowingRules := s.ts.FindShadowingRules(&matchSelectorEntry)
+	// Rules with a TTL or a schedule are installed and removed by the collector
+	if matchSelectorEntry.IsTimeBoxed() {
+		s.addTimeBoxedSelector(rw, &matchSelectorEntry, shadowingRules)
+		return
+	}
 	err = s.ts.AddTrafficSelectorRule(&matchSelectorEntry)
 	if err != nil {
 		s.logger.Error("Adding new selector rule failed", "err", err)
@@ -48,6 +83,152 @@ func (s *ControllerApiServer) AddNewSelector(rw http.ResponseWriter, r *http.Req
 	}
 
 	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(s.createSelectorResponse(&matchSelectorEntry, shadowingRules))
+}
+
+// Schedules a measurement for a rule with a TTL or a schedule.
+// Responds with 202 Accepted, if the rule is installed later and has no sessionId yet.
+func (s *ControllerApiServer) addTimeBoxedSelector(rw http.ResponseWriter, entry *model.MatchSelectorEntry, shadowingRules []*model.MatchSelectorEntry) {
+	measurement, err := s.mc.ScheduleMeasurement(entry)
+	if err != nil {
+		s.logger.Error("Scheduling new selector rule failed", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	response := s.createSelectorResponse(measurement.Selector, shadowingRules)
+	response.MeasurementId = measurement.Id
+	if measurement.State == model.MEASUREMENT_STATE_SCHEDULED {
+		rw.WriteHeader(http.StatusAccepted)
+	} else {
+		rw.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(rw).Encode(response)
+}
+
+// Adds a list of selector rules. Either all or none of the rules are added.
+func (s *ControllerApiServer) AddSelectorBatch(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var matchSelectorEntries []*model.MatchSelectorEntry
+
+	err := json.NewDecoder(r.Body).Decode(&matchSelectorEntries)
+	if err != nil || len(matchSelectorEntries) == 0 {
+		s.logger.Warn("Invalid request body for AddSelectorBatch API request", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid json or empty list of selector rules. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	shadowingRules := make([][]*model.MatchSelectorEntry, len(matchSelectorEntries))
+	for i := range matchSelectorEntries {
+		err = s.ts.CompileSelectorExpression(matchSelectorEntries[i])
+		if err != nil {
+			s.logger.Warn("Invalid selector expression for AddSelectorBatch API request", "index", i, "err", err)
+			errorMessage := &model.ApiErrorMessage{Message: fmt.Sprintf("selector rule %d: %s", i, err.Error()), Code: http.StatusBadRequest}
+			rw.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(rw).Encode(errorMessage)
+			return
+		}
+		if matchSelectorEntries[i].IsTimeBoxed() {
+			errorMessage := &model.ApiErrorMessage{Message: fmt.Sprintf("selector rule %d: a ttl or a schedule is only supported by /api/v1/selectors", i), Code: http.StatusBadRequest}
+			rw.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(rw).Encode(errorMessage)
+			return
+		}
+		shadowingRules[i] = s.ts.FindShadowingRules(matchSelectorEntries[i])
+	}
+	err = s.ts.AddTrafficSelectorRules(matchSelectorEntries)
+	if err != nil {
+		s.logger.Error("Adding batch of selector rules failed", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	responses := make([]*model.MatchSelectorResponse, 0, len(matchSelectorEntries))
+	for i := range matchSelectorEntries {
+		responses = append(responses, s.createSelectorResponse(matchSelectorEntries[i], shadowingRules[i]))
+	}
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(responses)
+}
+
+// Changes the keys or the priority of an existing selector rule. The sessionId is taken from the path /api/v1/selectors/{sessionId}
+func (s *ControllerApiServer) UpdateSelector(rw http.ResponseWriter, r *http.Request) {
+	sessionId, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/v1/selectors/"), 10, 32)
+	if err != nil {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid sessionId in path. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	var matchSelectorEntry model.MatchSelectorEntry
+	err = json.NewDecoder(r.Body).Decode(&matchSelectorEntry)
+	if err != nil {
+		s.logger.Warn("Invalid request body for UpdateSelector API request", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid Hexadecimal character detected. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	matchSelectorEntry.SessionId = uint32(sessionId)
+	// The expression is compiled against the table of the existing rule, if no table is defined
+	if matchSelectorEntry.Table == "" {
+		for _, entry := range s.ts.GetTrafficSelectorCache() {
+			if entry.SessionId == matchSelectorEntry.SessionId {
+				matchSelectorEntry.Table = entry.Table
+				break
+			}
+		}
+	}
+
+	err = s.ts.CompileSelectorExpression(&matchSelectorEntry)
+	if err != nil {
+		s.logger.Warn("Invalid selector expression for UpdateSelector API request", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	shadowingRules := s.ts.FindShadowingRules(&matchSelectorEntry)
+	err = s.ts.UpdateTrafficSelectorRule(uint32(sessionId), &matchSelectorEntry)
+	if err != nil {
+		s.logger.Error("Modifying selector rule failed", "sessionId", sessionId, "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		var notFoundErr *model.ErrNameNotFound
+		if errors.As(err, &notFoundErr) {
+			errorMessage.Code = http.StatusNotFound
+		}
+		rw.WriteHeader(errorMessage.Code)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(s.createSelectorResponse(&matchSelectorEntry, shadowingRules))
+}
+
+// Creates a response with a warning for every existing rule, which shadows the new rule.
+func (s *ControllerApiServer) createSelectorResponse(entry *model.MatchSelectorEntry, shadowingRules []*model.MatchSelectorEntry) *model.MatchSelectorResponse {
+	response := &model.MatchSelectorResponse{SessionId: entry.SessionId}
+	for _, shadowingRule := range shadowingRules {
+		priority := uint32(0)
+		if shadowingRule.Priority != nil {
+			priority = *shadowingRule.Priority
+		}
+		s.logger.Warn("Selector rule is shadowed by an existing rule", "sessionId", entry.SessionId, "shadowedBy", shadowingRule.SessionId, "priority", priority)
+		response.Warnings = append(response.Warnings, fmt.Sprintf("Selector rule is shadowed by the rule with sessionId %d and priority %d. Packets matching both rules are only measured by sessionId %d.", shadowingRule.SessionId, priority, shadowingRule.SessionId))
+	}
+
+	return response
 }
 
 func (s *ControllerApiServer) RemoveSelector(rw http.ResponseWriter, r *http.Request) {
@@ -73,6 +254,53 @@ func (s *ControllerApiServer) RemoveSelector(rw http.ResponseWriter, r *http.Req
 
 }
 
+// Changes the LPF parameter of the jitter probe for an existing selector rule
+func (s *ControllerApiServer) UpdateSelectorLPF(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var matchSelectorEntry model.MatchSelectorEntry
+
+	err := json.NewDecoder(r.Body).Decode(&matchSelectorEntry)
+	if err != nil || matchSelectorEntry.LPF == nil {
+		s.logger.Warn("Invalid request body for UpdateSelectorLPF API request", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid json. Define the sessionId and the lpf parameter", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	err = s.ts.UpdateLPFConfig(matchSelectorEntry.SessionId, matchSelectorEntry.LPF)
+	if err != nil {
+		s.logger.Error("Updating LPF parameter failed", "sessionId", matchSelectorEntry.SessionId, "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		var notFoundErr *model.ErrNameNotFound
+		if errors.As(err, &notFoundErr) {
+			errorMessage.Code = http.StatusNotFound
+		}
+		rw.WriteHeader(errorMessage.Code)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(matchSelectorEntry.LPF)
+}
+
+// Handles requests on a single selector rule /api/v1/selectors/{sessionId}
+func (s *ControllerApiServer) HandleSelectorItemReq(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		s.UpdateSelector(rw, r)
+	case http.MethodOptions:
+		rw.Header().Set("Allow", "PUT, OPTIONS")
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
 func (s *ControllerApiServer) HandleSelectorReq(rw http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -82,7 +310,7 @@ func (s *ControllerApiServer) HandleSelectorReq(rw http.ResponseWriter, r *http.
 	case http.MethodDelete:
 		s.RemoveSelector(rw, r)
 	case http.MethodOptions:
-		rw.Header().Set("Allow", "GET, POST, OPTIONS")
+		rw.Header().Set("Allow", "GET, POST, DELETE, OPTIONS")
 		rw.WriteHeader(http.StatusNoContent)
 	default:
 		rw.WriteHeader(http.StatusMethodNotAllowed)
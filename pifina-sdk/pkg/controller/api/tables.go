@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func (s *ControllerApiServer) GetAllAvailableTables(rw http.ResponseWriter, r *http.Request) {
+	tables := s.ts.GetAllAvailableTables()
+	sort.Strings(tables)
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(tables)
+}
+
+func (s *ControllerApiServer) HandleTablesToMonitor(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.GetMonitoredTables(rw, r)
+	case http.MethodPost:
+		s.AddTableToMonitor(rw, r)
+	case http.MethodDelete:
+		s.DeleteMonitoredTable(rw, r)
+	}
+}
+
+// Returns the monitored tables with their installed entries and size
+func (s *ControllerApiServer) GetMonitoredTables(rw http.ResponseWriter, r *http.Request) {
+	tables, err := s.mc.GetMonitoredTableOccupancy()
+	if err != nil {
+		s.logger.Error("Cannot read occupancy of monitored tables", "err", err)
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusInternalServerError}
+		rw.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(tables)
+}
+
+func (s *ControllerApiServer) AddTableToMonitor(rw http.ResponseWriter, r *http.Request) {
+	var table *model.TableOccupancy
+	err := json.NewDecoder(r.Body).Decode(&table)
+	if err != nil || table.Name == "" {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid json. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	err = s.ts.AddTableToMonitor(table.Name)
+	if err != nil {
+		errorMessage := &model.ApiErrorMessage{Message: err.Error(), Code: http.StatusBadRequest}
+		var notFoundErr *model.ErrNameNotFound
+		if errors.As(err, &notFoundErr) {
+			errorMessage.Code = http.StatusNotFound
+		}
+		rw.WriteHeader(errorMessage.Code)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	rw.WriteHeader(http.StatusCreated)
+}
+
+func (s *ControllerApiServer) DeleteMonitoredTable(rw http.ResponseWriter, r *http.Request) {
+	var table *model.TableOccupancy
+	err := json.NewDecoder(r.Body).Decode(&table)
+	if err != nil || table.Name == "" {
+		errorMessage := &model.ApiErrorMessage{Message: "Invalid json. Check your input", Code: http.StatusBadRequest}
+		rw.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(rw).Encode(errorMessage)
+		return
+	}
+	s.ts.RemoveTableToMonitor(table.Name)
+	rw.WriteHeader(http.StatusNoContent)
+}
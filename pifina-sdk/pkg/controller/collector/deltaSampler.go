@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"sync"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Keeps the previous raw value of every counter to compute the increments between two samples.
+// Used if the counters are never reset in the dataplane, so no increments are lost between read and reset.
+type deltaSampler struct {
+	lock     sync.Mutex
+	previous map[deltaKey]uint64
+}
+
+type deltaKey struct {
+	metricName string
+	metricType string
+	sessionId  uint32
+	label      string // Counters with a label like the drop reason share the metric name and sessionId
+}
+
+func newDeltaSampler() *deltaSampler {
+	return &deltaSampler{
+		previous: make(map[deltaKey]uint64),
+	}
+}
+
+// Replaces the raw value of the metric by the increment since the previous sample.
+// A counter with the given bit width wraps around to 0, hence a smaller value than the previous one means a wraparound.
+// Returns false for the first sample of a counter as there is no previous value yet.
+func (d *deltaSampler) apply(metric *model.MetricItem, width uint) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	key := deltaKey{metricName: metric.MetricName, metricType: metric.Type, sessionId: metric.SessionId, label: metric.Label}
+	current := metric.Value
+	if width < 64 {
+		current &= (1 << width) - 1
+	}
+	previous, ok := d.previous[key]
+	d.previous[key] = current
+	if !ok {
+		return false
+	}
+	metric.Value = computeDelta(previous, current, width)
+
+	return true
+}
+
+// Computes the increment between two raw values of a counter with the given bit width
+func computeDelta(previous uint64, current uint64, width uint) uint64 {
+	delta := current - previous
+	if width < 64 {
+		delta &= (1 << width) - 1
+	}
+	return delta
+}
+
+// Removes the previous values of app objects, which are not watched anymore.
+// The index of an app object is used as sessionId. An app object, which is added again later, starts with a new first sample.
+func (d *deltaSampler) retain(appObjects []*model.AppObject) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	watched := make(map[deltaKey]struct{}, len(appObjects))
+	for _, appObject := range appObjects {
+		watched[deltaKey{metricName: appObject.Name, sessionId: appObject.Index}] = struct{}{}
+	}
+	for key := range d.previous {
+		if _, ok := watched[deltaKey{metricName: key.metricName, sessionId: key.sessionId}]; !ok {
+			delete(d.previous, key)
+		}
+	}
+}
+
+// Removes the previous values of sessions, which do not exist anymore.
+// A selector rule, which reuses the sessionId later, starts with a new first sample.
+func (d *deltaSampler) prune(sessionIds []uint32) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	activeSessions := make(map[uint32]struct{}, len(sessionIds))
+	for _, sessionId := range sessionIds {
+		activeSessions[sessionId] = struct{}{}
+	}
+	for key := range d.previous {
+		if _, ok := activeSessions[key.sessionId]; !ok {
+			delete(d.previous, key)
+		}
+	}
+}
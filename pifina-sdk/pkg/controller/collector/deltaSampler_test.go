@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func TestComputeDelta(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous uint64
+		current  uint64
+		width    uint
+		expected uint64
+	}{
+		{name: "28 bit increment", previous: 100, current: 250, width: 28, expected: 150},
+		{name: "28 bit wraparound", previous: (1 << 28) - 10, current: 5, width: 28, expected: 15},
+		{name: "36 bit increment", previous: 1 << 30, current: 1<<30 + 4096, width: 36, expected: 4096},
+		{name: "36 bit wraparound", previous: (1 << 36) - 1, current: 0, width: 36, expected: 1},
+		{name: "64 bit increment", previous: 1 << 40, current: 1<<40 + 1, width: 64, expected: 1},
+		{name: "64 bit wraparound", previous: ^uint64(0) - 1, current: 3, width: 64, expected: 5},
+		{name: "unchanged", previous: 42, current: 42, width: 28, expected: 0},
+	}
+
+	for _, test := range tests {
+		if delta := computeDelta(test.previous, test.current, test.width); delta != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, delta)
+		}
+	}
+}
+
+func TestDeltaSamplerApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		width    uint
+		samples  []uint64
+		expected []uint64
+	}{
+		{name: "28 bit", width: 28, samples: []uint64{10, 30, (1 << 28) - 2, 3}, expected: []uint64{20, (1 << 28) - 32, 5}},
+		{name: "36 bit", width: 36, samples: []uint64{1 << 35, (1 << 36) - 1, 1}, expected: []uint64{(1 << 35) - 1, 2}},
+		{name: "64 bit", width: 64, samples: []uint64{^uint64(0), 9, 9}, expected: []uint64{10, 0}},
+		// Bits above the width are ignored
+		{name: "28 bit with upper bits", width: 28, samples: []uint64{1<<28 | 5, 1<<29 | 7}, expected: []uint64{2}},
+	}
+
+	for _, test := range tests {
+		sampler := newDeltaSampler()
+		deltas := make([]uint64, 0, len(test.expected))
+		for i, value := range test.samples {
+			metric := &model.MetricItem{SessionId: 1, MetricName: "PF_EGRESS_START_CNT", Type: model.METRIC_PKTS, Value: value}
+			ok := sampler.apply(metric, test.width)
+			// The first sample has no previous value
+			if i == 0 {
+				if ok {
+					t.Errorf("%s: expected first sample to be dropped", test.name)
+				}
+				continue
+			}
+			if !ok {
+				t.Errorf("%s: expected sample %d to be kept", test.name, i)
+				continue
+			}
+			deltas = append(deltas, metric.Value)
+		}
+		if len(deltas) != len(test.expected) {
+			t.Errorf("%s: expected %d increments, got %d", test.name, len(test.expected), len(deltas))
+			continue
+		}
+		for i := range test.expected {
+			if deltas[i] != test.expected[i] {
+				t.Errorf("%s: expected increment %d to be %d, got %d", test.name, i, test.expected[i], deltas[i])
+			}
+		}
+	}
+}
+
+func TestDeltaSamplerPrune(t *testing.T) {
+	sampler := newDeltaSampler()
+	for _, sessionId := range []uint32{1, 2} {
+		sampler.apply(&model.MetricItem{SessionId: sessionId, MetricName: "PF_EGRESS_START_CNT", Type: model.METRIC_PKTS, Value: 10}, 28)
+	}
+	sampler.prune([]uint32{2})
+
+	// The removed session starts with a new first sample, while the remaining session is kept
+	if sampler.apply(&model.MetricItem{SessionId: 1, MetricName: "PF_EGRESS_START_CNT", Type: model.METRIC_PKTS, Value: 20}, 28) {
+		t.Errorf("removed session: expected first sample to be dropped")
+	}
+	metric := &model.MetricItem{SessionId: 2, MetricName: "PF_EGRESS_START_CNT", Type: model.METRIC_PKTS, Value: 20}
+	if !sampler.apply(metric, 28) || metric.Value != 10 {
+		t.Errorf("remaining session: expected increment 10, got %d", metric.Value)
+	}
+}
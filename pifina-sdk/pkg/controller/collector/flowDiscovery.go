@@ -0,0 +1,284 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thushjandan/pifina/pkg/controller/dataplane/tofino/driver"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Amount of discovered flows, which are kept for the API. The least recently seen flows are removed first.
+const MAX_DISCOVERED_FLOWS = 1000
+
+// Keeps track of the flows reported by the flow discovery digest.
+// The filter of already reported flows in the dataplane is cleared by the discovery collection group,
+// so a flow is reported again in every interval, in which it is active.
+type flowDiscovery struct {
+	lock     sync.Mutex
+	policy   model.FlowDiscoveryPolicy
+	flows    map[string]*model.DiscoveredFlow // flow id => flow
+	reported map[string]struct{}              // Flows reported in the current discovery interval
+	// Measurements of auto-created selector rules. Kept apart from the flows, which can be removed before the TTL has expired.
+	autoMeasurements map[uint32]struct{}
+}
+
+func newFlowDiscovery() *flowDiscovery {
+	return &flowDiscovery{
+		flows:            make(map[string]*model.DiscoveredFlow),
+		reported:         make(map[string]struct{}),
+		autoMeasurements: make(map[uint32]struct{}),
+	}
+}
+
+// Returns the discovered flows ordered by the amount of intervals, in which they have been reported.
+func (collector *MetricCollector) GetDiscoveredFlows() ([]*model.DiscoveredFlow, error) {
+	if !collector.driver.HasFlowDigest() {
+		return nil, &model.ErrNameNotFound{Msg: "Flow discovery digest is not installed", Entity: driver.PROBE_FLOW_DIGEST}
+	}
+	discovery := collector.discovery
+	discovery.lock.Lock()
+	defer discovery.lock.Unlock()
+
+	flows := make([]*model.DiscoveredFlow, 0, len(discovery.flows))
+	for _, flow := range discovery.flows {
+		flowCopy := *flow
+		flows = append(flows, &flowCopy)
+	}
+	sortDiscoveredFlows(flows)
+
+	return flows, nil
+}
+
+// Forgets all discovered flows. Flows, which are still active, are discovered again in the next interval.
+// Auto-created selector rules are kept until their TTL has expired and still count towards the limit of the policy.
+func (collector *MetricCollector) RemoveDiscoveredFlows() {
+	discovery := collector.discovery
+	discovery.lock.Lock()
+	defer discovery.lock.Unlock()
+
+	discovery.flows = make(map[string]*model.DiscoveredFlow)
+	discovery.reported = make(map[string]struct{})
+}
+
+func (collector *MetricCollector) GetFlowDiscoveryPolicy() *model.FlowDiscoveryPolicy {
+	discovery := collector.discovery
+	discovery.lock.Lock()
+	defer discovery.lock.Unlock()
+
+	policy := discovery.policy
+	return &policy
+}
+
+// Changes the limits for the automatic creation of selector rules. Applied from the next discovery interval.
+func (collector *MetricCollector) SetFlowDiscoveryPolicy(policy *model.FlowDiscoveryPolicy) error {
+	if err := validateFlowDiscoveryPolicy(policy); err != nil {
+		return err
+	}
+	discovery := collector.discovery
+	discovery.lock.Lock()
+	defer discovery.lock.Unlock()
+
+	discovery.policy = *policy
+	collector.logger.Info("Flow discovery policy has been changed", "autoSelectors", policy.AutoSelectors, "maxSelectors", policy.MaxSelectors, "ttl", policy.TTL)
+
+	return nil
+}
+
+// Adds the flows of a digest list. Called by the digest listener of the driver.
+func (collector *MetricCollector) addDiscoveredFlows(flows []*model.DiscoveredFlow) {
+	discovery := collector.discovery
+	discovery.lock.Lock()
+	defer discovery.lock.Unlock()
+
+	for _, flow := range flows {
+		// The filter exists in every pipe. Hence a flow can be reported multiple times in an interval.
+		if _, ok := discovery.reported[flow.Id]; ok {
+			continue
+		}
+		discovery.reported[flow.Id] = struct{}{}
+		if existingFlow, ok := discovery.flows[flow.Id]; ok {
+			existingFlow.LastSeen = flow.LastSeen
+			existingFlow.Reports++
+			continue
+		}
+		flow.Reports = 1
+		expression, err := collector.ts.ProposeSelectorExpression(flow.Keys)
+		if err != nil {
+			flow.Error = err.Error()
+		}
+		flow.Expression = expression
+		if len(discovery.flows) >= MAX_DISCOVERED_FLOWS {
+			discovery.removeLeastRecentlySeen()
+		}
+		discovery.flows[flow.Id] = flow
+		collector.logger.Debug("A new flow has been discovered", "flow", flow.Id, "expression", flow.Expression)
+	}
+}
+
+// Clears the filter of already reported flows in the dataplane and creates selector rules for the top new flows of the interval.
+func (collector *MetricCollector) collectFlowDiscovery() (*rawCollection, error) {
+	if !collector.driver.HasFlowDigest() {
+		return nil, nil
+	}
+	discovery := collector.discovery
+	discovery.lock.Lock()
+	policy := discovery.policy
+	// Flows without a selector rule, which have been reported in this interval
+	candidates := make([]*model.DiscoveredFlow, 0)
+	for _, flow := range discovery.flows {
+		if flow.MeasurementId != 0 {
+			continue
+		}
+		if _, ok := discovery.reported[flow.Id]; ok && flow.Expression != "" {
+			flowCopy := *flow
+			candidates = append(candidates, &flowCopy)
+		}
+	}
+	discovery.reported = make(map[string]struct{})
+	discovery.lock.Unlock()
+
+	// Writes to the dataplane are not allowed in read-only mode or as follower.
+	if collector.driver.IsReadOnly() {
+		return nil, nil
+	}
+	if err := collector.driver.ResetFlowDiscoveryFilter(); err != nil {
+		return nil, err
+	}
+	if policy.AutoSelectors == 0 || len(candidates) == 0 {
+		return nil, nil
+	}
+
+	limit := autoSelectorLimit(&policy, collector.countAutoMeasurements())
+	if limit == 0 {
+		collector.logger.Debug("Limit of auto-created selector rules has been reached", "maxSelectors", policy.MaxSelectors)
+		return nil, nil
+	}
+
+	sortDiscoveredFlows(candidates)
+	created := uint32(0)
+	for _, flow := range candidates {
+		if created >= limit {
+			break
+		}
+		measurementId, err := collector.createDiscoveredSelector(flow, policy.TTL)
+		if err != nil {
+			collector.logger.Warn("Cannot create selector rule for discovered flow", "flow", flow.Id, "err", err)
+			continue
+		}
+		if measurementId == 0 {
+			continue
+		}
+		created++
+		discovery.lock.Lock()
+		discovery.autoMeasurements[measurementId] = struct{}{}
+		if existingFlow, ok := discovery.flows[flow.Id]; ok {
+			existingFlow.MeasurementId = measurementId
+		}
+		discovery.lock.Unlock()
+	}
+
+	return nil, nil
+}
+
+// Counts the auto-created selector rules, which are still scheduled or running.
+// Measurements, which have been finished or removed, are forgotten.
+func (collector *MetricCollector) countAutoMeasurements() uint32 {
+	discovery := collector.discovery
+	discovery.lock.Lock()
+	defer discovery.lock.Unlock()
+
+	activeSelectors := uint32(0)
+	for measurementId := range discovery.autoMeasurements {
+		measurement, err := collector.GetMeasurement(measurementId)
+		if err == nil && (measurement.State == model.MEASUREMENT_STATE_SCHEDULED || measurement.State == model.MEASUREMENT_STATE_RUNNING) {
+			activeSelectors++
+			continue
+		}
+		delete(discovery.autoMeasurements, measurementId)
+	}
+
+	return activeSelectors
+}
+
+// Installs a selector rule with a TTL for a discovered flow. Returns the id of its measurement
+// or 0, if the flow is already covered by an existing selector rule.
+func (collector *MetricCollector) createDiscoveredSelector(flow *model.DiscoveredFlow, ttl uint32) (uint32, error) {
+	entry := &model.MatchSelectorEntry{
+		Expression: flow.Expression,
+		TTL:        ttl,
+	}
+	if err := collector.ts.CompileSelectorExpression(entry); err != nil {
+		return 0, err
+	}
+	// The flow can be matched by a rule, which has been added after the flow has been reported.
+	if shadowingRules := collector.ts.FindShadowingRules(entry); len(shadowingRules) > 0 {
+		collector.logger.Debug("Discovered flow is already matched by a selector rule", "flow", flow.Id, "sessionId", shadowingRules[0].SessionId)
+		return 0, nil
+	}
+	measurement, err := collector.ScheduleMeasurement(entry)
+	if err != nil {
+		return 0, err
+	}
+	collector.logger.Info("Selector rule has been created for discovered flow", "flow", flow.Id, "measurementId", measurement.Id, "sessionId", measurement.Selector.SessionId)
+
+	return measurement.Id, nil
+}
+
+// Removes the flow, which has not been reported for the longest time. The caller needs to hold the lock.
+func (discovery *flowDiscovery) removeLeastRecentlySeen() {
+	var oldestId string
+	var oldest time.Time
+	for id, flow := range discovery.flows {
+		if oldestId == "" || flow.LastSeen.Before(oldest) {
+			oldestId = id
+			oldest = flow.LastSeen
+		}
+	}
+	delete(discovery.flows, oldestId)
+}
+
+// Returns the amount of selector rules, which can be created in this interval.
+// At most AutoSelectors per interval, but the active auto-created rules must not exceed MaxSelectors.
+func autoSelectorLimit(policy *model.FlowDiscoveryPolicy, activeSelectors uint32) uint32 {
+	if activeSelectors >= policy.MaxSelectors {
+		return 0
+	}
+	limit := policy.MaxSelectors - activeSelectors
+	if policy.AutoSelectors < limit {
+		limit = policy.AutoSelectors
+	}
+
+	return limit
+}
+
+func validateFlowDiscoveryPolicy(policy *model.FlowDiscoveryPolicy) error {
+	if policy.AutoSelectors == 0 {
+		return nil
+	}
+	if policy.TTL == 0 {
+		return fmt.Errorf("auto-created selector rules need a ttl")
+	}
+	if policy.AutoSelectors > policy.MaxSelectors {
+		return fmt.Errorf("autoSelectors %d cannot exceed maxSelectors %d", policy.AutoSelectors, policy.MaxSelectors)
+	}
+
+	return nil
+}
+
+// Orders flows by the amount of intervals, in which they have been reported. Older flows first on a tie.
+func sortDiscoveredFlows(flows []*model.DiscoveredFlow) {
+	sort.Slice(flows, func(i, j int) bool {
+		if flows[i].Reports != flows[j].Reports {
+			return flows[i].Reports > flows[j].Reports
+		}
+		return flows[i].FirstSeen.Before(flows[j].FirstSeen)
+	})
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func newTestFlow(id string, seen time.Time) *model.DiscoveredFlow {
+	return &model.DiscoveredFlow{
+		Id:        id,
+		Keys:      []*model.DiscoveredFlowKey{{Name: "ipv4_src_addr", Value: id}},
+		FirstSeen: seen,
+		LastSeen:  seen,
+	}
+}
+
+func TestValidateFlowDiscoveryPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *model.FlowDiscoveryPolicy
+		valid  bool
+	}{
+		{name: "only propose selector rules", policy: &model.FlowDiscoveryPolicy{}, valid: true},
+		{name: "auto selectors without ttl", policy: &model.FlowDiscoveryPolicy{AutoSelectors: 1, MaxSelectors: 5}},
+		{name: "auto selectors exceed max selectors", policy: &model.FlowDiscoveryPolicy{AutoSelectors: 6, MaxSelectors: 5, TTL: 10}},
+		{name: "auto selectors equal max selectors", policy: &model.FlowDiscoveryPolicy{AutoSelectors: 5, MaxSelectors: 5, TTL: 10}, valid: true},
+	}
+
+	for _, test := range tests {
+		err := validateFlowDiscoveryPolicy(test.policy)
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("%s: expected valid %v, got error %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestSortDiscoveredFlows(t *testing.T) {
+	now := time.Now()
+	flows := []*model.DiscoveredFlow{
+		{Id: "new", Reports: 2, FirstSeen: now},
+		{Id: "rare", Reports: 1, FirstSeen: now.Add(-time.Hour)},
+		{Id: "frequent", Reports: 5, FirstSeen: now},
+		{Id: "old", Reports: 2, FirstSeen: now.Add(-time.Minute)},
+	}
+
+	sortDiscoveredFlows(flows)
+	expected := []string{"frequent", "old", "new", "rare"}
+	for i := range expected {
+		if flows[i].Id != expected[i] {
+			t.Errorf("position %d: expected flow %s, got %s", i, expected[i], flows[i].Id)
+		}
+	}
+}
+
+func TestAddDiscoveredFlows(t *testing.T) {
+	collector := newTestSchedulerCollector(t, false, "")
+	start := time.Now()
+
+	// The same flow is reported by the filter of every pipe
+	collector.addDiscoveredFlows([]*model.DiscoveredFlow{newTestFlow("a", start), newTestFlow("b", start)})
+	collector.addDiscoveredFlows([]*model.DiscoveredFlow{newTestFlow("a", start.Add(time.Millisecond))})
+	// Next discovery interval
+	collector.discovery.reported = make(map[string]struct{})
+	collector.addDiscoveredFlows([]*model.DiscoveredFlow{newTestFlow("a", start.Add(time.Second))})
+
+	flows := collector.discovery.flows
+	if len(flows) != 2 {
+		t.Fatalf("expected 2 flows, got %d", len(flows))
+	}
+	if flows["a"].Reports != 2 || !flows["a"].LastSeen.Equal(start.Add(time.Second)) || !flows["a"].FirstSeen.Equal(start) {
+		t.Errorf("flow a: expected 2 reports and last seen in the second interval, got %d reports and last seen %s", flows["a"].Reports, flows["a"].LastSeen)
+	}
+	if flows["b"].Reports != 1 {
+		t.Errorf("flow b: expected 1 report, got %d", flows["b"].Reports)
+	}
+	// testBfrtInfo has no selector table
+	if flows["b"].Expression != "" || flows["b"].Error == "" {
+		t.Errorf("flow b: expected an error instead of an expression, got expression %q", flows["b"].Expression)
+	}
+}
+
+func TestRemoveLeastRecentlySeen(t *testing.T) {
+	discovery := newFlowDiscovery()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		flow := newTestFlow(fmt.Sprintf("flow%d", i), start.Add(-time.Hour))
+		flow.LastSeen = start.Add(time.Duration(i-1) * time.Minute)
+		discovery.flows[flow.Id] = flow
+	}
+
+	discovery.removeLeastRecentlySeen()
+	if len(discovery.flows) != 2 {
+		t.Fatalf("expected 2 flows, got %d", len(discovery.flows))
+	}
+	if _, ok := discovery.flows["flow0"]; ok {
+		t.Errorf("expected least recently seen flow0 to be removed")
+	}
+
+	// Does nothing without flows
+	emptyDiscovery := newFlowDiscovery()
+	emptyDiscovery.removeLeastRecentlySeen()
+	if len(emptyDiscovery.flows) != 0 {
+		t.Errorf("expected no flows, got %d", len(emptyDiscovery.flows))
+	}
+}
+
+func TestAddDiscoveredFlowsLimit(t *testing.T) {
+	collector := newTestSchedulerCollector(t, false, "")
+	start := time.Now()
+	flows := make([]*model.DiscoveredFlow, 0, MAX_DISCOVERED_FLOWS)
+	for i := 0; i < MAX_DISCOVERED_FLOWS; i++ {
+		flows = append(flows, newTestFlow(fmt.Sprintf("flow%d", i), start.Add(time.Duration(i)*time.Millisecond)))
+	}
+	collector.addDiscoveredFlows(flows)
+	collector.addDiscoveredFlows([]*model.DiscoveredFlow{newTestFlow("new", start.Add(time.Hour))})
+
+	if len(collector.discovery.flows) != MAX_DISCOVERED_FLOWS {
+		t.Fatalf("expected %d flows, got %d", MAX_DISCOVERED_FLOWS, len(collector.discovery.flows))
+	}
+	if _, ok := collector.discovery.flows["flow0"]; ok {
+		t.Errorf("expected least recently seen flow0 to be removed")
+	}
+	if _, ok := collector.discovery.flows["new"]; !ok {
+		t.Errorf("expected new flow to be added")
+	}
+}
+
+func TestAutoSelectorLimit(t *testing.T) {
+	tests := []struct {
+		name            string
+		policy          *model.FlowDiscoveryPolicy
+		activeSelectors uint32
+		expected        uint32
+	}{
+		{name: "no active selectors", policy: &model.FlowDiscoveryPolicy{AutoSelectors: 2, MaxSelectors: 5}, expected: 2},
+		{name: "capped by max selectors", policy: &model.FlowDiscoveryPolicy{AutoSelectors: 2, MaxSelectors: 5}, activeSelectors: 4, expected: 1},
+		{name: "max selectors reached", policy: &model.FlowDiscoveryPolicy{AutoSelectors: 2, MaxSelectors: 5}, activeSelectors: 5},
+		// Max selectors has been lowered below the active selectors
+		{name: "max selectors exceeded", policy: &model.FlowDiscoveryPolicy{AutoSelectors: 1, MaxSelectors: 2}, activeSelectors: 3},
+	}
+
+	for _, test := range tests {
+		if limit := autoSelectorLimit(test.policy, test.activeSelectors); limit != test.expected {
+			t.Errorf("%s: expected limit %d, got %d", test.name, test.expected, limit)
+		}
+	}
+}
+
+func TestCountAutoMeasurements(t *testing.T) {
+	collector := newTestSchedulerCollector(t, false, "")
+	start := time.Now()
+	scheduled := addTestMeasurement(collector, model.MEASUREMENT_STATE_SCHEDULED, 0, start.Add(time.Hour), nil)
+	running := addTestMeasurement(collector, model.MEASUREMENT_STATE_RUNNING, 3, start, nil)
+	finished := addTestMeasurement(collector, model.MEASUREMENT_STATE_FINISHED, 4, start, nil)
+	// Measurement created by the user
+	addTestMeasurement(collector, model.MEASUREMENT_STATE_RUNNING, 5, start, nil)
+
+	discovery := collector.discovery
+	for _, measurementId := range []uint32{scheduled.measurement.Id, running.measurement.Id, finished.measurement.Id, 99} {
+		discovery.autoMeasurements[measurementId] = struct{}{}
+	}
+
+	if activeSelectors := collector.countAutoMeasurements(); activeSelectors != 2 {
+		t.Errorf("expected 2 active auto-created selectors, got %d", activeSelectors)
+	}
+	// Finished and removed measurements are forgotten
+	if len(discovery.autoMeasurements) != 2 {
+		t.Errorf("expected 2 remaining auto-created measurements, got %d", len(discovery.autoMeasurements))
+	}
+	if _, ok := discovery.autoMeasurements[finished.measurement.Id]; ok {
+		t.Errorf("expected finished measurement %d to be forgotten", finished.measurement.Id)
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/thushjandan/pifina/pkg/controller/dataplane/tofino/driver"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Amount of finished or failed measurements, which are kept for the API. The oldest ones are removed first.
+const MAX_FINISHED_MEASUREMENTS = 100
+
+// Keeps track of time-boxed selector rules. The rules are installed and removed by the schedule collection group.
+type measurementScheduler struct {
+	lock         sync.Mutex
+	nextId       uint32
+	measurements []*scheduledMeasurement // Ordered by id
+	events       []*model.MetricItem     // Begin and end events, which have not been sent yet
+	stateFile    string                  // Scheduled and running measurements are kept in this file over a restart. Empty disables it.
+	dirty        bool                    // Scheduled or running measurements have been changed since they have been written to the file
+}
+
+type scheduledMeasurement struct {
+	measurement *model.Measurement
+	lastSample  time.Time // Time of the previous sample used to compute the rates
+}
+
+func newMeasurementScheduler(stateFile string) *measurementScheduler {
+	return &measurementScheduler{
+		nextId:       1,
+		measurements: make([]*scheduledMeasurement, 0),
+		events:       make([]*model.MetricItem, 0),
+		stateFile:    stateFile,
+	}
+}
+
+// Adds a selector rule with a TTL or a schedule. The rule is installed immediately, if the start time has been reached.
+// Otherwise no sessionId is allocated until the start time.
+func (collector *MetricCollector) ScheduleMeasurement(entry *model.MatchSelectorEntry) (*model.Measurement, error) {
+	now := time.Now()
+	start := now
+	if entry.Start != nil && entry.Start.After(now) {
+		start = *entry.Start
+	}
+	var stop *time.Time
+	if entry.TTL > 0 && entry.Stop != nil {
+		return nil, fmt.Errorf("define either a ttl or a stop time")
+	}
+	if entry.TTL > 0 {
+		stopTime := start.Add(time.Duration(entry.TTL) * time.Second)
+		stop = &stopTime
+	}
+	if entry.Stop != nil {
+		if !entry.Stop.After(start) {
+			return nil, fmt.Errorf("stop time %s must be after the start time %s", entry.Stop.Format(time.RFC3339), start.Format(time.RFC3339))
+		}
+		stopTime := *entry.Stop
+		stop = &stopTime
+	}
+
+	scheduler := collector.scheduler
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+
+	sm := &scheduledMeasurement{
+		measurement: &model.Measurement{
+			Id:       scheduler.nextId,
+			State:    model.MEASUREMENT_STATE_SCHEDULED,
+			Selector: entry,
+			Start:    start,
+			Stop:     stop,
+		},
+	}
+	if !start.After(now) {
+		if err := collector.startMeasurement(sm, now); err != nil {
+			return nil, err
+		}
+	}
+	scheduler.nextId++
+	scheduler.measurements = append(scheduler.measurements, sm)
+	scheduler.dirty = true
+	collector.saveMeasurements()
+	collector.logger.Info("A new measurement has been scheduled", "id", sm.measurement.Id, "start", start, "stop", stop)
+
+	return copyMeasurement(sm.measurement), nil
+}
+
+// Returns all scheduled, running and the latest finished measurements
+func (collector *MetricCollector) GetMeasurements() []*model.Measurement {
+	scheduler := collector.scheduler
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+
+	measurements := make([]*model.Measurement, 0, len(scheduler.measurements))
+	for _, sm := range scheduler.measurements {
+		measurements = append(measurements, copyMeasurement(sm.measurement))
+	}
+
+	return measurements
+}
+
+func (collector *MetricCollector) GetMeasurement(id uint32) (*model.Measurement, error) {
+	scheduler := collector.scheduler
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+
+	for _, sm := range scheduler.measurements {
+		if sm.measurement.Id == id {
+			return copyMeasurement(sm.measurement), nil
+		}
+	}
+
+	return nil, &model.ErrNameNotFound{Msg: "Measurement does not exist", Entity: fmt.Sprintf("%d", id)}
+}
+
+// Cancels a scheduled measurement, stops a running measurement by removing its rule or deletes the summary of a finished measurement.
+func (collector *MetricCollector) RemoveMeasurement(id uint32) error {
+	scheduler := collector.scheduler
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+
+	for i, sm := range scheduler.measurements {
+		if sm.measurement.Id != id {
+			continue
+		}
+		if sm.measurement.State == model.MEASUREMENT_STATE_RUNNING {
+			err := collector.stopMeasurement(sm, time.Now())
+			collector.saveMeasurements()
+			return err
+		}
+		if sm.measurement.State == model.MEASUREMENT_STATE_SCHEDULED {
+			scheduler.dirty = true
+		}
+		scheduler.measurements = append(scheduler.measurements[:i], scheduler.measurements[i+1:]...)
+		collector.saveMeasurements()
+		return nil
+	}
+
+	return &model.ErrNameNotFound{Msg: "Measurement does not exist", Entity: fmt.Sprintf("%d", id)}
+}
+
+// Installs and removes the rules of the measurements, whose start or stop time has been reached.
+// The begin and end events are returned as metrics.
+func (collector *MetricCollector) collectScheduledMeasurements() (*rawCollection, error) {
+	scheduler := collector.scheduler
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+
+	now := time.Now()
+	activeSessions := make(map[uint32]struct{})
+	for _, sessionId := range collector.ts.GetSessionIdCache() {
+		activeSessions[sessionId] = struct{}{}
+	}
+	// Rules cannot be installed or removed in read-only mode or as follower.
+	// Due measurements are started or stopped, once the lease has been acquired.
+	readOnly := collector.driver.IsReadOnly()
+	for _, sm := range scheduler.measurements {
+		switch sm.measurement.State {
+		case model.MEASUREMENT_STATE_SCHEDULED:
+			if readOnly || sm.measurement.Start.After(now) {
+				continue
+			}
+			if err := collector.startMeasurement(sm, now); err != nil {
+				collector.logger.Error("Cannot install selector rule of the measurement", "id", sm.measurement.Id, "err", err)
+				sm.measurement.State = model.MEASUREMENT_STATE_FAILED
+				sm.measurement.Error = err.Error()
+				scheduler.dirty = true
+			}
+		case model.MEASUREMENT_STATE_RUNNING:
+			// Rule has been removed by the user
+			if _, ok := activeSessions[sm.measurement.Selector.SessionId]; !ok {
+				collector.finishMeasurement(sm, now)
+				continue
+			}
+			if readOnly || sm.measurement.Stop == nil || sm.measurement.Stop.After(now) {
+				continue
+			}
+			if err := collector.stopMeasurement(sm, now); err != nil {
+				// Retried in the next cycle
+				collector.logger.Error("Cannot remove selector rule of the measurement", "id", sm.measurement.Id, "err", err)
+			}
+		}
+	}
+	scheduler.pruneFinished()
+	collector.saveMeasurements()
+
+	events := scheduler.events
+	scheduler.events = make([]*model.MetricItem, 0)
+
+	return &rawCollection{metrics: events, timestamp: now}, nil
+}
+
+// Installs the selector rule of a measurement. The caller needs to hold the lock of the scheduler.
+func (collector *MetricCollector) startMeasurement(sm *scheduledMeasurement, now time.Time) error {
+	if err := collector.ts.AddTrafficSelectorRule(sm.measurement.Selector); err != nil {
+		return err
+	}
+	// Keep the duration, if the start has been delayed
+	if sm.measurement.Stop != nil && sm.measurement.Selector.TTL > 0 {
+		stop := now.Add(time.Duration(sm.measurement.Selector.TTL) * time.Second)
+		sm.measurement.Stop = &stop
+	}
+	sm.measurement.State = model.MEASUREMENT_STATE_RUNNING
+	sm.measurement.Start = now
+	sm.measurement.Summary = &model.MeasurementSummary{}
+	sm.lastSample = now
+	collector.scheduler.dirty = true
+	collector.scheduler.addEvent(model.MEASUREMENT_EVENT_BEGIN, sm.measurement, now)
+	collector.logger.Info("Measurement has been started", "id", sm.measurement.Id, "sessionId", sm.measurement.Selector.SessionId)
+
+	return nil
+}
+
+// Removes the selector rule of a running measurement. The caller needs to hold the lock of the scheduler.
+func (collector *MetricCollector) stopMeasurement(sm *scheduledMeasurement, now time.Time) error {
+	for _, entry := range collector.ts.GetTrafficSelectorCache() {
+		if entry.SessionId != sm.measurement.Selector.SessionId {
+			continue
+		}
+		if err := collector.ts.RemoveTrafficSelectorRule(entry); err != nil {
+			return err
+		}
+		break
+	}
+	collector.finishMeasurement(sm, now)
+
+	return nil
+}
+
+// Marks a measurement as finished and keeps its summary. The caller needs to hold the lock of the scheduler.
+func (collector *MetricCollector) finishMeasurement(sm *scheduledMeasurement, now time.Time) {
+	sm.measurement.State = model.MEASUREMENT_STATE_FINISHED
+	sm.measurement.Stop = &now
+	collector.scheduler.dirty = true
+	collector.scheduler.addEvent(model.MEASUREMENT_EVENT_END, sm.measurement, now)
+	collector.logger.Info("Measurement has been finished", "id", sm.measurement.Id, "sessionId", sm.measurement.Selector.SessionId, "totalPkts", sm.measurement.Summary.TotalPkts)
+}
+
+// Updates the summaries of the running measurements with the metrics of a collection cycle.
+// The selector counters are increments since the previous cycle, either because they are reset or by delta sampling.
+func (collector *MetricCollector) recordMeasurements(metrics []*model.MetricItem, timestamp time.Time) {
+	scheduler := collector.scheduler
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+
+	runningMeasurements := make(map[uint32]*scheduledMeasurement)
+	for _, sm := range scheduler.measurements {
+		if sm.measurement.State == model.MEASUREMENT_STATE_RUNNING {
+			runningMeasurements[sm.measurement.Selector.SessionId] = sm
+		}
+	}
+	if len(runningMeasurements) == 0 {
+		return
+	}
+
+	cycleBytes := make(map[uint32]uint64)
+	cyclePkts := make(map[uint32]uint64)
+	for _, metric := range metrics {
+		sm, ok := runningMeasurements[metric.SessionId]
+		if !ok {
+			continue
+		}
+		summary := sm.measurement.Summary
+		switch metric.MetricName {
+		case driver.PROBE_INGRESS_MATCH_CNT:
+			if metric.Type == model.METRIC_BYTES {
+				summary.TotalBytes += metric.Value
+				cycleBytes[metric.SessionId] += metric.Value
+			} else if metric.Type == model.METRIC_PKTS {
+				summary.TotalPkts += metric.Value
+				cyclePkts[metric.SessionId] += metric.Value
+			}
+		case driver.PROBE_INGRESS_JITTER_REGISTER:
+			if metric.Value > summary.PeakJitter {
+				summary.PeakJitter = metric.Value
+			}
+		}
+	}
+
+	for sessionId, sm := range runningMeasurements {
+		elapsed := timestamp.Sub(sm.lastSample).Seconds()
+		if elapsed <= 0 {
+			continue
+		}
+		summary := sm.measurement.Summary
+		if rate := float64(cycleBytes[sessionId]) / elapsed; rate > summary.PeakBytesPerSec {
+			summary.PeakBytesPerSec = rate
+		}
+		if rate := float64(cyclePkts[sessionId]) / elapsed; rate > summary.PeakPktsPerSec {
+			summary.PeakPktsPerSec = rate
+		}
+		sm.lastSample = timestamp
+	}
+}
+
+// Queues a begin or end event of a measurement. The caller needs to hold the lock of the scheduler.
+func (scheduler *measurementScheduler) addEvent(eventName string, measurement *model.Measurement, now time.Time) {
+	scheduler.events = append(scheduler.events, &model.MetricItem{
+		SessionId:   measurement.Selector.SessionId,
+		Value:       uint64(measurement.Id),
+		Type:        model.METRIC_EXT_VALUE,
+		MetricName:  eventName,
+		LastUpdated: now,
+	})
+}
+
+// Removes the oldest finished and failed measurements above MAX_FINISHED_MEASUREMENTS.
+// The caller needs to hold the lock of the scheduler.
+func (scheduler *measurementScheduler) pruneFinished() {
+	finished := 0
+	for _, sm := range scheduler.measurements {
+		if sm.measurement.State == model.MEASUREMENT_STATE_FINISHED || sm.measurement.State == model.MEASUREMENT_STATE_FAILED {
+			finished++
+		}
+	}
+	if finished <= MAX_FINISHED_MEASUREMENTS {
+		return
+	}
+	toRemove := finished - MAX_FINISHED_MEASUREMENTS
+	measurements := make([]*scheduledMeasurement, 0, len(scheduler.measurements)-toRemove)
+	for _, sm := range scheduler.measurements {
+		if toRemove > 0 && (sm.measurement.State == model.MEASUREMENT_STATE_FINISHED || sm.measurement.State == model.MEASUREMENT_STATE_FAILED) {
+			toRemove--
+			continue
+		}
+		measurements = append(measurements, sm)
+	}
+	scheduler.measurements = measurements
+}
+
+// Copies a measurement, so it can be encoded without holding the lock of the scheduler
+func copyMeasurement(measurement *model.Measurement) *model.Measurement {
+	measurementCopy := *measurement
+	selectorCopy := *measurement.Selector
+	measurementCopy.Selector = &selectorCopy
+	if measurement.Stop != nil {
+		stop := *measurement.Stop
+		measurementCopy.Stop = &stop
+	}
+	if measurement.Summary != nil {
+		summary := *measurement.Summary
+		measurementCopy.Summary = &summary
+	}
+
+	return &measurementCopy
+}
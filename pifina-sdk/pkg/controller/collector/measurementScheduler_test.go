@@ -0,0 +1,220 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/thushjandan/pifina/pkg/controller/dataplane/tofino/driver"
+	"github.com/thushjandan/pifina/pkg/controller/trafficselector"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Creates a collector with a traffic selector, whose driver is not connected.
+// No selector rule is installed and installing a rule fails, as testBfrtInfo has no selector table.
+func newTestSchedulerCollector(t *testing.T, readOnly bool, stateFile string) *MetricCollector {
+	d := driver.NewTofinoDriver(hclog.NewNullLogger(), "test", readOnly)
+	if err := d.LoadBfrtInfo([]byte(testBfrtInfo), []byte("{}")); err != nil {
+		t.Fatalf("cannot load BfrtInfo: %v", err)
+	}
+	ts := trafficselector.NewTrafficSelector(hclog.NewNullLogger(), d, 1)
+
+	return NewMetricCollector(hclog.NewNullLogger(), d, 50, 1000, ts, 1, false, false, 10, stateFile)
+}
+
+func addTestMeasurement(collector *MetricCollector, state string, sessionId uint32, start time.Time, stop *time.Time) *scheduledMeasurement {
+	scheduler := collector.scheduler
+	sm := &scheduledMeasurement{
+		measurement: &model.Measurement{
+			Id:       scheduler.nextId,
+			State:    state,
+			Selector: &model.MatchSelectorEntry{SessionId: sessionId},
+			Start:    start,
+			Stop:     stop,
+			Summary:  &model.MeasurementSummary{},
+		},
+		lastSample: start,
+	}
+	scheduler.nextId++
+	scheduler.measurements = append(scheduler.measurements, sm)
+
+	return sm
+}
+
+func TestScheduleMeasurement(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	beforeFuture := future.Add(-time.Second)
+
+	tests := []struct {
+		name  string
+		entry *model.MatchSelectorEntry
+		valid bool
+	}{
+		{name: "ttl and stop time", entry: &model.MatchSelectorEntry{TTL: 10, Start: &future, Stop: &future}},
+		{name: "stop time before start", entry: &model.MatchSelectorEntry{Start: &future, Stop: &beforeFuture}},
+		{name: "stop time in the past", entry: &model.MatchSelectorEntry{Stop: &past}},
+		{name: "start is due, but rule cannot be installed", entry: &model.MatchSelectorEntry{TTL: 10}},
+		{name: "future start with ttl", entry: &model.MatchSelectorEntry{TTL: 10, Start: &future}, valid: true},
+	}
+
+	collector := newTestSchedulerCollector(t, false, "")
+	for _, test := range tests {
+		_, err := collector.ScheduleMeasurement(test.entry)
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("%s: expected valid %v, got error %v", test.name, test.valid, err)
+		}
+	}
+
+	measurements := collector.GetMeasurements()
+	if len(measurements) != 1 {
+		t.Fatalf("expected 1 scheduled measurement, got %d", len(measurements))
+	}
+	measurement := measurements[0]
+	if measurement.Id != 1 || measurement.State != model.MEASUREMENT_STATE_SCHEDULED {
+		t.Errorf("expected scheduled measurement with id 1, got %s measurement with id %d", measurement.State, measurement.Id)
+	}
+	if !measurement.Start.Equal(future) {
+		t.Errorf("expected start %s, got %s", future, measurement.Start)
+	}
+	if measurement.Stop == nil || !measurement.Stop.Equal(future.Add(10*time.Second)) {
+		t.Errorf("expected stop time 10s after the start, got %v", measurement.Stop)
+	}
+}
+
+func TestCollectScheduledMeasurements(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Hour)
+
+	collector := newTestSchedulerCollector(t, false, "")
+	waiting := addTestMeasurement(collector, model.MEASUREMENT_STATE_SCHEDULED, 0, future, nil)
+	due := addTestMeasurement(collector, model.MEASUREMENT_STATE_SCHEDULED, 0, now.Add(-time.Second), nil)
+	removed := addTestMeasurement(collector, model.MEASUREMENT_STATE_RUNNING, 7, now.Add(-time.Minute), &future)
+
+	raw, err := collector.collectScheduledMeasurements()
+	if err != nil {
+		t.Fatalf("cannot collect scheduled measurements: %v", err)
+	}
+	if waiting.measurement.State != model.MEASUREMENT_STATE_SCHEDULED {
+		t.Errorf("future start: expected state %s, got %s", model.MEASUREMENT_STATE_SCHEDULED, waiting.measurement.State)
+	}
+	if due.measurement.State != model.MEASUREMENT_STATE_FAILED || due.measurement.Error == "" {
+		t.Errorf("failed install: expected state %s with error, got %s", model.MEASUREMENT_STATE_FAILED, due.measurement.State)
+	}
+	// Rule has been removed by the user
+	if removed.measurement.State != model.MEASUREMENT_STATE_FINISHED || removed.measurement.Stop.Equal(future) {
+		t.Errorf("removed rule: expected state %s with actual stop time, got %s", model.MEASUREMENT_STATE_FINISHED, removed.measurement.State)
+	}
+	if len(raw.metrics) != 1 || raw.metrics[0].MetricName != model.MEASUREMENT_EVENT_END || raw.metrics[0].Value != uint64(removed.measurement.Id) || raw.metrics[0].SessionId != 7 {
+		t.Errorf("expected a single end event of measurement %d, got %d events", removed.measurement.Id, len(raw.metrics))
+	}
+	// Events are sent only once
+	if raw, _ := collector.collectScheduledMeasurements(); len(raw.metrics) != 0 {
+		t.Errorf("expected no events in the next cycle, got %d", len(raw.metrics))
+	}
+}
+
+// As follower, rules cannot be installed. Due measurements wait for the lease instead of failing every cycle.
+func TestCollectScheduledMeasurementsReadOnly(t *testing.T) {
+	collector := newTestSchedulerCollector(t, true, "")
+	due := addTestMeasurement(collector, model.MEASUREMENT_STATE_SCHEDULED, 0, time.Now().Add(-time.Second), nil)
+
+	raw, err := collector.collectScheduledMeasurements()
+	if err != nil {
+		t.Fatalf("cannot collect scheduled measurements: %v", err)
+	}
+	if due.measurement.State != model.MEASUREMENT_STATE_SCHEDULED || due.measurement.Error != "" {
+		t.Errorf("expected state %s without error, got %s with error %q", model.MEASUREMENT_STATE_SCHEDULED, due.measurement.State, due.measurement.Error)
+	}
+	if len(raw.metrics) != 0 {
+		t.Errorf("expected no events, got %d", len(raw.metrics))
+	}
+}
+
+func TestRecordMeasurements(t *testing.T) {
+	collector := newTestSchedulerCollector(t, false, "")
+	start := time.Now()
+	running := addTestMeasurement(collector, model.MEASUREMENT_STATE_RUNNING, 5, start, nil)
+	finished := addTestMeasurement(collector, model.MEASUREMENT_STATE_FINISHED, 6, start, nil)
+
+	cycles := []struct {
+		elapsed time.Duration
+		bytes   uint64
+		pkts    uint64
+		jitter  uint64
+	}{
+		{elapsed: 2 * time.Second, bytes: 2000, pkts: 20, jitter: 50},
+		{elapsed: 4 * time.Second, bytes: 1000, pkts: 10, jitter: 30},
+	}
+	for _, cycle := range cycles {
+		metrics := []*model.MetricItem{
+			{SessionId: 5, MetricName: driver.PROBE_INGRESS_MATCH_CNT, Type: model.METRIC_BYTES, Value: cycle.bytes},
+			{SessionId: 5, MetricName: driver.PROBE_INGRESS_MATCH_CNT, Type: model.METRIC_PKTS, Value: cycle.pkts},
+			{SessionId: 5, MetricName: driver.PROBE_INGRESS_JITTER_REGISTER, Type: model.METRIC_EXT_VALUE, Value: cycle.jitter},
+			{SessionId: 5, MetricName: driver.PROBE_EGRESS_START_CNT, Type: model.METRIC_BYTES, Value: 99999},
+			{SessionId: 6, MetricName: driver.PROBE_INGRESS_MATCH_CNT, Type: model.METRIC_BYTES, Value: cycle.bytes},
+		}
+		collector.recordMeasurements(metrics, start.Add(cycle.elapsed))
+	}
+
+	summary := running.measurement.Summary
+	if summary.TotalBytes != 3000 || summary.TotalPkts != 30 {
+		t.Errorf("expected totals of 3000 bytes and 30 packets, got %d bytes and %d packets", summary.TotalBytes, summary.TotalPkts)
+	}
+	// The second cycle has a lower rate over 2 seconds
+	if summary.PeakBytesPerSec != 1000 || summary.PeakPktsPerSec != 10 {
+		t.Errorf("expected peaks of 1000 bytes/s and 10 packets/s, got %f bytes/s and %f packets/s", summary.PeakBytesPerSec, summary.PeakPktsPerSec)
+	}
+	if summary.PeakJitter != 50 {
+		t.Errorf("expected peak jitter 50, got %d", summary.PeakJitter)
+	}
+	if finished.measurement.Summary.TotalBytes != 0 {
+		t.Errorf("expected finished measurement to be unchanged, got %d bytes", finished.measurement.Summary.TotalBytes)
+	}
+}
+
+func TestPruneFinished(t *testing.T) {
+	collector := newTestSchedulerCollector(t, false, "")
+	start := time.Now()
+	addTestMeasurement(collector, model.MEASUREMENT_STATE_RUNNING, 1, start, nil)
+	for i := 0; i < MAX_FINISHED_MEASUREMENTS; i++ {
+		state := model.MEASUREMENT_STATE_FINISHED
+		if i%2 == 0 {
+			state = model.MEASUREMENT_STATE_FAILED
+		}
+		addTestMeasurement(collector, state, 0, start, nil)
+	}
+	addTestMeasurement(collector, model.MEASUREMENT_STATE_SCHEDULED, 0, start.Add(time.Hour), nil)
+
+	collector.scheduler.pruneFinished()
+	if len(collector.scheduler.measurements) != MAX_FINISHED_MEASUREMENTS+2 {
+		t.Fatalf("expected no measurement to be removed below the limit, got %d", len(collector.scheduler.measurements))
+	}
+
+	addTestMeasurement(collector, model.MEASUREMENT_STATE_FINISHED, 0, start, nil)
+	addTestMeasurement(collector, model.MEASUREMENT_STATE_FINISHED, 0, start, nil)
+	collector.scheduler.pruneFinished()
+	measurements := collector.scheduler.measurements
+	if len(measurements) != MAX_FINISHED_MEASUREMENTS+2 {
+		t.Fatalf("expected %d measurements, got %d", MAX_FINISHED_MEASUREMENTS+2, len(measurements))
+	}
+	// The oldest finished measurements with id 2 and 3 are removed first
+	if measurements[0].measurement.Id != 1 || measurements[1].measurement.Id != 4 {
+		t.Errorf("expected running measurement 1 followed by measurement 4, got %d and %d", measurements[0].measurement.Id, measurements[1].measurement.Id)
+	}
+	scheduled := 0
+	for _, sm := range measurements {
+		if sm.measurement.State == model.MEASUREMENT_STATE_SCHEDULED {
+			scheduled++
+		}
+	}
+	if scheduled != 1 {
+		t.Errorf("expected scheduled measurement to be kept, got %d", scheduled)
+	}
+}
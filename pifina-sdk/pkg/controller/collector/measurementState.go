@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Scheduled and running measurements written to the measurement file.
+// The selector rules are kept in the dataplane on a restart, but their stop time is only known by the scheduler.
+type measurementState struct {
+	NextId       uint32               `json:"nextId"`
+	Measurements []*model.Measurement `json:"measurements"`
+}
+
+// Writes the scheduled and running measurements to the measurement file, if they have been changed.
+// The caller needs to hold the lock of the scheduler.
+func (collector *MetricCollector) saveMeasurements() {
+	scheduler := collector.scheduler
+	if scheduler.stateFile == "" || !scheduler.dirty {
+		return
+	}
+	state := &measurementState{
+		NextId:       scheduler.nextId,
+		Measurements: make([]*model.Measurement, 0),
+	}
+	for _, sm := range scheduler.measurements {
+		if sm.measurement.State == model.MEASUREMENT_STATE_SCHEDULED || sm.measurement.State == model.MEASUREMENT_STATE_RUNNING {
+			state.Measurements = append(state.Measurements, sm.measurement)
+		}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		collector.logger.Error("Cannot encode measurements", "err", err)
+		return
+	}
+	// Replace the file at once, so a crash does not leave a partial file behind
+	tmpFile := scheduler.stateFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		collector.logger.Error("Cannot write measurement file", "file", tmpFile, "err", err)
+		return
+	}
+	if err := os.Rename(tmpFile, scheduler.stateFile); err != nil {
+		collector.logger.Error("Cannot write measurement file", "file", scheduler.stateFile, "err", err)
+		return
+	}
+	scheduler.dirty = false
+}
+
+// Restores the measurements of a previous run from the measurement file.
+// A running measurement is only adopted, if its sessionId still belongs to the same rule in the dataplane.
+// Otherwise the rule has been removed or replaced while the tofino-probe was stopped and the measurement is not continued.
+func (collector *MetricCollector) restoreMeasurements() {
+	scheduler := collector.scheduler
+	if scheduler.stateFile == "" {
+		return
+	}
+	data, err := os.ReadFile(scheduler.stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		collector.logger.Error("Cannot read measurement file. Time-boxed selector rules of the previous run are not removed", "file", scheduler.stateFile, "err", err)
+		return
+	}
+	state := &measurementState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		collector.logger.Error("Cannot decode measurement file. Time-boxed selector rules of the previous run are not removed", "file", scheduler.stateFile, "err", err)
+		return
+	}
+
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+
+	now := time.Now()
+	selectorRules := make(map[uint32]*model.MatchSelectorEntry)
+	for _, entry := range collector.ts.GetTrafficSelectorCache() {
+		selectorRules[entry.SessionId] = entry
+	}
+	for _, measurement := range state.Measurements {
+		if measurement.Selector == nil {
+			continue
+		}
+		sm := &scheduledMeasurement{measurement: measurement, lastSample: now}
+		if measurement.State == model.MEASUREMENT_STATE_RUNNING {
+			if measurement.Summary == nil {
+				measurement.Summary = &model.MeasurementSummary{}
+			}
+			entry, ok := selectorRules[measurement.Selector.SessionId]
+			if !ok {
+				collector.finishMeasurement(sm, now)
+			} else if !isSameSelectorRule(entry, measurement.Selector) {
+				collector.logger.Warn("Selector rule of a running measurement has been replaced while the tofino-probe was stopped. The rule is kept", "id", measurement.Id, "sessionId", measurement.Selector.SessionId)
+				measurement.State = model.MEASUREMENT_STATE_FAILED
+				measurement.Error = "selector rule has been replaced while the tofino-probe was stopped"
+			} else {
+				collector.logger.Info("Running measurement has been restored", "id", measurement.Id, "sessionId", measurement.Selector.SessionId, "stop", measurement.Stop)
+			}
+		}
+		scheduler.measurements = append(scheduler.measurements, sm)
+	}
+	if state.NextId > scheduler.nextId {
+		scheduler.nextId = state.NextId
+	}
+	scheduler.dirty = true
+	collector.saveMeasurements()
+}
+
+// Checks if a rule in the dataplane matches the same keys as the selector rule of a measurement.
+// Keys are compared by their field id, because the order of the keys is not kept by the dataplane.
+func isSameSelectorRule(entry *model.MatchSelectorEntry, selector *model.MatchSelectorEntry) bool {
+	if entry.Table != "" && selector.Table != "" && entry.Table != selector.Table {
+		return false
+	}
+	if len(entry.Keys) != len(selector.Keys) {
+		return false
+	}
+	keys := make(map[uint32]*model.MatchSelectorKey, len(entry.Keys))
+	for _, key := range entry.Keys {
+		keys[key.FieldId] = key
+	}
+	for _, key := range selector.Keys {
+		otherKey, ok := keys[key.FieldId]
+		if !ok || !key.Equal(otherKey) {
+			return false
+		}
+	}
+
+	return true
+}
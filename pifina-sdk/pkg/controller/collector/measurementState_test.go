@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func TestRestoreMeasurements(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "measurements.json")
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	state := &measurementState{
+		NextId: 8,
+		Measurements: []*model.Measurement{
+			{Id: 5, State: model.MEASUREMENT_STATE_SCHEDULED, Selector: &model.MatchSelectorEntry{TTL: 10}, Start: start},
+			// Rule has been removed while the tofino-probe was stopped
+			{Id: 6, State: model.MEASUREMENT_STATE_RUNNING, Selector: &model.MatchSelectorEntry{SessionId: 3}, Start: time.Now().Add(-time.Minute)},
+			{Id: 7, State: model.MEASUREMENT_STATE_SCHEDULED, Start: start},
+		},
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("cannot encode state: %v", err)
+	}
+	if err := os.WriteFile(stateFile, data, 0644); err != nil {
+		t.Fatalf("cannot write state file: %v", err)
+	}
+
+	collector := newTestSchedulerCollector(t, false, stateFile)
+	collector.restoreMeasurements()
+
+	measurements := collector.GetMeasurements()
+	if len(measurements) != 2 {
+		t.Fatalf("expected 2 restored measurements without the one missing its selector, got %d", len(measurements))
+	}
+	if measurements[0].Id != 5 || measurements[0].State != model.MEASUREMENT_STATE_SCHEDULED || !measurements[0].Start.Equal(start) {
+		t.Errorf("expected scheduled measurement 5, got %s measurement %d", measurements[0].State, measurements[0].Id)
+	}
+	if measurements[1].Id != 6 || measurements[1].State != model.MEASUREMENT_STATE_FINISHED || measurements[1].Summary == nil {
+		t.Errorf("expected finished measurement 6 with summary, got %s measurement %d", measurements[1].State, measurements[1].Id)
+	}
+	if collector.scheduler.nextId != 8 {
+		t.Errorf("expected next id 8, got %d", collector.scheduler.nextId)
+	}
+
+	// Only the scheduled measurement is kept in the file
+	data, err = os.ReadFile(stateFile)
+	if err != nil {
+		t.Fatalf("cannot read state file: %v", err)
+	}
+	savedState := &measurementState{}
+	if err := json.Unmarshal(data, savedState); err != nil {
+		t.Fatalf("cannot decode state file: %v", err)
+	}
+	if savedState.NextId != 8 || len(savedState.Measurements) != 1 || savedState.Measurements[0].Id != 5 {
+		t.Errorf("expected state file with measurement 5 and next id 8, got %d measurements and next id %d", len(savedState.Measurements), savedState.NextId)
+	}
+}
+
+func TestRestoreMeasurementsWithoutFile(t *testing.T) {
+	collector := newTestSchedulerCollector(t, false, filepath.Join(t.TempDir(), "missing.json"))
+	collector.restoreMeasurements()
+	if len(collector.GetMeasurements()) != 0 || collector.scheduler.nextId != 1 {
+		t.Errorf("expected no measurements and next id 1, got %d measurements and next id %d", len(collector.GetMeasurements()), collector.scheduler.nextId)
+	}
+}
+
+func TestIsSameSelectorRule(t *testing.T) {
+	udp := &model.MatchSelectorKey{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{17}}
+	tcp := &model.MatchSelectorKey{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{6}}
+	dst := &model.MatchSelectorKey{FieldId: 2, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 0, 0}, PrefixLength: 16}
+
+	tests := []struct {
+		name     string
+		entry    *model.MatchSelectorEntry
+		selector *model.MatchSelectorEntry
+		same     bool
+	}{
+		{
+			name:     "same keys in other order",
+			entry:    &model.MatchSelectorEntry{Keys: []*model.MatchSelectorKey{dst, udp}},
+			selector: &model.MatchSelectorEntry{Keys: []*model.MatchSelectorKey{udp, dst}},
+			same:     true,
+		},
+		{
+			name:     "table of the rule is unknown",
+			entry:    &model.MatchSelectorEntry{Keys: []*model.MatchSelectorKey{udp}},
+			selector: &model.MatchSelectorEntry{Table: "ROCE", Keys: []*model.MatchSelectorKey{udp}},
+			same:     true,
+		},
+		{
+			name:     "other table",
+			entry:    &model.MatchSelectorEntry{Table: "PF_INGRESS_MATCH_CNT", Keys: []*model.MatchSelectorKey{udp}},
+			selector: &model.MatchSelectorEntry{Table: "ROCE", Keys: []*model.MatchSelectorKey{udp}},
+		},
+		{
+			name:     "other value",
+			entry:    &model.MatchSelectorEntry{Keys: []*model.MatchSelectorKey{tcp}},
+			selector: &model.MatchSelectorEntry{Keys: []*model.MatchSelectorKey{udp}},
+		},
+		{
+			name:     "missing key",
+			entry:    &model.MatchSelectorEntry{Keys: []*model.MatchSelectorKey{udp}},
+			selector: &model.MatchSelectorEntry{Keys: []*model.MatchSelectorKey{udp, dst}},
+		},
+	}
+
+	for _, test := range tests {
+		if same := isSameSelectorRule(test.entry, test.selector); same != test.same {
+			t.Errorf("%s: expected %v, got %v", test.name, test.same, same)
+		}
+	}
+}
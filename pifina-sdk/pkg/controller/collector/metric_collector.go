@@ -20,24 +20,79 @@ import (
 )
 
 type MetricCollector struct {
-	logger         hclog.Logger
-	driver         *driver.TofinoDriver
-	sampleInterval time.Duration
-	ts             *trafficselector.TrafficSelector
-	lpfTimeConst   float32
-	pipelineCount  int
+	logger           hclog.Logger
+	driver           *driver.TofinoDriver
+	sampleInterval   time.Duration
+	tmSampleInterval time.Duration
+	ts               *trafficselector.TrafficSelector
+	lpfTimeConst     float32
+	pipelineCount    int
+	groups           []*collectionGroup
+	deltaSampling    bool
+	deltaSampler     *deltaSampler
+	appDeltaSampler  *deltaSampler // Counters of the user application, which are never reset
+	hwSync           bool
+	hwSyncSkipped    bool // Sync has been skipped in the last cycle as follower. Only accessed by the session group.
+	scheduler        *measurementScheduler
+	discovery        *flowDiscovery
 }
 
-func NewMetricCollector(logger hclog.Logger, driver *driver.TofinoDriver, sampleInterval int, ts *trafficselector.TrafficSelector, pipelineCount int) *MetricCollector {
-	return &MetricCollector{
-		logger:         logger.Named("collector"),
-		driver:         driver,
-		sampleInterval: time.Duration(sampleInterval) * time.Millisecond,
-		ts:             ts,
-		pipelineCount:  pipelineCount,
+// A group of probes, which is collected in its own goroutine with its own interval.
+// The read and reset requests of a cycle are sent by the collection goroutine,
+// while the responses are processed in a separate stage. So the next cycle does not wait for the processing.
+type collectionGroup struct {
+	name     string
+	interval time.Duration
+	collect  func() (*rawCollection, error)
+	stats    model.CollectorGroupStats
+	lock     sync.Mutex
+}
+
+// Raw responses of a single collection cycle
+type rawCollection struct {
+	entities   []*bfruntime.Entity
+	metrics    []*model.MetricItem
+	counters   []*model.MetricItem // Counters of the user application, which have been already transformed, but are still cumulative
+	sessionIds []uint32            // Sessions of the cycle. Only set by the collection of the session probes
+	timestamp  time.Time           // Time of the hardware sync or of the read response
+}
+
+func NewMetricCollector(logger hclog.Logger, driver *driver.TofinoDriver, sampleInterval int, tmSampleInterval int, ts *trafficselector.TrafficSelector, pipelineCount int, deltaSampling bool, hwSync bool, discoveryInterval int, measurementFile string) *MetricCollector {
+	collector := &MetricCollector{
+		logger:           logger.Named("collector"),
+		driver:           driver,
+		sampleInterval:   time.Duration(sampleInterval) * time.Millisecond,
+		tmSampleInterval: time.Duration(tmSampleInterval) * time.Millisecond,
+		ts:               ts,
+		pipelineCount:    pipelineCount,
+		deltaSampling:    deltaSampling,
+		deltaSampler:     newDeltaSampler(),
+		appDeltaSampler:  newDeltaSampler(),
+		hwSync:           hwSync,
+		scheduler:        newMeasurementScheduler(measurementFile),
+		discovery:        newFlowDiscovery(),
+	}
+	collector.groups = []*collectionGroup{
+		{name: COLLECTION_GROUP_SESSION, interval: collector.sampleInterval, collect: collector.collectSessionMetrics},
+		{name: COLLECTION_GROUP_TM, interval: collector.tmSampleInterval, collect: collector.collectTMMetrics},
+		{name: COLLECTION_GROUP_SCHEDULE, interval: time.Second, collect: collector.collectScheduledMeasurements},
+		{name: COLLECTION_GROUP_DISCOVERY, interval: time.Duration(discoveryInterval) * time.Second, collect: collector.collectFlowDiscovery},
 	}
+	for _, group := range collector.groups {
+		group.stats.Name = group.name
+		group.stats.IntervalMs = float64(group.interval) / float64(time.Millisecond)
+	}
+
+	return collector
 }
 
+const (
+	COLLECTION_GROUP_SESSION   = "session"
+	COLLECTION_GROUP_TM        = "tm"
+	COLLECTION_GROUP_SCHEDULE  = "schedule"
+	COLLECTION_GROUP_DISCOVERY = "discovery"
+)
+
 func (collector *MetricCollector) StartMetricCollection(ctx context.Context, wg *sync.WaitGroup, metricSink chan *model.MetricItem) {
 	// If sessionId cache is empty, then refresh the cache
 	if collector.ts.GetTrafficSelectorCache() == nil {
@@ -45,9 +100,36 @@ func (collector *MetricCollector) StartMetricCollection(ctx context.Context, wg
 		return
 	}
 
-	err := collector.ts.ConfigureLPF()
+	// Time-boxed selector rules of a previous run are removed at their stop time
+	collector.restoreMeasurements()
+
+	// Counters cannot be reset in read-only mode or as follower, hence the increments are computed in software.
+	// Delta sampling is kept after a takeover of the lease, so no sample is lost.
+	if collector.driver.IsReadOnly() {
+		collector.deltaSampling = true
+	}
+	// LPF instances are configured by the owning application in read-only mode
+	if !collector.driver.IsReadOnly() {
+		err := collector.ts.ConfigureLPF()
+		if err != nil {
+			collector.logger.Error("Error occured during LPF initialization", "err", err)
+		}
+	}
+
+	err := collector.driver.LoadPacketSizeBuckets()
 	if err != nil {
-		collector.logger.Error("Error occured during LPF initialization", "err", err)
+		collector.logger.Error("Error occured during loading packet size histogram buckets", "err", err)
+	}
+
+	// New flows are reported by the flow discovery digest on the stream channel.
+	// Digests are only sent to the bound client. A follower is bound, as soon as it has acquired the lease.
+	if collector.driver.HasFlowDigest() {
+		if collector.driver.IsBound() || collector.driver.IsFollower() {
+			collector.logger.Info("Flow discovery digest has been found. Listening for new flows")
+			go collector.driver.ListenForFlowDigests(collector.addDiscoveredFlows)
+		} else {
+			collector.logger.Warn("Flow discovery digest has been found, but the client is not bound to the P4 program. Flow discovery is disabled")
+		}
 	}
 
 	wg.Add(1)
@@ -55,11 +137,43 @@ func (collector *MetricCollector) StartMetricCollection(ctx context.Context, wg
 	go collector.CollectMetrics(ctx, wg, metricSink)
 }
 
+// Starts a collection goroutine per group and closes the metric sink, after all groups have been stopped.
 func (collector *MetricCollector) CollectMetrics(ctx context.Context, wg *sync.WaitGroup, metricSink chan *model.MetricItem) {
 	// Mark the context as done after exiting the routine.
 	defer wg.Done()
 
-	ticker := time.NewTicker(collector.sampleInterval)
+	var groupWg sync.WaitGroup
+	for _, group := range collector.groups {
+		groupWg.Add(1)
+		go collector.collectGroup(ctx, &groupWg, group, metricSink)
+	}
+	groupWg.Wait()
+
+	collector.logger.Info("Stopping collector...")
+	// Sender closes the channel
+	close(metricSink)
+}
+
+// Runs the collection cycles of a single group. A cycle, which takes longer than the interval, is counted as overrun.
+// The ticker drops the ticks in the meantime, so there is at most one cycle of a group in flight.
+func (collector *MetricCollector) collectGroup(ctx context.Context, wg *sync.WaitGroup, group *collectionGroup, metricSink chan *model.MetricItem) {
+	defer wg.Done()
+
+	// Processing stage. The buffer allows the next cycle to start while the previous cycle is processed.
+	processingQueue := make(chan *rawCollection, 1)
+	processingDone := make(chan struct{})
+	go func() {
+		defer close(processingDone)
+		for raw := range processingQueue {
+			collector.processCollection(raw, metricSink)
+		}
+	}()
+	defer func() {
+		close(processingQueue)
+		<-processingDone
+	}()
+
+	ticker := time.NewTicker(group.interval)
 	// Stop the ticker before leaving
 	defer ticker.Stop()
 
@@ -68,106 +182,300 @@ func (collector *MetricCollector) CollectMetrics(ctx context.Context, wg *sync.W
 		// Got a tick from the ticker.
 		case <-ticker.C:
 			start := time.Now()
-			sessionIds := collector.ts.GetSessionIdCache()
-			allMetricRequests := make([]*bfruntime.Entity, 0)
-			metricRequests, err := collector.driver.GetMatchSelectorEntriesRequest()
-			if err == nil {
-				allMetricRequests = append(allMetricRequests, metricRequests...)
-			}
-			metricRequests, err = collector.driver.GetIngressHdrStartCounter(sessionIds)
-			if err == nil {
-				allMetricRequests = append(allMetricRequests, metricRequests...)
-			}
-			metricRequests, err = collector.driver.GetIngressHdrEndCounter(sessionIds)
-			if err == nil {
-				allMetricRequests = append(allMetricRequests, metricRequests...)
-			}
-			metricRequests, err = collector.driver.GetEgressStartCounter(sessionIds)
-			if err == nil {
-				allMetricRequests = append(allMetricRequests, metricRequests...)
-			}
-			metricRequests, err = collector.driver.GetEgressEndCounter(sessionIds)
-			if err == nil {
-				allMetricRequests = append(allMetricRequests, metricRequests...)
-			}
-			metricRequests, err = collector.driver.GetIngressJitter(sessionIds)
-			if err == nil {
-				allMetricRequests = append(allMetricRequests, metricRequests...)
-			}
-			// App registers
-			appRegistersToReq := collector.ts.GetAppRegisterProbes()
-			if len(appRegistersToReq) > 0 {
-				metricRequests, err = collector.driver.GetMetricFromRegisterRequest(appRegistersToReq, model.METRIC_EXT_VALUE)
-				if err == nil {
-					allMetricRequests = append(allMetricRequests, metricRequests...)
-				}
-			}
-			// Extra Probes
-			extraProbes := collector.driver.GetExtraProbes()
-			for i := range extraProbes {
-				metricRequests, err = collector.driver.GetHdrSizeCounter(extraProbes[i], sessionIds)
-				if err == nil {
-					allMetricRequests = append(allMetricRequests, metricRequests...)
-				}
-			}
-			bfResponse, err := collector.driver.SendReadRequest(allMetricRequests)
-			if err != nil {
-				// Check if GRPC request has been canceled
-				// If true, then user stopped app. Skip processing and move to cleanup
-				if collector.errorIsCanceled(err) {
-					continue
-				}
-				collector.logger.Error("Error occured during collection", "err", err)
-			}
-			// Reset counters
-			collector.ResetCounters(sessionIds)
-			// Traffic manager requests per port
-			monitoredPorts := collector.ts.GetMonitoredPorts()
-			if len(monitoredPorts) > 0 {
-				metricRequests = collector.driver.GetTMCountersByPortRequests(monitoredPorts)
-				tmBfResponse, err := collector.driver.SendReadRequest(metricRequests)
-				if err != nil {
-					// Check if GRPC request has been canceled
-					// If true, then user stopped app. Skip processing and move to cleanup
-					if collector.errorIsCanceled(err) {
-						continue
-					}
-					collector.logger.Warn("Error occured during collection of traffic manager metric", "ports", monitoredPorts, "err", err)
-				}
-				bfResponse = append(bfResponse, tmBfResponse...)
-			}
-			// Traffic manager requests per pipeline
-			tmMetrics, err := collector.driver.GetTMPipelineCounter(collector.pipelineCount)
+			raw, err := group.collect()
 			if err != nil {
 				// Check if GRPC request has been canceled
 				// If true, then user stopped app. Skip processing and move to cleanup
 				if collector.errorIsCanceled(err) {
 					continue
 				}
-				collector.logger.Warn("Error occured during collection of traffic manager metrics per pipeline", "err", err)
+				collector.logger.Error("Error occured during collection", "group", group.name, "err", err)
 			}
-			// Process metrics
-			metrics, err := collector.driver.ProcessMetricResponse(bfResponse)
-			if err != nil {
-				collector.logger.Error("Error occured during processing raw metric values", "err", err)
-			} else {
-				// Append TM metrics
-				metrics = append(metrics, tmMetrics...)
-				// Send to sink thread
-				for i := range metrics {
-					metricSink <- metrics[i]
+			if raw != nil {
+				select {
+				case processingQueue <- raw:
+				case <-ctx.Done():
+					return
 				}
 			}
-			collector.logger.Debug("Time Collection end", "time", time.Since(start))
+			collector.recordCycle(group, time.Since(start))
 		// Terminate the for loop.
 		case <-ctx.Done():
-			collector.logger.Info("Stopping collector...")
-			// Sender closes the channel
-			close(metricSink)
 			return
 		}
 	}
+}
 
+// Updates the statistics of a group after a collection cycle
+func (collector *MetricCollector) recordCycle(group *collectionGroup, duration time.Duration) {
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	group.stats.Cycles++
+	group.stats.LastDurationMs = float64(duration) / float64(time.Millisecond)
+	if group.stats.LastDurationMs > group.stats.MaxDurationMs {
+		group.stats.MaxDurationMs = group.stats.LastDurationMs
+	}
+	if duration > group.interval {
+		skippedTicks := uint64(duration / group.interval)
+		group.stats.Overruns++
+		group.stats.SkippedTicks += skippedTicks
+		collector.logger.Warn("Collection cycle took longer than its interval", "group", group.name, "duration", duration, "interval", group.interval, "skippedTicks", skippedTicks, "overruns", group.stats.Overruns)
+		return
+	}
+	collector.logger.Trace("Time Collection end", "group", group.name, "time", duration)
+}
+
+// Checks if the collector is not allowed to write to the dataplane, because of read-only mode or a missing lease
+func (collector *MetricCollector) IsReadOnly() bool {
+	return collector.driver.IsReadOnly()
+}
+
+// Returns the operating mode of the collector
+func (collector *MetricCollector) GetControllerStatus() *model.ControllerStatus {
+	role := model.CONTROLLER_ROLE_LEADER
+	if !collector.driver.IsLeader() {
+		role = model.CONTROLLER_ROLE_FOLLOWER
+	}
+	return &model.ControllerStatus{
+		Role:          role,
+		ReadOnly:      collector.driver.IsReadOnly(),
+		DeltaSampling: collector.deltaSampling,
+		HardwareSync:  collector.hwSync,
+	}
+}
+
+// Returns a snapshot of the statistics of all collection groups
+func (collector *MetricCollector) GetCollectorStats() []*model.CollectorGroupStats {
+	stats := make([]*model.CollectorGroupStats, 0, len(collector.groups))
+	for _, group := range collector.groups {
+		group.lock.Lock()
+		groupStats := group.stats
+		group.lock.Unlock()
+		stats = append(stats, &groupStats)
+	}
+	return stats
+}
+
+// Reads all probes per session and resets them afterwards.
+func (collector *MetricCollector) collectSessionMetrics() (*rawCollection, error) {
+	sessionIds := collector.ts.GetSessionIdCache()
+	allMetricRequests := make([]*bfruntime.Entity, 0)
+	metricRequests, err := collector.driver.GetMatchSelectorEntriesRequest()
+	if err == nil {
+		allMetricRequests = append(allMetricRequests, metricRequests...)
+	}
+	metricRequests, err = collector.driver.GetIngressHdrStartCounter(sessionIds)
+	if err == nil {
+		allMetricRequests = append(allMetricRequests, metricRequests...)
+	}
+	metricRequests, err = collector.driver.GetIngressHdrEndCounter(sessionIds)
+	if err == nil {
+		allMetricRequests = append(allMetricRequests, metricRequests...)
+	}
+	metricRequests, err = collector.driver.GetEgressStartCounter(sessionIds)
+	if err == nil {
+		allMetricRequests = append(allMetricRequests, metricRequests...)
+	}
+	metricRequests, err = collector.driver.GetEgressEndCounter(sessionIds)
+	if err == nil {
+		allMetricRequests = append(allMetricRequests, metricRequests...)
+	}
+	metricRequests, err = collector.driver.GetIngressJitter(sessionIds)
+	if err == nil {
+		allMetricRequests = append(allMetricRequests, metricRequests...)
+	}
+	metricRequests, err = collector.driver.GetPacketSizeHistogram(sessionIds)
+	if err == nil {
+		allMetricRequests = append(allMetricRequests, metricRequests...)
+	}
+	metricRequests, err = collector.driver.GetDropCounter(sessionIds)
+	if err == nil {
+		allMetricRequests = append(allMetricRequests, metricRequests...)
+	}
+	metricRequests, err = collector.driver.GetBurstMetrics(sessionIds)
+	if err == nil {
+		allMetricRequests = append(allMetricRequests, metricRequests...)
+	}
+	// App registers. Index ranges and whole register arrays are read separately.
+	appRegistersToReq := make([]*model.AppRegister, 0)
+	appRegisterRangesToReq := make([]*model.AppRegister, 0)
+	for _, appRegister := range collector.ts.GetAppRegisterProbes() {
+		if appRegister.All || appRegister.IndexEnd != nil {
+			appRegisterRangesToReq = append(appRegisterRangesToReq, appRegister)
+		} else {
+			appRegistersToReq = append(appRegistersToReq, appRegister)
+		}
+	}
+	if len(appRegistersToReq) > 0 {
+		metricRequests, err = collector.driver.GetMetricFromRegisterRequest(appRegistersToReq, model.METRIC_EXT_VALUE)
+		if err == nil {
+			allMetricRequests = append(allMetricRequests, metricRequests...)
+		}
+	}
+	// Counters and meters of the user application
+	appObjectsToReq := collector.ts.GetAppObjectProbes("")
+	if len(appObjectsToReq) > 0 {
+		metricRequests, err = collector.driver.GetMetricFromAppObjectRequest(appObjectsToReq)
+		if err == nil {
+			allMetricRequests = append(allMetricRequests, metricRequests...)
+		}
+	}
+	// Extra Probes
+	extraProbes := collector.driver.GetExtraProbes()
+	for i := range extraProbes {
+		metricRequests, err = collector.driver.GetHdrSizeCounter(extraProbes[i], sessionIds)
+		if err == nil {
+			allMetricRequests = append(allMetricRequests, metricRequests...)
+		}
+	}
+	// Sync all probes from the hardware first, so the read returns the values of all probes at the same moment.
+	var timestamp time.Time
+	synced := false
+	// Sync operations are write requests, hence not allowed as follower. Read-only mode is rejected at startup.
+	if collector.hwSync && collector.driver.IsReadOnly() {
+		if !collector.hwSyncSkipped {
+			collector.logger.Warn("Syncing probes from hardware is skipped, as the controller is not allowed to write to the dataplane. Probes do not share a timestamp until the lease has been acquired")
+			collector.hwSyncSkipped = true
+		}
+	} else if collector.hwSync {
+		if collector.hwSyncSkipped {
+			collector.logger.Info("Syncing probes from hardware has been resumed")
+			collector.hwSyncSkipped = false
+		}
+		timestamp, err = collector.driver.SyncProbeTables()
+		if err != nil {
+			if collector.errorIsCanceled(err) {
+				return nil, err
+			}
+			collector.logger.Warn("Syncing probes from hardware failed", "err", err)
+		} else {
+			// The probes are read from the software shadow holding the values of the sync
+			collector.driver.ReadFromSyncedTables(allMetricRequests)
+			synced = true
+		}
+	}
+	bfResponse, err := collector.driver.SendReadRequest(allMetricRequests)
+	if err != nil {
+		return nil, err
+	}
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	// Top flows need to be read before the sketch is reset. Already transformed by the driver.
+	readMetrics, err := collector.driver.GetHeavyHitterMetrics(sessionIds, synced)
+	if err != nil {
+		if collector.errorIsCanceled(err) {
+			return nil, err
+		}
+		collector.logger.Warn("Error occured during collection of heavy hitter metrics", "err", err)
+	}
+	// Direct counters of the user application are read by match key
+	directCounterMetrics, err := collector.driver.GetDirectCounterMetrics(appObjectsToReq)
+	if err != nil {
+		if collector.errorIsCanceled(err) {
+			return nil, err
+		}
+		collector.logger.Warn("Error occured during collection of direct counters", "err", err)
+	}
+	appRegisterRangeMetrics, err := collector.driver.GetAppRegisterRangeMetrics(appRegisterRangesToReq)
+	if err != nil {
+		if collector.errorIsCanceled(err) {
+			return nil, err
+		}
+		collector.logger.Warn("Error occured during collection of register ranges", "err", err)
+	}
+	readMetrics = append(readMetrics, appRegisterRangeMetrics...)
+	if collector.driver.IsReadOnly() {
+		// Nothing is written to the dataplane. The increments are computed during processing.
+	} else if collector.deltaSampling {
+		// Counters are never reset. The increments are computed during processing.
+		collector.ResetGauges(sessionIds)
+	} else {
+		// Reset counters
+		collector.ResetCounters(sessionIds)
+	}
+
+	return &rawCollection{entities: bfResponse, metrics: readMetrics, counters: directCounterMetrics, sessionIds: sessionIds, timestamp: timestamp}, nil
+}
+
+// Reads the traffic manager counters per monitored port and per pipeline
+func (collector *MetricCollector) collectTMMetrics() (*rawCollection, error) {
+	raw := &rawCollection{}
+	// Traffic manager requests per port
+	monitoredPorts := collector.ts.GetMonitoredPorts()
+	if len(monitoredPorts) > 0 {
+		metricRequests := collector.driver.GetTMCountersByPortRequests(monitoredPorts)
+		// Link state and RMON counters of the ports
+		metricRequests = append(metricRequests, collector.driver.GetPortRequests(monitoredPorts)...)
+		tmBfResponse, err := collector.driver.SendReadRequest(metricRequests)
+		if err != nil {
+			if collector.errorIsCanceled(err) {
+				return nil, err
+			}
+			collector.logger.Warn("Error occured during collection of traffic manager metric", "ports", monitoredPorts, "err", err)
+		}
+		raw.entities = tmBfResponse
+	}
+	raw.timestamp = time.Now()
+	// Traffic manager requests per pipeline
+	tmMetrics, err := collector.driver.GetTMPipelineCounter(collector.pipelineCount)
+	if err != nil {
+		if collector.errorIsCanceled(err) {
+			return nil, err
+		}
+		collector.logger.Warn("Error occured during collection of traffic manager metrics per pipeline", "err", err)
+	}
+	raw.metrics = tmMetrics
+	// Occupancy of the selector tables and the monitored tables
+	tableMetrics, err := collector.driver.GetTableOccupancyMetrics(collector.ts.GetMonitoredTables())
+	if err != nil {
+		if collector.errorIsCanceled(err) {
+			return nil, err
+		}
+		collector.logger.Warn("Error occured during collection of table occupancy", "err", err)
+	}
+	raw.metrics = append(raw.metrics, tableMetrics...)
+
+	return raw, nil
+}
+
+// Returns the monitored ports with their link state and RMON counters
+func (collector *MetricCollector) GetMonitoredPortDetails() ([]*model.DevPort, error) {
+	return collector.driver.GetPortDetails(collector.ts.GetMonitoredPorts())
+}
+
+// Returns the installed entries and the size of the monitored tables
+func (collector *MetricCollector) GetMonitoredTableOccupancy() ([]*model.TableOccupancy, error) {
+	return collector.driver.GetTableOccupancy(collector.ts.GetMonitoredTables())
+}
+
+// Transforms the raw responses of a cycle and sends them to the sink
+func (collector *MetricCollector) processCollection(raw *rawCollection, metricSink chan *model.MetricItem) {
+	metrics, err := collector.driver.ProcessMetricResponseAt(raw.entities, raw.timestamp)
+	if err != nil {
+		collector.logger.Error("Error occured during processing raw metric values", "err", err)
+		return
+	}
+	// Counters of the user application are never reset. Hence their increments are always computed in software.
+	if raw.sessionIds != nil {
+		metrics = append(metrics, raw.counters...)
+		metrics = collector.applyAppDeltas(metrics, collector.ts.GetAppObjectProbes(""))
+	}
+	if collector.deltaSampling {
+		metrics = collector.applyDeltas(metrics, raw.sessionIds)
+	}
+	// Totals and peaks of time-boxed measurements
+	if raw.sessionIds != nil {
+		collector.recordMeasurements(metrics, raw.timestamp)
+	}
+	// Derive packets dropped in TM per session
+	metrics = append(metrics, collector.driver.GetTMDropMetrics(metrics)...)
+	// Append metrics, which have been already transformed
+	metrics = append(metrics, raw.metrics...)
+	// Send to sink thread
+	for i := range metrics {
+		metricSink <- metrics[i]
+	}
 }
 
 func (collector *MetricCollector) ResetCounters(sessionIds []uint32) {
@@ -183,6 +491,9 @@ func (collector *MetricCollector) ResetCounters(sessionIds []uint32) {
 	// Counter Reset requests
 	resetRequests = collector.driver.GetResetCounterRequests(sessionIds)
 	allResetRequests = append(allResetRequests, resetRequests...)
+	// Clear heavy hitter sketch
+	resetRequests = collector.driver.GetResetHeavyHitterRequests()
+	allResetRequests = append(allResetRequests, resetRequests...)
 	err = collector.driver.SendWriteRequest(allResetRequests)
 	if err != nil {
 		// Check if grpc request has been canceled
@@ -194,6 +505,57 @@ func (collector *MetricCollector) ResetCounters(sessionIds []uint32) {
 	}
 }
 
+// Replaces the raw values of all counters by their increments since the previous cycle.
+// Counters without previous value are dropped, as their increment is unknown.
+func (collector *MetricCollector) applyDeltas(metrics []*model.MetricItem, sessionIds []uint32) []*model.MetricItem {
+	if sessionIds != nil {
+		collector.deltaSampler.prune(sessionIds)
+	}
+	deltaMetrics := make([]*model.MetricItem, 0, len(metrics))
+	for _, metric := range metrics {
+		width, isCounter := collector.driver.GetCounterWidth(metric)
+		if isCounter && !collector.deltaSampler.apply(metric, width) {
+			continue
+		}
+		deltaMetrics = append(deltaMetrics, metric)
+	}
+
+	return deltaMetrics
+}
+
+// Replaces the raw values of the counters and direct counters of the user application by their increments since the previous cycle.
+// The first sample of a counter is dropped, as its increment is unknown.
+func (collector *MetricCollector) applyAppDeltas(metrics []*model.MetricItem, appObjects []*model.AppObject) []*model.MetricItem {
+	collector.appDeltaSampler.retain(appObjects)
+	deltaMetrics := make([]*model.MetricItem, 0, len(metrics))
+	for _, metric := range metrics {
+		width, isCounter := collector.driver.GetAppCounterWidth(metric)
+		if isCounter && !collector.appDeltaSampler.apply(metric, width) {
+			continue
+		}
+		deltaMetrics = append(deltaMetrics, metric)
+	}
+
+	return deltaMetrics
+}
+
+// Resets the peak queue depth and the heavy hitter sketch, which hold a value per interval and are no counters.
+// No increments get lost as the counters are kept.
+func (collector *MetricCollector) ResetGauges(sessionIds []uint32) {
+	allResetRequests := collector.driver.GetResetGaugeRegisterRequests(sessionIds)
+	allResetRequests = append(allResetRequests, collector.driver.GetResetHeavyHitterRequests()...)
+	if len(allResetRequests) == 0 {
+		return
+	}
+	err := collector.driver.SendWriteRequest(allResetRequests)
+	if err != nil {
+		if collector.errorIsCanceled(err) {
+			return
+		}
+		collector.logger.Error("Resetting peak queue depth and heavy hitter sketch failed!", "err", err)
+	}
+}
+
 // Checks if given error is context canceled error
 // Most probably initiated by the user.
 func (collector *MetricCollector) errorIsCanceled(err error) bool {
@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/thushjandan/pifina/pkg/controller/dataplane/tofino/driver"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Counter of the user application with bytes and packets
+const testBfrtInfo = `{"tables": [{
+	"name": "pipe.SwitchIngress.app_counter", "id": 33554436, "table_type": "Counter", "size": 1024,
+	"key": [{"id": 65556, "name": "$COUNTER_INDEX", "match_type": "Exact", "type": {"type": "uint32"}}],
+	"data": [
+		{"singleton": {"id": 65553, "name": "$COUNTER_SPEC_BYTES", "type": {"type": "uint64"}}},
+		{"singleton": {"id": 65554, "name": "$COUNTER_SPEC_PKTS", "type": {"type": "uint64"}}}
+	]
+}]}`
+
+// Creates a collector with a driver, which knows the tables of testBfrtInfo, but is not connected
+func newTestCollector(t *testing.T) *MetricCollector {
+	d := driver.NewTofinoDriver(hclog.NewNullLogger(), "test", false)
+	if err := d.LoadBfrtInfo([]byte(testBfrtInfo), []byte("{}")); err != nil {
+		t.Fatalf("cannot load BfrtInfo: %v", err)
+	}
+
+	return NewMetricCollector(hclog.NewNullLogger(), d, 50, 1000, nil, 1, false, false, 10, "")
+}
+
+// App counters are never reset, hence the collector needs to send the increments
+func TestApplyAppDeltas(t *testing.T) {
+	collector := newTestCollector(t)
+	appObjects := []*model.AppObject{{Name: "pipe.SwitchIngress.app_counter", Type: model.APP_OBJECT_TYPE_COUNTER, Index: 3}}
+
+	tests := []struct {
+		name     string
+		value    uint64
+		sent     bool
+		expected uint64
+	}{
+		{name: "first sample", value: 1000, sent: false},
+		{name: "unchanged", value: 1000, sent: true, expected: 0},
+		{name: "increased", value: 1500, sent: true, expected: 500},
+		{name: "unchanged again", value: 1500, sent: true, expected: 0},
+		{name: "wraparound", value: 100, sent: true, expected: (1 << driver.COUNTER_PKTS_WIDTH) - 1500 + 100},
+	}
+
+	for _, test := range tests {
+		counter := &model.MetricItem{SessionId: 3, MetricName: "pipe.SwitchIngress.app_counter", Type: model.METRIC_PKTS, Value: test.value}
+		probe := &model.MetricItem{SessionId: 3, MetricName: driver.PROBE_INGRESS_JITTER_REGISTER, Type: model.METRIC_EXT_VALUE, Value: test.value}
+		metrics := collector.applyAppDeltas([]*model.MetricItem{counter, probe}, appObjects)
+		if len(metrics) == 0 || metrics[len(metrics)-1] != probe || probe.Value != test.value {
+			t.Errorf("%s: expected other metrics to be kept unchanged", test.name)
+		}
+		if sent := len(metrics) == 2; sent != test.sent {
+			t.Errorf("%s: expected counter sent %v, got %v", test.name, test.sent, sent)
+			continue
+		}
+		if test.sent && counter.Value != test.expected {
+			t.Errorf("%s: expected increment %d, got %d", test.name, test.expected, counter.Value)
+		}
+	}
+
+	// A counter, which is watched again, starts with a new first sample
+	collector.applyAppDeltas(nil, nil)
+	counter := &model.MetricItem{SessionId: 3, MetricName: "pipe.SwitchIngress.app_counter", Type: model.METRIC_PKTS, Value: 2000}
+	if metrics := collector.applyAppDeltas([]*model.MetricItem{counter}, appObjects); len(metrics) != 0 {
+		t.Errorf("removed app object: expected first sample to be dropped, got %d metrics", len(metrics))
+	}
+}
@@ -8,6 +8,7 @@ package controller
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/thushjandan/pifina/pkg/controller/api"
@@ -20,17 +21,21 @@ import (
 )
 
 type TofinoController struct {
-	ctx            context.Context
-	logger         hclog.Logger
-	endpoint       string
-	p4name         string
-	connectTimeout int
-	driver         *driver.TofinoDriver
-	collector      *collector.MetricCollector
-	ts             *trafficselector.TrafficSelector
-	sink           *sink.Sink
-	bp             *bufferpool.Bufferpool
-	api            *api.ControllerApiServer
+	ctx             context.Context
+	logger          hclog.Logger
+	endpoint        string
+	p4name          string
+	connectTimeout  int
+	driver          *driver.TofinoDriver
+	collector       *collector.MetricCollector
+	ts              *trafficselector.TrafficSelector
+	sink            *sink.Sink
+	bp              *bufferpool.Bufferpool
+	api             *api.ControllerApiServer
+	readOnly        bool
+	leaseInterval   time.Duration
+	monitoredTables []string
+	discoveryPolicy *model.FlowDiscoveryPolicy
 }
 
 type TofinoControllerOptions struct {
@@ -41,32 +46,51 @@ type TofinoControllerOptions struct {
 	P4name                  string
 	CollectorServerEndpoint string
 	SampleInterval          int
+	TMSampleInterval        int
 	APIPort                 string
 	LpfTimeConst            float32
 	PipelineCount           int
+	DeltaSampling           bool
+	HardwareSync            bool
+	ReadOnly                bool
+	LeaseInterval           int      // Interval in seconds, in which a follower tries to acquire the single-writer lease. 0 disables the lease.
+	MonitoredTables         []string // Tables of the user application, whose occupancy is monitored in addition to the selector tables
+	DiscoveryInterval       int      // Interval in seconds, in which the filter of the flow discovery digest is cleared and selector rules are created for new flows
+	DiscoveryPolicy         *model.FlowDiscoveryPolicy
+	MeasurementFile         string // File, in which scheduled and running measurements are kept over a restart. Empty disables it.
 }
 
 func NewTofinoController(options *TofinoControllerOptions) *TofinoController {
 	if options.Logger == nil {
 		return nil
 	}
-	driver := driver.NewTofinoDriver(options.Logger, options.P4name)
+	driver := driver.NewTofinoDriver(options.Logger, options.P4name, options.ReadOnly)
 	ts := trafficselector.NewTrafficSelector(options.Logger, driver, options.LpfTimeConst)
-	collector := collector.NewMetricCollector(options.Logger, driver, options.SampleInterval, ts, options.PipelineCount)
+	collector := collector.NewMetricCollector(options.Logger, driver, options.SampleInterval, options.TMSampleInterval, ts, options.PipelineCount, options.DeltaSampling, options.HardwareSync, options.DiscoveryInterval, options.MeasurementFile)
 	bp := bufferpool.NewBufferpool(options.Logger, driver, ts)
-	apiServer := api.NewControllerApiServer(options.Logger, options.APIPort, ts, bp)
+	apiServer := api.NewControllerApiServer(options.Logger, options.APIPort, ts, bp, collector)
+	apiPort, err := sink.ParseApiPort(options.APIPort)
+	if err != nil {
+		options.Logger.Warn("Cannot announce API port to pifina server. Default port of the pifina server is used", "port", options.APIPort, "err", err)
+	}
 	sink := sink.NewSink(options.Logger, model.HOSTTYPE_TOFINO, options.CollectorServerEndpoint, uint32(options.GroupId))
+	// The pifina server proxies the API requests of the web frontend to this port
+	sink.SetApiPort(apiPort)
 	return &TofinoController{
-		logger:         options.Logger.Named("controller"),
-		driver:         driver,
-		collector:      collector,
-		endpoint:       options.Endpoint,
-		p4name:         options.P4name,
-		connectTimeout: options.ConnectTimeout,
-		sink:           sink,
-		ts:             ts,
-		bp:             bp,
-		api:            apiServer,
+		logger:          options.Logger.Named("controller"),
+		driver:          driver,
+		collector:       collector,
+		endpoint:        options.Endpoint,
+		p4name:          options.P4name,
+		connectTimeout:  options.ConnectTimeout,
+		sink:            sink,
+		ts:              ts,
+		bp:              bp,
+		api:             apiServer,
+		readOnly:        options.ReadOnly,
+		leaseInterval:   time.Duration(options.LeaseInterval) * time.Second,
+		monitoredTables: options.MonitoredTables,
+		discoveryPolicy: options.DiscoveryPolicy,
 	}
 }
 
@@ -83,6 +107,30 @@ func (controller *TofinoController) StartController(ctx context.Context, wg *syn
 	if err != nil {
 		return err
 	}
+	for _, tblName := range controller.monitoredTables {
+		if err := controller.ts.AddTableToMonitor(tblName); err != nil {
+			controller.logger.Warn("Cannot monitor occupancy of table", "table", tblName, "err", err)
+		}
+	}
+	if controller.discoveryPolicy != nil {
+		if err := controller.collector.SetFlowDiscoveryPolicy(controller.discoveryPolicy); err != nil {
+			return err
+		}
+	}
+	// Only a single controller is allowed to write to the switch
+	if !controller.readOnly && controller.leaseInterval > 0 {
+		err = controller.driver.AcquireLease()
+		if err != nil {
+			controller.logger.Warn("Another client is bound to the P4 program. Starting as read-only follower", "err", err)
+		}
+		go controller.maintainLease(ctx)
+	}
+	// Without a lease, the client is bound anyway to receive the digests of the flow discovery
+	if !controller.readOnly && controller.leaseInterval == 0 && controller.driver.HasFlowDigest() {
+		if err := controller.driver.BindProgram(); err != nil {
+			controller.logger.Warn("Cannot bind to the P4 program. Flow discovery digests are not received", "err", err)
+		}
+	}
 
 	metricDataChannel := make(chan *model.MetricItem, 10)
 	metricsSinkChannel := make(chan *model.SinkEmitCommand)
@@ -101,3 +149,37 @@ func (controller *TofinoController) StartController(ctx context.Context, wg *syn
 
 	return nil
 }
+
+// Followers try to acquire the lease every interval and take over, as soon as the leader has disappeared.
+// In the meantime, the selector rules are reloaded, as the leader can change them anytime.
+func (controller *TofinoController) maintainLease(ctx context.Context) {
+	ticker := time.NewTicker(controller.leaseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if controller.driver.IsLeader() {
+				continue
+			}
+			leaseErr := controller.driver.AcquireLease()
+			if leaseErr != nil {
+				controller.logger.Debug("Lease is still held by another client", "err", leaseErr)
+			}
+			err := controller.ts.LoadSessionsFromDevice()
+			if err != nil {
+				controller.logger.Warn("Cannot reload selector rules from the dataplane", "err", err)
+			}
+			if leaseErr != nil {
+				continue
+			}
+			// LPF instances of rules, which have been added without LPF parameter by the previous owner, are initialized like at startup
+			err = controller.ts.ConfigureLPF()
+			if err != nil {
+				controller.logger.Error("Error occured during LPF initialization after acquiring the lease", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Returns from cache all available indirect counters on device.
+func (driver *TofinoDriver) GetAllCounterNames() []string {
+	return driver.getTableNamesByType(TABLE_TYPE_COUNTER)
+}
+
+// Returns from cache all available meters on device.
+func (driver *TofinoDriver) GetAllMeterNames() []string {
+	return driver.getTableNamesByType(TABLE_TYPE_METER)
+}
+
+// Returns from cache all match action tables with a direct counter on device.
+func (driver *TofinoDriver) GetAllDirectCounterNames() []string {
+	tableNames := make([]string, 0)
+	for _, tblName := range driver.getTableNamesByType(TABLE_TYPE_MATCHACTION) {
+		if driver.hasDirectCounter(tblName) {
+			tableNames = append(tableNames, tblName)
+		}
+	}
+
+	return tableNames
+}
+
+func (driver *TofinoDriver) getTableNamesByType(tableType string) []string {
+	tableNames := make([]string, 0)
+	for i := range driver.P4Tables {
+		if driver.P4Tables[i].TableType == tableType {
+			tableNames = append(tableNames, driver.P4Tables[i].Name)
+		}
+	}
+
+	return tableNames
+}
+
+func (driver *TofinoDriver) hasDirectCounter(tblName string) bool {
+	return driver.GetSingletonDataIdByName(tblName, COUNTER_SPEC_BYTES) != 0 || driver.GetSingletonDataIdByName(tblName, COUNTER_SPEC_PKTS) != 0
+}
+
+// Returns the bit width of a counter or direct counter of the user application.
+// These counters are never reset by PIFINA, hence the increments are always computed from the previous sample.
+// A counter with bytes and packets shares 64 bits, while a counter of a single type uses all 64 bits.
+func (driver *TofinoDriver) GetAppCounterWidth(metric *model.MetricItem) (uint, bool) {
+	if metric.Type != model.METRIC_BYTES && metric.Type != model.METRIC_PKTS {
+		return 0, false
+	}
+	sliceIdx, ok := driver.indexP4Tables[metric.MetricName]
+	if !ok {
+		return 0, false
+	}
+	switch driver.P4Tables[sliceIdx].TableType {
+	case TABLE_TYPE_COUNTER:
+	case TABLE_TYPE_MATCHACTION:
+		if !driver.hasDirectCounter(metric.MetricName) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	hasBytes := driver.GetSingletonDataIdByName(metric.MetricName, COUNTER_SPEC_BYTES) != 0
+	hasPkts := driver.GetSingletonDataIdByName(metric.MetricName, COUNTER_SPEC_PKTS) != 0
+	if !hasBytes || !hasPkts {
+		return COUNTER_PKTS_ONLY_WIDTH, true
+	}
+	if metric.Type == model.METRIC_PKTS {
+		return COUNTER_PKTS_WIDTH, true
+	}
+
+	return COUNTER_BYTES_WIDTH, true
+}
+
+// Checks if the table of the app object exists and is of the given type.
+func (driver *TofinoDriver) ValidateAppObject(appObject *model.AppObject) error {
+	sliceIdx, ok := driver.indexP4Tables[appObject.Name]
+	if !ok {
+		return &model.ErrNameNotFound{Msg: "Table not found", Entity: appObject.Name}
+	}
+	tableType := driver.P4Tables[sliceIdx].TableType
+	switch appObject.Type {
+	case model.APP_OBJECT_TYPE_COUNTER:
+		if tableType != TABLE_TYPE_COUNTER {
+			return fmt.Errorf("table %s is not a counter", appObject.Name)
+		}
+	case model.APP_OBJECT_TYPE_METER:
+		if tableType != TABLE_TYPE_METER {
+			return fmt.Errorf("table %s is not a meter", appObject.Name)
+		}
+	case model.APP_OBJECT_TYPE_DIRECT_COUNTER:
+		if tableType != TABLE_TYPE_MATCHACTION || !driver.hasDirectCounter(appObject.Name) {
+			return fmt.Errorf("table %s has no direct counter", appObject.Name)
+		}
+		if len(appObject.Keys) == 0 {
+			return fmt.Errorf("match key of the table entry is missing")
+		}
+	default:
+		return fmt.Errorf("unknown object type %s", appObject.Type)
+	}
+	if appObject.Type != model.APP_OBJECT_TYPE_DIRECT_COUNTER && appObject.Index >= driver.P4Tables[sliceIdx].Size {
+		return fmt.Errorf("index %d is out of range. %s has a size of %d", appObject.Index, appObject.Name, driver.P4Tables[sliceIdx].Size)
+	}
+
+	return nil
+}
+
+// Generate GRPC request payload for retrieving indirect counters and meters by index.
+// Direct counters are skipped, as they are read by GetDirectCounterMetrics.
+func (driver *TofinoDriver) GetMetricFromAppObjectRequest(appObjects []*model.AppObject) ([]*bfruntime.Entity, error) {
+	tblEntries := []*bfruntime.Entity{}
+
+	for i := range appObjects {
+		var keyName string
+		switch appObjects[i].Type {
+		case model.APP_OBJECT_TYPE_COUNTER:
+			keyName = COUNTER_INDEX_KEY_NAME
+		case model.APP_OBJECT_TYPE_METER:
+			keyName = METER_INDEX_KEY_NAME
+		default:
+			continue
+		}
+		tblId := driver.GetTableIdByName(appObjects[i].Name)
+		if tblId == 0 {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: appObjects[i].Name}
+		}
+		keyId := driver.GetKeyIdByName(appObjects[i].Name, keyName)
+		if keyId == 0 {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find key id for table name", Entity: appObjects[i].Name}
+		}
+
+		// Convert to byte slice
+		byteEntryId := make([]byte, 4)
+		binary.BigEndian.PutUint32(byteEntryId, appObjects[i].Index)
+
+		tblEntries = append(tblEntries,
+			&bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						TableId: tblId,
+						TableFlags: &bfruntime.TableFlags{
+							FromHw: true,
+						},
+						Value: &bfruntime.TableEntry_Key{
+							Key: &bfruntime.TableKey{
+								Fields: []*bfruntime.KeyField{
+									{
+										FieldId: keyId,
+										MatchType: &bfruntime.KeyField_Exact_{
+											Exact: &bfruntime.KeyField_Exact{
+												Value: byteEntryId,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		)
+	}
+
+	return tblEntries, nil
+}
+
+// Transform the meter configuration to metrics. Each rate and burst size is its own metric
+// named <table name>_<field> like pipe.SwitchIngress.meter_CIR_KBPS.
+func (driver *TofinoDriver) ProcessMeterResponse(entity *bfruntime.Entity) ([]*model.MetricItem, error) {
+	tblName := driver.GetTableNameById(entity.GetTableEntry().GetTableId())
+	keyFields := entity.GetTableEntry().GetKey().GetFields()
+	if len(keyFields) == 0 {
+		return nil, &model.ErrNameNotFound{Msg: "Meter response without index", Entity: tblName}
+	}
+	index := decodeUint32(keyFields[0].GetExact().GetValue())
+
+	transformedMetrics := make([]*model.MetricItem, 0)
+	for _, dataField := range entity.GetTableEntry().GetData().GetFields() {
+		dataName := driver.GetSingletonDataNameById(tblName, dataField.FieldId)
+		if !strings.HasPrefix(dataName, METER_SPEC_PREFIX) {
+			continue
+		}
+		// Configured rates and burst sizes are not counters. The unit like KBPS, PPS, KBITS or PKTS is kept in the field name.
+		fieldName := strings.TrimPrefix(dataName, METER_SPEC_PREFIX)
+		transformedMetrics = append(transformedMetrics, &model.MetricItem{
+			SessionId:  index,
+			Value:      decodeUint64(dataField.GetStream()),
+			Type:       model.METRIC_EXT_VALUE,
+			MetricName: fmt.Sprintf("%s_%s", tblName, fieldName),
+		})
+	}
+
+	return transformedMetrics, nil
+}
+
+// Reads the direct counters of the given table entries. The index of the app object is used as sessionId.
+// The values are cumulative and converted to increments by the collector.
+func (driver *TofinoDriver) GetDirectCounterMetrics(appObjects []*model.AppObject) ([]*model.MetricItem, error) {
+	directCounters := make([]*model.AppObject, 0, len(appObjects))
+	tblEntries := []*bfruntime.Entity{}
+	for i := range appObjects {
+		if appObjects[i].Type != model.APP_OBJECT_TYPE_DIRECT_COUNTER {
+			continue
+		}
+		tblName := appObjects[i].Name
+		tblId := driver.GetTableIdByName(tblName)
+		if tblId == 0 {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+		}
+		dataFields := []*bfruntime.DataField{}
+		for _, dataName := range []string{COUNTER_SPEC_BYTES, COUNTER_SPEC_PKTS} {
+			if dataId := driver.GetSingletonDataIdByName(tblName, dataName); dataId != 0 {
+				dataFields = append(dataFields, &bfruntime.DataField{FieldId: dataId})
+			}
+		}
+		directCounters = append(directCounters, appObjects[i])
+		tblEntries = append(tblEntries,
+			&bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						TableId: tblId,
+						TableFlags: &bfruntime.TableFlags{
+							FromHw: true,
+						},
+						Value: &bfruntime.TableEntry_Key{
+							Key: &bfruntime.TableKey{
+								Fields: driver.transformMatchSelectorEntryKeys(tblName, &model.MatchSelectorEntry{Keys: appObjects[i].Keys, Priority: appObjects[i].Priority}),
+							},
+						},
+						Data: &bfruntime.TableData{
+							Fields: dataFields,
+						},
+					},
+				},
+			},
+		)
+	}
+	if len(tblEntries) == 0 {
+		return nil, nil
+	}
+
+	entities, err := driver.SendReadRequest(tblEntries)
+	if err != nil {
+		return nil, err
+	}
+	// Entries read by key are returned in the order of the request
+	if len(entities) != len(directCounters) {
+		return nil, fmt.Errorf("expected %d direct counter entries, but device returned %d", len(directCounters), len(entities))
+	}
+
+	timeNow := time.Now()
+	transformedMetrics := make([]*model.MetricItem, 0, 2*len(entities))
+	for i := range entities {
+		tblName := directCounters[i].Name
+		counterBytesKeyId := driver.GetSingletonDataIdByName(tblName, COUNTER_SPEC_BYTES)
+		counterPktsKeyId := driver.GetSingletonDataIdByName(tblName, COUNTER_SPEC_PKTS)
+		for _, dataField := range entities[i].GetTableEntry().GetData().GetFields() {
+			metricType := ""
+			switch dataField.GetFieldId() {
+			case counterBytesKeyId:
+				metricType = model.METRIC_BYTES
+			case counterPktsKeyId:
+				metricType = model.METRIC_PKTS
+			default:
+				continue
+			}
+			transformedMetrics = append(transformedMetrics, &model.MetricItem{
+				SessionId:   directCounters[i].Index,
+				Value:       decodeUint64(dataField.GetStream()),
+				Type:        metricType,
+				MetricName:  tblName,
+				LastUpdated: timeNow,
+			})
+		}
+	}
+
+	return transformedMetrics, nil
+}
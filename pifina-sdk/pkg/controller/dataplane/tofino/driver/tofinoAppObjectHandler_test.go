@@ -0,0 +1,38 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func TestGetAppCounterWidth(t *testing.T) {
+	driver := newTestDriver(t)
+
+	tests := []struct {
+		name      string
+		metric    *model.MetricItem
+		width     uint
+		isCounter bool
+	}{
+		{name: "counter bytes", metric: &model.MetricItem{MetricName: "pipe.SwitchIngress.app_counter", Type: model.METRIC_BYTES}, width: COUNTER_BYTES_WIDTH, isCounter: true},
+		{name: "counter packets", metric: &model.MetricItem{MetricName: "pipe.SwitchIngress.app_counter", Type: model.METRIC_PKTS}, width: COUNTER_PKTS_WIDTH, isCounter: true},
+		{name: "packet only counter", metric: &model.MetricItem{MetricName: "pipe.SwitchIngress.app_pkts", Type: model.METRIC_PKTS}, width: COUNTER_PKTS_ONLY_WIDTH, isCounter: true},
+		{name: "direct counter", metric: &model.MetricItem{MetricName: "pipe.SwitchIngress.forward", Type: model.METRIC_PKTS}, width: COUNTER_PKTS_WIDTH, isCounter: true},
+		{name: "register", metric: &model.MetricItem{MetricName: "pipe.SwitchIngress.app_register", Type: model.METRIC_EXT_VALUE}},
+		{name: "meter", metric: &model.MetricItem{MetricName: "pipe.SwitchIngress.meter_CIR_KBPS", Type: model.METRIC_EXT_VALUE}},
+		{name: "probe", metric: &model.MetricItem{MetricName: PROBE_EGRESS_START_CNT, Type: model.METRIC_PKTS}},
+	}
+
+	for _, test := range tests {
+		width, isCounter := driver.GetAppCounterWidth(test.metric)
+		if isCounter != test.isCounter || width != test.width {
+			t.Errorf("%s: expected width %d and counter %v, got %d and %v", test.name, test.width, test.isCounter, width, isCounter)
+		}
+	}
+}
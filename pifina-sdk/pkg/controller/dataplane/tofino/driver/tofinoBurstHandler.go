@@ -0,0 +1,149 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Checks if the microburst probe has been compiled into the P4 application.
+func (driver *TofinoDriver) HasBurstProbe() bool {
+	_, ok := driver.probeTableMap[PROBE_EGRESS_BURST_CNT]
+	return ok
+}
+
+// Retrieve the burst packet counter and the peak queue depth by a list of sessionIds, if the microburst probe is installed.
+func (driver *TofinoDriver) GetBurstMetrics(sessionIds []uint32) ([]*bfruntime.Entity, error) {
+	driver.logger.Trace("Requesting microburst metrics", "sessionIds", sessionIds)
+	if len(sessionIds) == 0 || !driver.HasBurstProbe() {
+		return nil, nil
+	}
+
+	registersToReq := make([]*model.AppRegister, 0, 2*len(sessionIds))
+	for _, shortTblName := range []string{PROBE_EGRESS_BURST_CNT, PROBE_EGRESS_BURST_MAX_QDEPTH} {
+		tblName := driver.FindTableNameByShortName(shortTblName)
+		if tblName == "" {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: shortTblName}
+		}
+		registersToReq = append(registersToReq, driver.transformSessionIdToAppRegister(sessionIds, tblName)...)
+	}
+
+	return driver.GetMetricFromRegisterRequest(registersToReq, model.METRIC_EXT_VALUE)
+}
+
+// Reads the queue depth threshold in cells of the microburst probe from the default action of the threshold table.
+func (driver *TofinoDriver) GetBurstThreshold() (uint32, error) {
+	tblId, actionName, err := driver.getBurstThresholdTable()
+	if err != nil {
+		return 0, err
+	}
+	tblName := driver.GetTableNameById(tblId)
+	dataId := driver.GetDataIdByName(tblName, actionName, PROBE_BURST_THRESHOLD_ACTION_NAME_VALUE)
+
+	entities, err := driver.SendReadRequest([]*bfruntime.Entity{
+		{
+			Entity: &bfruntime.Entity_TableEntry{
+				TableEntry: &bfruntime.TableEntry{
+					TableId:        tblId,
+					IsDefaultEntry: true,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range entities {
+		for _, dataField := range entities[i].GetTableEntry().GetData().GetFields() {
+			if dataField.GetFieldId() == dataId {
+				return decodeUint32(dataField.GetStream()), nil
+			}
+		}
+	}
+
+	return 0, &model.ErrNameNotFound{Msg: "Cannot find action param in default entry", Entity: PROBE_BURST_THRESHOLD_ACTION_NAME_VALUE}
+}
+
+// Sets the queue depth threshold in cells of the microburst probe by modifying the default action of the threshold table.
+func (driver *TofinoDriver) SetBurstThreshold(threshold uint32) error {
+	tblId, actionName, err := driver.getBurstThresholdTable()
+	if err != nil {
+		return err
+	}
+	tblName := driver.GetTableNameById(tblId)
+	actionId := driver.GetActionIdByName(tblName, actionName)
+	dataId := driver.GetDataIdByName(tblName, actionName, PROBE_BURST_THRESHOLD_ACTION_NAME_VALUE)
+	if dataId == 0 {
+		return &model.ErrNameNotFound{Msg: "Cannot find action param name", Entity: PROBE_BURST_THRESHOLD_ACTION_NAME_VALUE}
+	}
+	dataWidth := driver.GetActionDataWidthByName(tblName, actionName, PROBE_BURST_THRESHOLD_ACTION_NAME_VALUE)
+	if dataWidth < 32 && threshold >= 1<<dataWidth {
+		return fmt.Errorf("burst threshold %d exceeds the maximum queue depth of %d cells", threshold, uint32(1<<dataWidth)-1)
+	}
+
+	// The threshold is encoded with the minimal amount of bytes.
+	byteThreshold := make([]byte, 4)
+	binary.BigEndian.PutUint32(byteThreshold, threshold)
+	byteArrayWidth := len(byteThreshold) - ((int(dataWidth) + 7) / 8)
+
+	updateReq := []*bfruntime.Update{
+		{
+			Type: bfruntime.Update_MODIFY,
+			Entity: &bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						TableId:        tblId,
+						IsDefaultEntry: true,
+						Data: &bfruntime.TableData{
+							ActionId: actionId,
+							Fields: []*bfruntime.DataField{
+								{
+									FieldId: dataId,
+									Value: &bfruntime.DataField_Stream{
+										Stream: byteThreshold[byteArrayWidth:],
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = driver.SendWriteRequest(updateReq)
+	if err != nil {
+		return err
+	}
+	driver.logger.Info("Burst threshold has been configured", "threshold", threshold)
+
+	return nil
+}
+
+// Returns the table id and the full action name of the threshold table of the microburst probe.
+func (driver *TofinoDriver) getBurstThresholdTable() (uint32, string, error) {
+	tblName, ok := driver.probeTableMap[PROBE_EGRESS_BURST_THRESHOLD]
+	if !ok {
+		return 0, "", &model.ErrNameNotFound{Msg: "Microburst probe is not installed", Entity: PROBE_EGRESS_BURST_THRESHOLD}
+	}
+
+	tblId := driver.GetTableIdByName(tblName)
+	if tblId == 0 {
+		return 0, "", &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+	}
+
+	actionName := driver.FindFullActionName(tblName, PROBE_BURST_THRESHOLD_ACTION_NAME)
+	if actionName == "" {
+		return 0, "", &model.ErrNameNotFound{Msg: "Cannot find action name", Entity: PROBE_BURST_THRESHOLD_ACTION_NAME}
+	}
+
+	return tblId, actionName, nil
+}
@@ -6,6 +6,7 @@
 package driver
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -13,10 +14,28 @@ func (driver *TofinoDriver) createP4TableIndex() {
 	driver.indexP4Tables = make(map[string]int)
 	driver.indexByIdP4Tables = make(map[uint32]int)
 	driver.extraProbeNameCache = make([]string, 0)
+	driver.selectorTableCache = make([]string, 0)
+	driver.heavyHitterTableCache = make([]string, 0)
 	for i := range driver.P4Tables {
 		name := driver.P4Tables[i].Name
 		driver.indexP4Tables[name] = i
 		driver.indexByIdP4Tables[driver.P4Tables[i].Id] = i
+		tblNameSplit := strings.Split(name, ".")
+		shortTblName := tblNameSplit[len(tblNameSplit)-1]
+		// Check if it is a selector table. Multiple selector tables with a suffix can exist like PF_INGRESS_MATCH_CNT_ROCE
+		if shortTblName == PROBE_INGRESS_MATCH_CNT || strings.HasPrefix(shortTblName, PROBE_INGRESS_MATCH_CNT+"_") {
+			driver.probeTableMap[shortTblName] = name
+			driver.probeTableMap[name] = shortTblName
+			driver.selectorTableCache = append(driver.selectorTableCache, shortTblName)
+			continue
+		}
+		// Registers of the heavy hitter probe. The amount depends on the flow key.
+		if strings.HasPrefix(shortTblName, PROBE_HH_PREFIX) {
+			driver.probeTableMap[shortTblName] = name
+			driver.probeTableMap[name] = shortTblName
+			driver.heavyHitterTableCache = append(driver.heavyHitterTableCache, shortTblName)
+			continue
+		}
 		// Find the full table name of each probe and cache it
 		for _, probe := range PROBE_TABLES {
 			if strings.Contains(name, probe) {
@@ -27,8 +46,6 @@ func (driver *TofinoDriver) createP4TableIndex() {
 		}
 		// Check if there are any extra probes installed
 		if strings.Contains(name, PROBE_EXTRA_PREFIX) {
-			tblNameSplit := strings.Split(name, ".")
-			shortTblName := tblNameSplit[len(tblNameSplit)-1]
 			// Add the short name to the table cache
 			driver.probeTableMap[shortTblName] = name
 			driver.probeTableMap[name] = shortTblName
@@ -36,6 +53,13 @@ func (driver *TofinoDriver) createP4TableIndex() {
 			driver.extraProbeNameCache = append(driver.extraProbeNameCache, shortTblName)
 		}
 	}
+	// Default selector table first, followed by the additional selector tables
+	sort.Slice(driver.selectorTableCache, func(i, j int) bool {
+		if driver.selectorTableCache[i] == PROBE_INGRESS_MATCH_CNT || driver.selectorTableCache[j] == PROBE_INGRESS_MATCH_CNT {
+			return driver.selectorTableCache[i] == PROBE_INGRESS_MATCH_CNT
+		}
+		return driver.selectorTableCache[i] < driver.selectorTableCache[j]
+	})
 }
 
 func (driver *TofinoDriver) createNonP4TableIndex() {
@@ -90,6 +114,17 @@ func (driver *TofinoDriver) GetTableNameById(tblId uint32) string {
 	return tblName
 }
 
+func (driver *TofinoDriver) GetTableSizeByName(tblName string) uint32 {
+	tblSize := uint32(0)
+	// Find table name in index
+	if sliceIdx, ok := driver.indexP4Tables[tblName]; ok {
+		// Table name has been found in hash table
+		return driver.P4Tables[sliceIdx].Size
+	}
+
+	return tblSize
+}
+
 func (driver *TofinoDriver) GetTableTypeById(tblId uint32) string {
 	tblType := ""
 	// Find table name in index
@@ -274,6 +309,18 @@ func (driver *TofinoDriver) GetSingletonDataNameById(tblName string, dataId uint
 	return dataName
 }
 
+// Returns the schema of a data field of a P4 table. Nil, if not found.
+func (driver *TofinoDriver) getSingletonDataFieldById(tblName string, dataId uint32) *SingletonField {
+	if sliceIdx, ok := driver.indexP4Tables[tblName]; ok {
+		for dataIdx := range driver.P4Tables[sliceIdx].Data {
+			if driver.P4Tables[sliceIdx].Data[dataIdx].Singleton.Id == dataId {
+				return &driver.P4Tables[sliceIdx].Data[dataIdx].Singleton
+			}
+		}
+	}
+	return nil
+}
+
 func (driver *TofinoDriver) GetSingletonDataIdLikeName(tblName, shortDataName string) (uint32, string) {
 	dataId := uint32(0)
 	dataName := ""
@@ -290,6 +337,11 @@ func (driver *TofinoDriver) GetSingletonDataIdLikeName(tblName, shortDataName st
 	return dataId, dataName
 }
 
+// Returns the short names of all selector tables. The default selector table comes first.
+func (driver *TofinoDriver) GetSelectorTables() []string {
+	return driver.selectorTableCache
+}
+
 func (driver *TofinoDriver) GetExtraProbes() []string {
 	// Return from cache if exists
 	return driver.extraProbeNameCache
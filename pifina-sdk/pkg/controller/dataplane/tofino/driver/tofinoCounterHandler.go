@@ -7,6 +7,9 @@ package driver
 
 import (
 	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
 	"github.com/thushjandan/pifina/pkg/model"
@@ -20,6 +23,150 @@ func (driver *TofinoDriver) GetEgressStartCounter(sessionIds []uint32) ([]*bfrun
 	return metrics, err
 }
 
+// Retrieve ingress and egress drop counter by a list of sessionIds, if the drop probes are installed.
+func (driver *TofinoDriver) GetDropCounter(sessionIds []uint32) ([]*bfruntime.Entity, error) {
+	driver.logger.Trace("Requesting drop counter", "sessionIds", sessionIds)
+	if !driver.HasDropProbes() {
+		return nil, nil
+	}
+	metrics, err := driver.GetMetricFromCounterRequest(sessionIds, PROBE_INGRESS_DROP_CNT)
+	if err != nil {
+		return nil, err
+	}
+	egressMetrics, err := driver.GetMetricFromCounterRequest(sessionIds, PROBE_EGRESS_DROP_CNT)
+	if err != nil {
+		return nil, err
+	}
+	metrics = append(metrics, egressMetrics...)
+	if !driver.HasDropReasonProbes() {
+		return metrics, nil
+	}
+	for _, shortTblName := range []string{PROBE_INGRESS_DROP_REASON_CNT, PROBE_EGRESS_DROP_REASON_CNT} {
+		indexes, err := driver.getDropReasonIndexes(sessionIds, shortTblName)
+		if err != nil {
+			return nil, err
+		}
+		reasonMetrics, err := driver.GetMetricFromCounterRequest(indexes, shortTblName)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, reasonMetrics...)
+	}
+
+	return metrics, nil
+}
+
+// Checks if the drop probes count the dropped packets per reason.
+func (driver *TofinoDriver) HasDropReasonProbes() bool {
+	_, ok := driver.probeTableMap[PROBE_INGRESS_DROP_REASON_CNT]
+	return ok
+}
+
+// Converts a list of sessionIds to the counter indexes of all drop reasons.
+// The reason counters are indexed by dropReason ++ sessionId. The amount of reasons is derived from the size of the counter.
+func (driver *TofinoDriver) getDropReasonIndexes(sessionIds []uint32, shortTblName string) ([]uint32, error) {
+	sessionIdWidth, err := driver.GetSessionIdBitWidth()
+	if err != nil {
+		return nil, err
+	}
+	tblName := driver.FindTableNameByShortName(shortTblName)
+	reasonCount := driver.GetTableSizeByName(tblName) >> sessionIdWidth
+	if reasonCount == 0 {
+		return nil, &model.ErrNameNotFound{Msg: "Cannot find size of the counter", Entity: tblName}
+	}
+	indexes := make([]uint32, 0, len(sessionIds)*int(reasonCount))
+	for reason := uint32(0); reason < reasonCount; reason++ {
+		for _, sessionId := range sessionIds {
+			indexes = append(indexes, reason<<sessionIdWidth|sessionId)
+		}
+	}
+
+	return indexes, nil
+}
+
+// Splits the index of a drop reason counter into the sessionId and a label with the reason like reason=3.
+func splitDropReasonIndex(index uint32, sessionIdWidth uint32) (uint32, string) {
+	return index & ((1 << sessionIdWidth) - 1), fmt.Sprintf("%s=%d", DROP_REASON_LABEL, index>>sessionIdWidth)
+}
+
+// Returns the bit width of a metric, which is a cumulative counter in the dataplane.
+// The second return value is false for metrics, which are no counters like the jitter or the peak queue depth.
+// Used to compute the increments between two samples including wraparounds, if the counters are never reset.
+func (driver *TofinoDriver) GetCounterWidth(metric *model.MetricItem) (uint, bool) {
+	switch metric.MetricName {
+	case PROBE_INGRESS_MATCH_CNT, PROBE_EGRESS_START_CNT:
+		if metric.Type == model.METRIC_PKTS {
+			return COUNTER_PKTS_WIDTH, true
+		}
+		return COUNTER_BYTES_WIDTH, true
+	case PROBE_INGRESS_DROP_CNT, PROBE_EGRESS_DROP_CNT, PROBE_INGRESS_DROP_REASON_CNT, PROBE_EGRESS_DROP_REASON_CNT:
+		return COUNTER_PKTS_ONLY_WIDTH, true
+	case PROBE_INGRESS_START_HDR_SIZE, PROBE_INGRESS_END_HDR_SIZE, PROBE_EGRESS_END_CNT, PROBE_EGRESS_BURST_CNT:
+		return REGISTER_COUNTER_WIDTH, true
+	}
+	if strings.HasPrefix(metric.MetricName, PROBE_EGRESS_PKT_SIZE_HIST+"_") {
+		return REGISTER_COUNTER_WIDTH, true
+	}
+	for _, extraProbe := range driver.GetExtraProbes() {
+		if metric.MetricName == extraProbe {
+			return REGISTER_COUNTER_WIDTH, true
+		}
+	}
+
+	return 0, false
+}
+
+// Checks if the drop probes have been compiled into the P4 application.
+func (driver *TofinoDriver) HasDropProbes() bool {
+	_, ok := driver.probeTableMap[PROBE_INGRESS_DROP_CNT]
+	return ok
+}
+
+// Computes the packets dropped in the traffic manager for each session.
+// Packets, which have been matched in ingress and not dropped by the ingress deparser, but never arrived in egress, are counted as TM drops.
+// The value is an approximation as packets can be in flight during collection.
+func (driver *TofinoDriver) GetTMDropMetrics(metrics []*model.MetricItem) []*model.MetricItem {
+	if !driver.HasDropProbes() {
+		return nil
+	}
+	ingressPkts := make(map[uint32]uint64)
+	ingressDrops := make(map[uint32]uint64)
+	egressPkts := make(map[uint32]uint64)
+	lastUpdated := make(map[uint32]time.Time)
+	for _, metric := range metrics {
+		if metric.Type != model.METRIC_PKTS {
+			continue
+		}
+		switch metric.MetricName {
+		case PROBE_INGRESS_MATCH_CNT:
+			ingressPkts[metric.SessionId] += metric.Value
+			lastUpdated[metric.SessionId] = metric.LastUpdated
+		case PROBE_INGRESS_DROP_CNT:
+			ingressDrops[metric.SessionId] += metric.Value
+		case PROBE_EGRESS_START_CNT:
+			egressPkts[metric.SessionId] += metric.Value
+		}
+	}
+
+	tmDropMetrics := make([]*model.MetricItem, 0, len(ingressPkts))
+	for sessionId, pkts := range ingressPkts {
+		forwardedPkts := egressPkts[sessionId] + ingressDrops[sessionId]
+		tmDrops := uint64(0)
+		if pkts > forwardedPkts {
+			tmDrops = pkts - forwardedPkts
+		}
+		tmDropMetrics = append(tmDropMetrics, &model.MetricItem{
+			SessionId:   sessionId,
+			Value:       tmDrops,
+			Type:        model.METRIC_PKTS,
+			MetricName:  PROBE_TM_DROP_CNT,
+			LastUpdated: lastUpdated[sessionId],
+		})
+	}
+
+	return tmDropMetrics
+}
+
 // Generate GRPC request payload for retrieving counter values
 func (driver *TofinoDriver) GetMetricFromCounterRequest(sessionIds []uint32, shortTblName string) ([]*bfruntime.Entity, error) {
 	if len(sessionIds) == 0 {
@@ -97,6 +244,15 @@ func (driver *TofinoDriver) ProcessCounterResponse(entity *bfruntime.Entity) ([]
 	if shortTblName != "" {
 		tblName = shortTblName
 	}
+	// Reason counters are indexed by dropReason ++ sessionId
+	label := ""
+	if shortTblName == PROBE_INGRESS_DROP_REASON_CNT || shortTblName == PROBE_EGRESS_DROP_REASON_CNT {
+		sessionIdWidth, err := driver.GetSessionIdBitWidth()
+		if err != nil {
+			return nil, err
+		}
+		sessionId, label = splitDropReasonIndex(sessionId, sessionIdWidth)
+	}
 
 	for data_i := range dataEntries {
 		// If the key indicates a byte counter
@@ -106,6 +262,7 @@ func (driver *TofinoDriver) ProcessCounterResponse(entity *bfruntime.Entity) ([]
 				Value:      binary.BigEndian.Uint64(dataEntries[data_i].GetStream()),
 				Type:       model.METRIC_BYTES,
 				MetricName: tblName,
+				Label:      label,
 			})
 		}
 		// If the key indicates a packet counter
@@ -115,6 +272,7 @@ func (driver *TofinoDriver) ProcessCounterResponse(entity *bfruntime.Entity) ([]
 				Value:      binary.BigEndian.Uint64(dataEntries[data_i].GetStream()),
 				Type:       model.METRIC_PKTS,
 				MetricName: tblName,
+				Label:      label,
 			})
 		}
 	}
@@ -124,20 +282,45 @@ func (driver *TofinoDriver) ProcessCounterResponse(entity *bfruntime.Entity) ([]
 
 // Generate reset indirect counters requests on device given a list of sessionIds
 func (driver *TofinoDriver) GetResetCounterRequests(sessionIds []uint32) []*bfruntime.Update {
-	shortTblName := PROBE_EGRESS_START_CNT
 	registerValueByteSize := 8
+	// Counter table name => data fields to reset
+	counterTables := map[string][]string{
+		PROBE_EGRESS_START_CNT: {COUNTER_SPEC_BYTES, COUNTER_SPEC_PKTS},
+	}
+	if driver.HasDropProbes() {
+		counterTables[PROBE_INGRESS_DROP_CNT] = []string{COUNTER_SPEC_PKTS}
+		counterTables[PROBE_EGRESS_DROP_CNT] = []string{COUNTER_SPEC_PKTS}
+	}
+	// Counter table name => indexes to reset
+	counterIndexes := make(map[string][]uint32)
+	for shortTblName := range counterTables {
+		counterIndexes[shortTblName] = sessionIds
+	}
+	if driver.HasDropReasonProbes() {
+		for _, shortTblName := range []string{PROBE_INGRESS_DROP_REASON_CNT, PROBE_EGRESS_DROP_REASON_CNT} {
+			indexes, err := driver.getDropReasonIndexes(sessionIds, shortTblName)
+			if err != nil {
+				driver.logger.Error("cannot build bfrt reset request", "tblName", shortTblName, "err", err)
+				continue
+			}
+			counterTables[shortTblName] = []string{COUNTER_SPEC_PKTS}
+			counterIndexes[shortTblName] = indexes
+		}
+	}
 	allResetReq := make([]*bfruntime.Update, 0)
 	// Build reset request
-	for _, id := range sessionIds {
-		resetReq, err := driver.getIndirectCounterResetRequest(shortTblName, COUNTER_INDEX_KEY_NAME, id, []string{COUNTER_SPEC_BYTES, COUNTER_SPEC_PKTS}, registerValueByteSize)
-		if err != nil {
-			driver.logger.Error("cannot build bfrt reset request", "tblName", shortTblName, "err", err)
-			continue
-		} else {
-			allResetReq = append(allResetReq, &bfruntime.Update{
-				Type:   bfruntime.Update_MODIFY,
-				Entity: resetReq,
-			})
+	for shortTblName, dataNames := range counterTables {
+		for _, id := range counterIndexes[shortTblName] {
+			resetReq, err := driver.getIndirectCounterResetRequest(shortTblName, COUNTER_INDEX_KEY_NAME, id, dataNames, registerValueByteSize)
+			if err != nil {
+				driver.logger.Error("cannot build bfrt reset request", "tblName", shortTblName, "err", err)
+				continue
+			} else {
+				allResetReq = append(allResetReq, &bfruntime.Update{
+					Type:   bfruntime.Update_MODIFY,
+					Entity: resetReq,
+				})
+			}
 		}
 	}
 	return allResetReq
@@ -0,0 +1,41 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func TestSplitDropReasonIndex(t *testing.T) {
+	tests := []struct {
+		index     uint32
+		sessionId uint32
+		label     string
+	}{
+		{index: 5, sessionId: 5, label: "reason=0"},
+		{index: 3<<10 | 5, sessionId: 5, label: "reason=3"},
+		{index: 15<<10 | 1023, sessionId: 1023, label: "reason=15"},
+	}
+
+	for _, test := range tests {
+		sessionId, label := splitDropReasonIndex(test.index, 10)
+		if sessionId != test.sessionId || label != test.label {
+			t.Errorf("index %#x: expected sessionId %d with %s, got sessionId %d with %s", test.index, test.sessionId, test.label, sessionId, label)
+		}
+	}
+}
+
+func TestGetDropCounterWidth(t *testing.T) {
+	driver := &TofinoDriver{}
+	for _, metricName := range []string{PROBE_INGRESS_DROP_CNT, PROBE_EGRESS_DROP_CNT, PROBE_INGRESS_DROP_REASON_CNT, PROBE_EGRESS_DROP_REASON_CNT} {
+		width, isCounter := driver.GetCounterWidth(&model.MetricItem{MetricName: metricName, Type: model.METRIC_PKTS})
+		if !isCounter || width != COUNTER_PKTS_ONLY_WIDTH {
+			t.Errorf("%s: expected counter with %d bits, got %d (counter: %t)", metricName, COUNTER_PKTS_ONLY_WIDTH, width, isCounter)
+		}
+	}
+}
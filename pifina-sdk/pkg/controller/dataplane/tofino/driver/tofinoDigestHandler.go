@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Checks if the flow discovery digest has been compiled into the P4 application.
+func (driver *TofinoDriver) HasFlowDigest() bool {
+	return driver.getFlowDigest() != nil
+}
+
+// Receives the digests on the stream channel and acknowledges them, so the switch sends the next digest lists.
+// The reported flows of the flow discovery digest are passed to the handler.
+// Blocks until the stream channel has been closed. Digests are only sent to the client bound to the P4 program.
+func (driver *TofinoDriver) ListenForFlowDigests(handler func([]*model.DiscoveredFlow)) {
+	flowDigest := driver.getFlowDigest()
+	if flowDigest == nil {
+		return
+	}
+
+	for {
+		resp, err := driver.streamChannel.Recv()
+		if err != nil {
+			// Stream channel is closed after disconnecting
+			if err != io.EOF && driver.ctx.Err() == nil {
+				driver.logger.Error("Cannot receive digests from stream channel", "err", err)
+			}
+			return
+		}
+		digestList := resp.GetDigest()
+		if digestList == nil {
+			continue
+		}
+		if digestList.GetDigestId() == flowDigest.Id {
+			if flows := driver.decodeFlowDigest(flowDigest, digestList); len(flows) > 0 {
+				handler(flows)
+			}
+		}
+		ack := &bfruntime.StreamMessageRequest_DigestAck{
+			DigestAck: &bfruntime.DigestListAck{
+				DigestId: digestList.GetDigestId(),
+				ListId:   digestList.GetListId(),
+			},
+		}
+		err = driver.streamChannel.Send(&bfruntime.StreamMessageRequest{ClientId: driver.clientId, Update: ack})
+		if err != nil {
+			driver.logger.Warn("Cannot acknowledge digest list", "digestId", digestList.GetDigestId(), "listId", digestList.GetListId(), "err", err)
+		}
+	}
+}
+
+// Clears the filter of already reported flows, so active flows are reported again by the flow discovery digest.
+func (driver *TofinoDriver) ResetFlowDiscoveryFilter() error {
+	tblName, ok := driver.probeTableMap[PROBE_DISCOVERY_FILTER]
+	if !ok {
+		return &model.ErrNameNotFound{Msg: "Flow discovery digest is not installed", Entity: PROBE_DISCOVERY_FILTER}
+	}
+	tblId := driver.GetTableIdByName(tblName)
+	if tblId == 0 {
+		return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+	}
+
+	// A delete request without a key clears the whole register
+	return driver.SendWriteRequest([]*bfruntime.Update{
+		{
+			Type: bfruntime.Update_DELETE,
+			Entity: &bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						TableId: tblId,
+					},
+				},
+			},
+		},
+	})
+}
+
+// Returns the flow discovery digest of the P4 application or nil, if it does not exist
+func (driver *TofinoDriver) getFlowDigest() *LearnFilter {
+	for i := range driver.LearnFilters {
+		nameSplit := strings.Split(driver.LearnFilters[i].Name, ".")
+		if nameSplit[len(nameSplit)-1] == PROBE_FLOW_DIGEST {
+			return &driver.LearnFilters[i]
+		}
+	}
+
+	return nil
+}
+
+// Transforms a digest list into flows. The keys are ordered like the fields of the digest.
+func (driver *TofinoDriver) decodeFlowDigest(flowDigest *LearnFilter, digestList *bfruntime.DigestList) []*model.DiscoveredFlow {
+	timeNow := time.Now()
+	flows := make([]*model.DiscoveredFlow, 0, len(digestList.GetData()))
+	for _, data := range digestList.GetData() {
+		values := make(map[uint32][]byte)
+		for _, dataField := range data.GetFields() {
+			values[dataField.GetFieldId()] = dataField.GetStream()
+		}
+		keys := make([]*model.DiscoveredFlowKey, 0, len(flowDigest.Fields))
+		labels := make([]string, 0, len(flowDigest.Fields))
+		for _, field := range flowDigest.Fields {
+			value, ok := values[field.Id]
+			if !ok {
+				continue
+			}
+			key := &model.DiscoveredFlowKey{
+				Name:  field.Name,
+				Value: fmt.Sprintf("0x%s", new(big.Int).SetBytes(value).Text(16)),
+			}
+			keys = append(keys, key)
+			labels = append(labels, fmt.Sprintf("%s=%s", key.Name, key.Value))
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		flows = append(flows, &model.DiscoveredFlow{
+			Id:        strings.Join(labels, ","),
+			Keys:      keys,
+			FirstSeen: timeNow,
+			LastSeen:  timeNow,
+		})
+	}
+
+	return flows
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+)
+
+func newTestDigestField(fieldId uint32, value []byte) *bfruntime.DataField {
+	return &bfruntime.DataField{FieldId: fieldId, Value: &bfruntime.DataField_Stream{Stream: value}}
+}
+
+func TestDecodeFlowDigest(t *testing.T) {
+	driver := newTestDriver(t)
+	flowDigest := driver.getFlowDigest()
+	if flowDigest == nil {
+		t.Fatalf("expected flow digest %s in fixture", PROBE_FLOW_DIGEST)
+	}
+
+	digestList := &bfruntime.DigestList{
+		DigestId: flowDigest.Id,
+		Data: []*bfruntime.TableData{
+			// Fields are ordered like the digest, regardless of the order in the message
+			{Fields: []*bfruntime.DataField{
+				newTestDigestField(2, []byte{10, 0, 0, 2}),
+				newTestDigestField(1, []byte{10, 0, 0, 1}),
+			}},
+			// Missing field is skipped
+			{Fields: []*bfruntime.DataField{
+				newTestDigestField(1, []byte{192, 168, 0, 1}),
+			}},
+			// Unknown field only
+			{Fields: []*bfruntime.DataField{
+				newTestDigestField(9, []byte{1}),
+			}},
+		},
+	}
+
+	flows := driver.decodeFlowDigest(flowDigest, digestList)
+	expected := []struct {
+		id   string
+		keys int
+	}{
+		{id: "ipv4_src_addr=0xa000001,ipv4_dst_addr=0xa000002", keys: 2},
+		{id: "ipv4_src_addr=0xc0a80001", keys: 1},
+	}
+	if len(flows) != len(expected) {
+		t.Fatalf("expected %d flows, got %d", len(expected), len(flows))
+	}
+	for i := range expected {
+		if flows[i].Id != expected[i].id {
+			t.Errorf("flow %d: expected id %s, got %s", i, expected[i].id, flows[i].Id)
+		}
+		if len(flows[i].Keys) != expected[i].keys {
+			t.Errorf("flow %d: expected %d keys, got %d", i, expected[i].keys, len(flows[i].Keys))
+		}
+		if flows[i].FirstSeen.IsZero() || !flows[i].FirstSeen.Equal(flows[i].LastSeen) {
+			t.Errorf("flow %d: expected first seen to equal last seen, got %s and %s", i, flows[i].FirstSeen, flows[i].LastSeen)
+		}
+	}
+	if key := flows[0].Keys[1]; key.Name != "ipv4_dst_addr" || key.Value != "0xa000002" {
+		t.Errorf("expected second key ipv4_dst_addr=0xa000002, got %s=%s", key.Name, key.Value)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"context"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
@@ -16,25 +17,32 @@ import (
 )
 
 type TofinoDriver struct {
-	logger               hclog.Logger
-	p4Name               string
-	isConnected          bool
-	conn                 *grpc.ClientConn
-	client               bfruntime.BfRuntimeClient
-	lock                 sync.Mutex
-	streamChannel        bfruntime.BfRuntime_StreamChannelClient
-	ctx                  context.Context
-	cancel               context.CancelFunc
-	clientId             uint32
-	P4Tables             []Table
-	NonP4Tables          []Table
-	indexP4Tables        map[string]int
-	indexByIdP4Tables    map[uint32]int
-	indexNonP4Tables     map[string]int
-	indexByIdNonP4Tables map[uint32]int
-	portCache            map[string][]byte
-	probeTableMap        map[string]string
-	extraProbeNameCache  []string
+	logger                hclog.Logger
+	p4Name                string
+	isConnected           bool
+	conn                  *grpc.ClientConn
+	client                bfruntime.BfRuntimeClient
+	lock                  sync.RWMutex
+	readOnly              bool        // Rejects all write requests to the dataplane
+	follower              atomic.Bool // Another client is bound to the P4 program. Rejects all write requests until the lease has been acquired.
+	bound                 atomic.Bool // Client is bound to the P4 program. Only the bound client receives digests.
+	streamChannel         bfruntime.BfRuntime_StreamChannelClient
+	ctx                   context.Context
+	cancel                context.CancelFunc
+	clientId              uint32
+	P4Tables              []Table
+	NonP4Tables           []Table
+	LearnFilters          []LearnFilter
+	indexP4Tables         map[string]int
+	indexByIdP4Tables     map[uint32]int
+	indexNonP4Tables      map[string]int
+	indexByIdNonP4Tables  map[uint32]int
+	portCache             map[string][]byte
+	probeTableMap         map[string]string
+	extraProbeNameCache   []string
+	selectorTableCache    []string
+	pktSizeHist           *packetSizeHistogram
+	heavyHitterTableCache []string
 }
 
 const (
@@ -49,8 +57,15 @@ const (
 	COUNTER_INDEX_KEY_NAME                    = "$COUNTER_INDEX"
 	REGISTER_INDEX_KEY_NAME                   = "$REGISTER_INDEX"
 	LPF_INDEX_KEY_NAME                        = "$LPF_INDEX"
+	MATCH_PRIORITY_KEY_NAME                   = "$MATCH_PRIORITY"
 	COUNTER_SPEC_BYTES                        = "$COUNTER_SPEC_BYTES"
 	COUNTER_SPEC_PKTS                         = "$COUNTER_SPEC_PKTS"
+	METER_INDEX_KEY_NAME                      = "$METER_INDEX"
+	METER_SPEC_PREFIX                         = "$METER_SPEC_"
+	COUNTER_PKTS_WIDTH                        = 28 // Width of the packet counter of a counter with packets and bytes
+	COUNTER_BYTES_WIDTH                       = 36 // Width of the byte counter of a counter with packets and bytes
+	COUNTER_PKTS_ONLY_WIDTH                   = 64
+	REGISTER_COUNTER_WIDTH                    = 32
 	LPF_SPEC_TYPE                             = "$LPF_SPEC_TYPE"
 	LPF_GAIN_TIME                             = "$LPF_SPEC_GAIN_TIME_CONSTANT_NS"
 	LPF_DECAY_TIME                            = "$LPF_SPEC_DECAY_TIME_CONSTANT_NS"
@@ -58,10 +73,18 @@ const (
 	TABLE_TYPE_REGISTER                       = "Register"
 	TABLE_TYPE_COUNTER                        = "Counter"
 	TABLE_TYPE_MATCHACTION                    = "MatchAction_Direct"
+	TABLE_TYPE_METER                          = "Meter"
 	TABLE_TYPE_TM_CNT_IG                      = "TmCounterIgPort"
 	TABLE_TYPE_TM_CNT_EG                      = "TmCounterEgPort"
 	TABLE_NAME_PORT_INFO                      = "$PORT"
 	PORT_NAME_INDEX_NAME                      = "$PORT_NAME"
+	PORT_KEY_NAME                             = "$DEV_PORT"
+	PORT_UP_DATA_NAME                         = "$PORT_UP"
+	PORT_SPEED_DATA_NAME                      = "$SPEED"
+	PORT_FEC_DATA_NAME                        = "$FEC"
+	TABLE_NAME_PORT_STAT                      = "$PORT_STAT"
+	TABLE_TYPE_PORT_CFG                       = "PortConfigure"
+	TABLE_TYPE_PORT_STAT                      = "PortStat"
 	TABLE_NAME_TM_CNT_IG                      = "tf2.tm.counter.ig_port"
 	TABLE_NAME_TM_CNT_EG                      = "tf2.tm.counter.eg_port"
 	TABLE_NAME_TM_CNT_PIPE                    = "tf2.tm.counter.pipe"
@@ -69,15 +92,46 @@ const (
 	PROBE_EXTRA_PREFIX                        = "PF_EXTRA"
 	PROBE_INGRESS_JITTER_LPF                  = "PF_INGRESS_JITTER_LPF"
 	PROBE_INGRESS_JITTER_REGISTER             = "PF_INGRESS_JITTER_AVG"
+	PROBE_EGRESS_PKT_SIZE_HIST                = "PF_EGRESS_PKT_SIZE_HIST"
+	PROBE_EGRESS_PKT_SIZE_BUCKET              = "PF_EGRESS_PKT_SIZE_BUCKET"
+	PROBE_PKT_SIZE_BUCKET_ACTION_NAME         = "pf_set_pkt_size_bucket"
+	PROBE_PKT_SIZE_BUCKET_ACTION_NAME_ID      = "bucketId"
+	PROBE_INGRESS_DROP_CNT                    = "PF_INGRESS_DROP_CNT"
+	PROBE_EGRESS_DROP_CNT                     = "PF_EGRESS_DROP_CNT"
+	PROBE_INGRESS_DROP_REASON_CNT             = "PF_INGRESS_DROP_REASON_CNT"
+	PROBE_EGRESS_DROP_REASON_CNT              = "PF_EGRESS_DROP_REASON_CNT"
+	DROP_REASON_LABEL                         = "reason"
+	PROBE_TM_DROP_CNT                         = "PF_TM_DROP_CNT" // Derived from the ingress and egress counters
+	PROBE_HH_PREFIX                           = "PF_HH_"
+	PROBE_HH_SKETCH_PREFIX                    = "PF_HH_SKETCH_"
+	PROBE_HH_SLOT_CNT                         = "PF_HH_SLOT_CNT"
+	PROBE_HH_KEY_PREFIX                       = "PF_HH_KEY_"
+	PROBE_HH_FLOW                             = "PF_HH_FLOW"
+	PROBE_EGRESS_BURST_CNT                    = "PF_EGRESS_BURST_CNT"
+	PROBE_EGRESS_BURST_MAX_QDEPTH             = "PF_EGRESS_BURST_MAX_QDEPTH"
+	PROBE_EGRESS_BURST_THRESHOLD              = "PF_EGRESS_BURST_THRESHOLD"
+	PROBE_BURST_THRESHOLD_ACTION_NAME         = "pf_set_burst_threshold"
+	PROBE_BURST_THRESHOLD_ACTION_NAME_VALUE   = "threshold"
+	PROBE_PORT_PREFIX                         = "PF_PORT_"
+	PROBE_PORT_UP                             = "PF_PORT_UP"    // Oper state of the port. 1 => up
+	PROBE_PORT_SPEED                          = "PF_PORT_SPEED" // Configured speed in Gbit/s
+	PROBE_PORT_FEC                            = "PF_PORT_FEC"   // FEC mode as enum, see model.PORT_FEC_*
+	PROBE_PORT_STAT_PREFIX                    = "PF_PORT_STAT_"
+	PROBE_TABLE_PREFIX                        = "PF_TABLE_"
+	PROBE_TABLE_USAGE_PREFIX                  = "PF_TABLE_USAGE_"     // Installed entries of a table
+	PROBE_TABLE_OCCUPANCY_PREFIX              = "PF_TABLE_OCCUPANCY_" // Installed entries in percent of the table size
+	PROBE_FLOW_DIGEST                         = "PF_FLOW_DIGEST"
+	PROBE_DISCOVERY_FILTER                    = "PF_DISCOVERY_FILTER"
 )
 
-var PROBE_TABLES = []string{PROBE_INGRESS_MATCH_CNT, PROBE_INGRESS_START_HDR_SIZE, PROBE_INGRESS_END_HDR_SIZE, PROBE_EGRESS_START_CNT, PROBE_EGRESS_END_CNT, PROBE_INGRESS_JITTER_LPF, PROBE_INGRESS_JITTER_REGISTER}
+var PROBE_TABLES = []string{PROBE_INGRESS_MATCH_CNT, PROBE_INGRESS_START_HDR_SIZE, PROBE_INGRESS_END_HDR_SIZE, PROBE_EGRESS_START_CNT, PROBE_EGRESS_END_CNT, PROBE_INGRESS_JITTER_LPF, PROBE_INGRESS_JITTER_REGISTER, PROBE_EGRESS_PKT_SIZE_HIST, PROBE_EGRESS_PKT_SIZE_BUCKET, PROBE_INGRESS_DROP_CNT, PROBE_EGRESS_DROP_CNT, PROBE_INGRESS_DROP_REASON_CNT, PROBE_EGRESS_DROP_REASON_CNT, PROBE_EGRESS_BURST_CNT, PROBE_EGRESS_BURST_MAX_QDEPTH, PROBE_EGRESS_BURST_THRESHOLD, PROBE_DISCOVERY_FILTER}
 
 // Creates new Tofino driver object
-func NewTofinoDriver(logger hclog.Logger, p4Name string) *TofinoDriver {
+func NewTofinoDriver(logger hclog.Logger, p4Name string, readOnly bool) *TofinoDriver {
 	return &TofinoDriver{
 		logger:        logger.Named("tofinoDriver"),
 		p4Name:        p4Name,
+		readOnly:      readOnly,
 		isConnected:   false,
 		clientId:      uint32(rand.Intn(100) + 1),
 		probeTableMap: make(map[string]string),
@@ -0,0 +1,27 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Creates a driver with the tables and digests of the BfrtInfo fixture in testdata
+func newTestDriver(t *testing.T) *TofinoDriver {
+	bfrtInfo, err := os.ReadFile("testdata/bfrt.json")
+	if err != nil {
+		t.Fatalf("cannot read BfrtInfo fixture: %v", err)
+	}
+	driver := NewTofinoDriver(hclog.NewNullLogger(), "test", false)
+	if err := driver.LoadBfrtInfo(bfrtInfo, []byte("{}")); err != nil {
+		t.Fatalf("cannot load BfrtInfo fixture: %v", err)
+	}
+
+	return driver
+}
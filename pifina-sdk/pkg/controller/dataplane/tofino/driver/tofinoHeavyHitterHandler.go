@@ -0,0 +1,242 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Top flow slot of the heavy hitter probe. Each pipe has its own sketch and slots, hence the values are kept per pipe.
+type heavyHitterSlot struct {
+	estimates []uint64   // pipe => estimated packet count
+	keys      [][]uint64 // position in the flow key => pipe => key value
+}
+
+// Top flow of a session with the estimates of all pipes, which have seen the flow
+type heavyHitterFlow struct {
+	label     string
+	estimates []uint64
+}
+
+// Checks if the heavy hitter probe has been compiled into the P4 application.
+func (driver *TofinoDriver) HasHeavyHitterProbe() bool {
+	_, ok := driver.probeTableMap[PROBE_HH_SLOT_CNT]
+	return ok
+}
+
+// Reads the top flow slots of the heavy hitter probe for a list of sessionIds.
+// Returns a metric per flow with its flow key as label ordered by the estimated packet count.
+// Needs to be called before the sketch is reset. The slots are read from the software shadow, if the probes have been synced.
+func (driver *TofinoDriver) GetHeavyHitterMetrics(sessionIds []uint32, synced bool) ([]*model.MetricItem, error) {
+	if len(sessionIds) == 0 || !driver.HasHeavyHitterProbe() {
+		return nil, nil
+	}
+
+	sessionIdWidth, err := driver.GetSessionIdBitWidth()
+	if err != nil {
+		return nil, err
+	}
+	slotTblName := driver.FindTableNameByShortName(PROBE_HH_SLOT_CNT)
+	slotTblSize := driver.GetTableSizeByName(slotTblName)
+	if slotTblSize>>sessionIdWidth == 0 {
+		return nil, &model.ErrNameNotFound{Msg: "Cannot find size of the register", Entity: slotTblName}
+	}
+	// Register is indexed by sessionId ++ slot
+	slotWidth := uint32(bits.Len32(slotTblSize)) - 1 - sessionIdWidth
+	slotCount := uint32(1) << slotWidth
+
+	// Slot count register first, followed by the flow key registers
+	keyRegisters := driver.getHeavyHitterKeyRegisters()
+	registerNames := append([]string{PROBE_HH_SLOT_CNT}, keyRegisters...)
+	registersToReq := make([]*model.AppRegister, 0, len(sessionIds)*int(slotCount)*len(registerNames))
+	for _, shortTblName := range registerNames {
+		tblName := driver.FindTableNameByShortName(shortTblName)
+		for _, sessionId := range sessionIds {
+			for slot := uint32(0); slot < slotCount; slot++ {
+				registersToReq = append(registersToReq, &model.AppRegister{
+					Name:  tblName,
+					Index: sessionId<<slotWidth | slot,
+				})
+			}
+		}
+	}
+	requests, err := driver.GetMetricFromRegisterRequest(registersToReq, model.METRIC_PKTS)
+	if err != nil {
+		return nil, err
+	}
+	if synced {
+		driver.ReadFromSyncedTables(requests)
+	}
+	entities, err := driver.SendReadRequest(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	// Position of a key register in the flow key
+	keyPositions := make(map[string]int)
+	for i, shortTblName := range keyRegisters {
+		keyPositions[shortTblName] = i
+	}
+	// register index => slot
+	slots := make(map[uint32]*heavyHitterSlot)
+	for i := range entities {
+		tblName := driver.GetTableNameById(entities[i].GetTableEntry().GetTableId())
+		shortTblName := driver.FindShortTableNameByName(tblName)
+		keyFields := entities[i].GetTableEntry().GetKey().GetFields()
+		dataFields := entities[i].GetTableEntry().GetData().GetFields()
+		if len(keyFields) == 0 || len(dataFields) == 0 {
+			continue
+		}
+		width := uint32(REGISTER_COUNTER_WIDTH)
+		if field := driver.getSingletonDataFieldById(tblName, dataFields[0].GetFieldId()); field != nil {
+			width = field.Type.Width
+		}
+		index := binary.BigEndian.Uint32(keyFields[0].GetExact().GetValue())
+		pipeValues := decodeRegisterValues(dataFields[0].GetStream(), width, false)
+		slot, ok := slots[index]
+		if !ok {
+			slot = &heavyHitterSlot{keys: make([][]uint64, len(keyRegisters))}
+			slots[index] = slot
+		}
+		if shortTblName == PROBE_HH_SLOT_CNT {
+			slot.estimates = pipeValues
+		} else if position, ok := keyPositions[shortTblName]; ok {
+			slot.keys[position] = pipeValues
+		}
+	}
+
+	timeNow := time.Now()
+	metrics := make([]*model.MetricItem, 0)
+	for _, sessionId := range sessionIds {
+		sessionSlots := make([]*heavyHitterSlot, 0, slotCount)
+		for slot := uint32(0); slot < slotCount; slot++ {
+			if s, ok := slots[sessionId<<slotWidth|slot]; ok {
+				sessionSlots = append(sessionSlots, s)
+			}
+		}
+		for _, flow := range driver.mergeHeavyHitterFlows(keyRegisters, sessionSlots) {
+			metrics = append(metrics, &model.MetricItem{
+				SessionId:   sessionId,
+				Value:       combinePipeValues(flow.estimates, model.AGGREGATION_SUM, false),
+				Type:        model.METRIC_PKTS,
+				MetricName:  PROBE_HH_FLOW,
+				Label:       flow.label,
+				LastUpdated: timeNow,
+			})
+		}
+	}
+
+	return metrics, nil
+}
+
+// Merges the top flows of all pipes of a session. A flow seen by several pipes is reported once with the estimates of all pipes.
+// Returns the flows ordered by their summed estimate.
+func (driver *TofinoDriver) mergeHeavyHitterFlows(keyRegisters []string, slots []*heavyHitterSlot) []*heavyHitterFlow {
+	flowsByLabel := make(map[string]*heavyHitterFlow)
+	flows := make([]*heavyHitterFlow, 0)
+	for _, slot := range slots {
+		for pipe, estimate := range slot.estimates {
+			// Empty slot
+			if estimate == 0 {
+				continue
+			}
+			keys := make([]uint64, len(keyRegisters))
+			for position := range keys {
+				if pipe < len(slot.keys[position]) {
+					keys[position] = slot.keys[position][pipe]
+				}
+			}
+			label := driver.getHeavyHitterLabel(keyRegisters, keys)
+			flow, ok := flowsByLabel[label]
+			if !ok {
+				flow = &heavyHitterFlow{label: label}
+				flowsByLabel[label] = flow
+				flows = append(flows, flow)
+			}
+			flow.estimates = append(flow.estimates, estimate)
+		}
+	}
+	sort.SliceStable(flows, func(i, j int) bool {
+		return combinePipeValues(flows[i].estimates, model.AGGREGATION_SUM, false) > combinePipeValues(flows[j].estimates, model.AGGREGATION_SUM, false)
+	})
+
+	return flows
+}
+
+// Generate requests to clear the sketch and the top flow slots of the heavy hitter probe.
+// The flow key registers do not need to be cleared as empty slots are detected by the slot count.
+func (driver *TofinoDriver) GetResetHeavyHitterRequests() []*bfruntime.Update {
+	allResetReq := make([]*bfruntime.Update, 0)
+	for _, shortTblName := range driver.heavyHitterTableCache {
+		if !strings.HasPrefix(shortTblName, PROBE_HH_SKETCH_PREFIX) && shortTblName != PROBE_HH_SLOT_CNT {
+			continue
+		}
+		tblId := driver.GetTableIdByName(driver.FindTableNameByShortName(shortTblName))
+		if tblId == 0 {
+			driver.logger.Error("cannot build bfrt reset request", "tblName", shortTblName)
+			continue
+		}
+		// A delete request without a key clears the whole register
+		allResetReq = append(allResetReq, &bfruntime.Update{
+			Type: bfruntime.Update_DELETE,
+			Entity: &bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						TableId: tblId,
+					},
+				},
+			},
+		})
+	}
+
+	return allResetReq
+}
+
+// Returns the short names of the flow key registers ordered by their position in the flow key.
+// e.g. PF_HH_KEY_0_ipv4_src_addr, PF_HH_KEY_1_ipv4_dst_addr
+func (driver *TofinoDriver) getHeavyHitterKeyRegisters() []string {
+	keyRegisters := make([]string, 0)
+	for _, shortTblName := range driver.heavyHitterTableCache {
+		if strings.HasPrefix(shortTblName, PROBE_HH_KEY_PREFIX) {
+			keyRegisters = append(keyRegisters, shortTblName)
+		}
+	}
+	sort.Slice(keyRegisters, func(i, j int) bool {
+		return heavyHitterKeyPosition(keyRegisters[i]) < heavyHitterKeyPosition(keyRegisters[j])
+	})
+
+	return keyRegisters
+}
+
+// Creates a label of a flow like ipv4_src_addr=0xa000001,ipv4_dst_addr=0xa000002
+func (driver *TofinoDriver) getHeavyHitterLabel(keyRegisters []string, keys []uint64) string {
+	labels := make([]string, 0, len(keys))
+	for i := range keyRegisters {
+		// Remove prefix and position from the register name
+		keyName := strings.SplitN(strings.TrimPrefix(keyRegisters[i], PROBE_HH_KEY_PREFIX), "_", 2)
+		labels = append(labels, fmt.Sprintf("%s=0x%x", keyName[len(keyName)-1], keys[i]))
+	}
+
+	return strings.Join(labels, ",")
+}
+
+// Parses the position of a flow key register. e.g. PF_HH_KEY_1_ipv4_dst_addr => 1
+func heavyHitterKeyPosition(shortTblName string) int {
+	position, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(shortTblName, PROBE_HH_KEY_PREFIX), "_", 2)[0])
+	if err != nil {
+		return -1
+	}
+	return position
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func TestMergeHeavyHitterFlows(t *testing.T) {
+	driver := &TofinoDriver{}
+	keyRegisters := []string{"PF_HH_KEY_0_ipv4_src_addr", "PF_HH_KEY_1_ipv4_dst_addr"}
+	slots := []*heavyHitterSlot{
+		{
+			// Flow 10.0.0.1 => 10.0.0.2 in pipe 0 and 2, pipe 1 is empty
+			estimates: []uint64{40, 0, 30},
+			keys:      [][]uint64{{0xa000001, 0, 0xa000001}, {0xa000002, 0, 0xa000002}},
+		},
+		{
+			// Flow 10.0.0.3 => 10.0.0.4 in pipe 1 only
+			estimates: []uint64{0, 50, 0},
+			keys:      [][]uint64{{0, 0xa000003, 0}, {0, 0xa000004, 0}},
+		},
+	}
+
+	flows := driver.mergeHeavyHitterFlows(keyRegisters, slots)
+	expected := []struct {
+		label    string
+		estimate uint64
+	}{
+		{label: "ipv4_src_addr=0xa000001,ipv4_dst_addr=0xa000002", estimate: 70},
+		{label: "ipv4_src_addr=0xa000003,ipv4_dst_addr=0xa000004", estimate: 50},
+	}
+	if len(flows) != len(expected) {
+		t.Fatalf("expected %d flows, got %d", len(expected), len(flows))
+	}
+	for i := range expected {
+		if flows[i].label != expected[i].label {
+			t.Errorf("flow %d: expected label %s, got %s", i, expected[i].label, flows[i].label)
+		}
+		if estimate := combinePipeValues(flows[i].estimates, model.AGGREGATION_SUM, false); estimate != expected[i].estimate {
+			t.Errorf("flow %d: expected estimate %d, got %d", i, expected[i].estimate, estimate)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Bucket boundaries of the packet size histogram probe read from the dataplane.
+type packetSizeHistogram struct {
+	sessionIdWidth uint32
+	buckets        []*packetSizeBucket
+}
+
+type packetSizeBucket struct {
+	id         uint32
+	low        uint32
+	high       uint32
+	metricName string
+}
+
+// Checks if the packet size histogram probe has been compiled into the P4 application.
+func (driver *TofinoDriver) HasPacketSizeHistogram() bool {
+	_, ok := driver.probeTableMap[PROBE_EGRESS_PKT_SIZE_HIST]
+	return ok
+}
+
+// Reads the bucket boundaries of the packet size histogram from the bucket table.
+// The boundaries are defined as const entries by pifina-cli, hence they need to be loaded only once.
+func (driver *TofinoDriver) LoadPacketSizeBuckets() error {
+	if !driver.HasPacketSizeHistogram() {
+		driver.logger.Debug("Packet size histogram probe is not installed. Skipping loading buckets.")
+		return nil
+	}
+
+	tblName, ok := driver.probeTableMap[PROBE_EGRESS_PKT_SIZE_BUCKET]
+	if !ok {
+		return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_EGRESS_PKT_SIZE_BUCKET}
+	}
+
+	tblId := driver.GetTableIdByName(tblName)
+	if tblId == 0 {
+		return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+	}
+
+	actionName := driver.FindFullActionName(tblName, PROBE_PKT_SIZE_BUCKET_ACTION_NAME)
+	bucketDataId := driver.GetDataIdByName(tblName, actionName, PROBE_PKT_SIZE_BUCKET_ACTION_NAME_ID)
+	if bucketDataId == 0 {
+		return &model.ErrNameNotFound{Msg: "Cannot find action param name", Entity: PROBE_PKT_SIZE_BUCKET_ACTION_NAME_ID}
+	}
+
+	sessionIdWidth, err := driver.GetSessionIdBitWidth()
+	if err != nil {
+		return err
+	}
+
+	// Read all entries of the bucket table
+	entities, err := driver.SendReadRequest([]*bfruntime.Entity{
+		{
+			Entity: &bfruntime.Entity_TableEntry{
+				TableEntry: &bfruntime.TableEntry{
+					IsDefaultEntry: false,
+					TableId:        tblId,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	buckets := make([]*packetSizeBucket, 0, len(entities))
+	for i := range entities {
+		keyFields := entities[i].GetTableEntry().GetKey().GetFields()
+		if len(keyFields) == 0 || keyFields[0].GetRange() == nil {
+			continue
+		}
+		bucket := &packetSizeBucket{
+			low:  decodeUint32(keyFields[0].GetRange().GetLow()),
+			high: decodeUint32(keyFields[0].GetRange().GetHigh()),
+		}
+		for _, dataField := range entities[i].GetTableEntry().GetData().GetFields() {
+			if dataField.GetFieldId() == bucketDataId {
+				bucket.id = decodeUint32(dataField.GetStream())
+			}
+		}
+		bucket.metricName = fmt.Sprintf("%s_%d_%d", PROBE_EGRESS_PKT_SIZE_HIST, bucket.low, bucket.high)
+		buckets = append(buckets, bucket)
+	}
+
+	driver.pktSizeHist = &packetSizeHistogram{
+		sessionIdWidth: sessionIdWidth,
+		buckets:        buckets,
+	}
+	driver.logger.Info("Packet size histogram buckets have been loaded", "buckets", len(buckets))
+
+	return nil
+}
+
+// Retrieve the packet counter of each packet size bucket by a list of sessionIds.
+// A metric will be emitted per bucket.
+func (driver *TofinoDriver) GetPacketSizeHistogram(sessionIds []uint32) ([]*bfruntime.Entity, error) {
+	driver.logger.Trace("Requesting packet size histogram", "sessionIds", sessionIds)
+
+	if len(sessionIds) == 0 || driver.pktSizeHist == nil {
+		return nil, nil
+	}
+
+	tblName := driver.FindTableNameByShortName(PROBE_EGRESS_PKT_SIZE_HIST)
+	if tblName == "" {
+		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_EGRESS_PKT_SIZE_HIST}
+	}
+
+	return driver.GetMetricFromRegisterRequest(driver.transformSessionIdToHistogramRegister(sessionIds, tblName), model.METRIC_PKTS)
+}
+
+// Generate reset requests for all buckets of the packet size histogram given a list of sessionIds
+func (driver *TofinoDriver) GetResetPacketSizeHistogramRequests(sessionIds []uint32) []*bfruntime.Update {
+	allResetReq := make([]*bfruntime.Update, 0)
+	if driver.pktSizeHist == nil {
+		return allResetReq
+	}
+	tblName := driver.FindTableNameByShortName(PROBE_EGRESS_PKT_SIZE_HIST)
+	_, dataName := driver.GetSingletonDataIdLikeName(tblName, PROBE_EGRESS_PKT_SIZE_HIST)
+	dataWidth := driver.GetSingletonDataWidthByName(tblName, dataName) / 8
+	for _, register := range driver.transformSessionIdToHistogramRegister(sessionIds, tblName) {
+		resetReq, err := driver.getIndirectCounterResetRequest(PROBE_EGRESS_PKT_SIZE_HIST, REGISTER_INDEX_KEY_NAME, register.Index, []string{PROBE_EGRESS_PKT_SIZE_HIST}, int(dataWidth))
+		if err != nil {
+			driver.logger.Error("cannot build bfrt reset request", "tblName", PROBE_EGRESS_PKT_SIZE_HIST, "err", err)
+			continue
+		}
+		allResetReq = append(allResetReq, &bfruntime.Update{
+			Type:   bfruntime.Update_MODIFY,
+			Entity: resetReq,
+		})
+	}
+
+	return allResetReq
+}
+
+// Converts the register index of the histogram back to the sessionId and the bucket and creates a metric.
+func (driver *TofinoDriver) processPacketSizeHistogramResponse(index uint32, value uint64) (*model.MetricItem, error) {
+	if driver.pktSizeHist == nil {
+		return nil, &model.ErrNotReady{Msg: "Packet size histogram buckets have not been loaded"}
+	}
+	sessionId := index & ((1 << driver.pktSizeHist.sessionIdWidth) - 1)
+	bucketId := index >> driver.pktSizeHist.sessionIdWidth
+	for _, bucket := range driver.pktSizeHist.buckets {
+		if bucket.id == bucketId {
+			return &model.MetricItem{
+				SessionId:  sessionId,
+				Value:      value,
+				Type:       model.METRIC_PKTS,
+				MetricName: bucket.metricName,
+			}, nil
+		}
+	}
+
+	return nil, &model.ErrNameNotFound{Msg: "Cannot find packet size bucket", Entity: fmt.Sprintf("%d", bucketId)}
+}
+
+// Converts a list of sessionIds to the register indexes of all buckets.
+// The histogram register is indexed by bucketId ++ sessionId
+func (driver *TofinoDriver) transformSessionIdToHistogramRegister(sessionIds []uint32, tblName string) []*model.AppRegister {
+	registerToRequest := make([]*model.AppRegister, 0, len(sessionIds)*len(driver.pktSizeHist.buckets))
+	for _, bucket := range driver.pktSizeHist.buckets {
+		for i := range sessionIds {
+			registerToRequest = append(registerToRequest, &model.AppRegister{
+				Name:  tblName,
+				Index: bucket.id<<driver.pktSizeHist.sessionIdWidth | sessionIds[i],
+			})
+		}
+	}
+
+	return registerToRequest
+}
+
+// Dataplane returns only as many bytes as needed. Decode it as uint32.
+func decodeUint32(rawValue []byte) uint32 {
+	buffer := make([]byte, 4)
+	if len(rawValue) > len(buffer) {
+		rawValue = rawValue[len(rawValue)-len(buffer):]
+	}
+	copy(buffer[len(buffer)-len(rawValue):], rawValue)
+	return binary.BigEndian.Uint32(buffer)
+}
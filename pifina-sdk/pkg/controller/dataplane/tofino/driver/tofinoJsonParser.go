@@ -8,8 +8,9 @@ package driver
 import "encoding/json"
 
 type ForwardPipelineConfig struct {
-	SchemaVersion string  `json:"schema_version"`
-	Tables        []Table `json:"tables"`
+	SchemaVersion string        `json:"schema_version"`
+	Tables        []Table       `json:"tables"`
+	LearnFilters  []LearnFilter `json:"learn_filters"`
 }
 
 type Table struct {
@@ -27,6 +28,14 @@ type Table struct {
 	SupportedOperations   []string     `json:"supported_operations"`
 }
 
+// Digest of the P4 application
+type LearnFilter struct {
+	Name        string       `json:"name"`
+	Id          uint32       `json:"id"`
+	Annotations []Annotation `json:"annotations"`
+	Fields      []Field      `json:"fields"`
+}
+
 type Field struct {
 	Id          uint32         `json:"id"`
 	Name        string         `json:"name"`
@@ -77,3 +86,16 @@ func UnmarshalBfruntimeInfoJson(input []byte) ([]Table, error) {
 
 	return bfrtInfo.Tables, nil
 }
+
+// Parses the digests of the P4 application from the BfrtInfo
+func UnmarshalBfruntimeLearnFilters(input []byte) ([]LearnFilter, error) {
+	var bfrtInfo ForwardPipelineConfig
+
+	err := json.Unmarshal(input, &bfrtInfo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bfrtInfo.LearnFilters, nil
+}
@@ -12,21 +12,11 @@ import (
 	"github.com/thushjandan/pifina/pkg/model"
 )
 
-// Configure all LPF instances with the correct parameter (time constants)
-func (driver *TofinoDriver) ConfigureLPF(sessionIds []uint32) error {
-	tblName, ok := driver.probeTableMap[PROBE_INGRESS_JITTER_LPF]
-	if !ok {
-		return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_JITTER_LPF}
-	}
-
-	tblId := driver.GetTableIdByName(tblName)
-	if tblId == 0 {
-		return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
-	}
-
-	keyId := driver.GetKeyIdByName(tblName, LPF_INDEX_KEY_NAME)
-	if keyId == 0 {
-		return &model.ErrNameNotFound{Msg: "Cannot find key id for table name", Entity: tblName}
+// Configure the LPF instances of the given sessionIds with the given parameter (type and time constants)
+func (driver *TofinoDriver) ConfigureLPF(sessionIds []uint32, lpf *model.LPFConfig) error {
+	tblName, tblId, keyId, err := driver.getLPFTable()
+	if err != nil {
+		return err
 	}
 
 	// Get key Ids
@@ -34,28 +24,27 @@ func (driver *TofinoDriver) ConfigureLPF(sessionIds []uint32) error {
 	lpfGainKeyId := driver.GetSingletonDataIdByName(tblName, LPF_GAIN_TIME)
 	lpfDecayKeyId := driver.GetSingletonDataIdByName(tblName, LPF_DECAY_TIME)
 	lpfScaleDownKeyId := driver.GetSingletonDataIdByName(tblName, LPF_SCALE_DOWN_FACTOR)
-	lpfTimeConst := float32(80)
 
 	byteScaleDown := make([]byte, 4)
-	binary.BigEndian.PutUint32(byteScaleDown, 0)
+	binary.BigEndian.PutUint32(byteScaleDown, lpf.ScaleDown)
 	// Set the LPF parameter
 	dataFields := []*bfruntime.DataField{
 		{
 			FieldId: lpfSampleKeyId,
 			Value: &bfruntime.DataField_StrVal{
-				StrVal: "SAMPLE",
+				StrVal: lpf.Type,
 			},
 		},
 		{
 			FieldId: lpfGainKeyId,
 			Value: &bfruntime.DataField_FloatVal{
-				FloatVal: lpfTimeConst,
+				FloatVal: lpf.GainTimeConst,
 			},
 		},
 		{
 			FieldId: lpfDecayKeyId,
 			Value: &bfruntime.DataField_FloatVal{
-				FloatVal: lpfTimeConst,
+				FloatVal: lpf.DecayTimeConst,
 			},
 		},
 		{
@@ -69,21 +58,6 @@ func (driver *TofinoDriver) ConfigureLPF(sessionIds []uint32) error {
 	updateReq := []*bfruntime.Update{}
 
 	for _, sessionId := range sessionIds {
-		// Convert to byte slice
-		byteEntryId := make([]byte, 4)
-		binary.BigEndian.PutUint32(byteEntryId, sessionId)
-
-		keyFields := []*bfruntime.KeyField{
-			{
-				FieldId: keyId,
-				MatchType: &bfruntime.KeyField_Exact_{
-					Exact: &bfruntime.KeyField_Exact{
-						Value: byteEntryId,
-					},
-				},
-			},
-		}
-
 		updateReq = append(updateReq, &bfruntime.Update{
 			Type: bfruntime.Update_MODIFY,
 			Entity: &bfruntime.Entity{
@@ -92,7 +66,7 @@ func (driver *TofinoDriver) ConfigureLPF(sessionIds []uint32) error {
 						TableId: tblId,
 						Value: &bfruntime.TableEntry_Key{
 							Key: &bfruntime.TableKey{
-								Fields: keyFields,
+								Fields: getLPFKeyFields(keyId, sessionId),
 							},
 						},
 						Data: &bfruntime.TableData{
@@ -104,11 +78,107 @@ func (driver *TofinoDriver) ConfigureLPF(sessionIds []uint32) error {
 		})
 	}
 
-	err := driver.SendWriteRequest(updateReq)
+	err = driver.SendWriteRequest(updateReq)
 	if err != nil {
 		return err
 	}
-	driver.logger.Info("LPF has been configured", "sessionIds", sessionIds)
+	driver.logger.Info("LPF has been configured", "sessionIds", sessionIds, "type", lpf.Type, "gain", lpf.GainTimeConst, "decay", lpf.DecayTimeConst, "scaleDown", lpf.ScaleDown)
 
 	return nil
 }
+
+// Reads the parameter of the LPF instances of the given sessionIds from the dataplane.
+func (driver *TofinoDriver) GetLPFConfigs(sessionIds []uint32) (map[uint32]*model.LPFConfig, error) {
+	lpfConfigs := make(map[uint32]*model.LPFConfig, len(sessionIds))
+	if len(sessionIds) == 0 {
+		return lpfConfigs, nil
+	}
+
+	tblName, tblId, keyId, err := driver.getLPFTable()
+	if err != nil {
+		return nil, err
+	}
+
+	readReq := make([]*bfruntime.Entity, 0, len(sessionIds))
+	for _, sessionId := range sessionIds {
+		readReq = append(readReq, &bfruntime.Entity{
+			Entity: &bfruntime.Entity_TableEntry{
+				TableEntry: &bfruntime.TableEntry{
+					TableId: tblId,
+					Value: &bfruntime.TableEntry_Key{
+						Key: &bfruntime.TableKey{
+							Fields: getLPFKeyFields(keyId, sessionId),
+						},
+					},
+				},
+			},
+		})
+	}
+
+	entities, err := driver.SendReadRequest(readReq)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entities {
+		keyFields := entities[i].GetTableEntry().GetKey().GetFields()
+		if len(keyFields) == 0 {
+			continue
+		}
+		sessionId := decodeUint32(keyFields[0].GetExact().GetValue())
+		lpf := &model.LPFConfig{}
+		for _, dataField := range entities[i].GetTableEntry().GetData().GetFields() {
+			switch driver.GetSingletonDataNameById(tblName, dataField.GetFieldId()) {
+			case LPF_SPEC_TYPE:
+				lpf.Type = dataField.GetStrVal()
+			case LPF_GAIN_TIME:
+				lpf.GainTimeConst = dataField.GetFloatVal()
+			case LPF_DECAY_TIME:
+				lpf.DecayTimeConst = dataField.GetFloatVal()
+			case LPF_SCALE_DOWN_FACTOR:
+				lpf.ScaleDown = decodeUint32(dataField.GetStream())
+			}
+		}
+		lpfConfigs[sessionId] = lpf
+	}
+
+	return lpfConfigs, nil
+}
+
+// Returns the full name, the table id and the id of the index key of the LPF table.
+func (driver *TofinoDriver) getLPFTable() (string, uint32, uint32, error) {
+	tblName, ok := driver.probeTableMap[PROBE_INGRESS_JITTER_LPF]
+	if !ok {
+		return "", 0, 0, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_JITTER_LPF}
+	}
+
+	tblId := driver.GetTableIdByName(tblName)
+	if tblId == 0 {
+		return "", 0, 0, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+	}
+
+	keyId := driver.GetKeyIdByName(tblName, LPF_INDEX_KEY_NAME)
+	if keyId == 0 {
+		return "", 0, 0, &model.ErrNameNotFound{Msg: "Cannot find key id for table name", Entity: tblName}
+	}
+
+	return tblName, tblId, keyId, nil
+}
+
+// LPF instances are indexed by the sessionId
+func getLPFKeyFields(keyId uint32, sessionId uint32) []*bfruntime.KeyField {
+	// Convert to byte slice
+	byteEntryId := make([]byte, 4)
+	binary.BigEndian.PutUint32(byteEntryId, sessionId)
+
+	return []*bfruntime.KeyField{
+		{
+			FieldId: keyId,
+			MatchType: &bfruntime.KeyField_Exact_{
+				Exact: &bfruntime.KeyField_Exact{
+					Value: byteEntryId,
+				},
+			},
+		},
+	}
+}
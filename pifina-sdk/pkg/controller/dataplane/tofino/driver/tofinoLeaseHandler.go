@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"context"
+	"time"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Tries to acquire the single-writer lease by binding the client to the P4 program.
+// BF Runtime allows only a single client to be bound to a P4 program. The binding is released by the switch,
+// as soon as the stream channel of the bound client disappears.
+// If another controller or a bfrt script is bound, the driver stays a read-only follower.
+func (driver *TofinoDriver) AcquireLease() error {
+	if !driver.isConnected {
+		return &model.ErrNotReady{Msg: "Not connected to Tofino"}
+	}
+	if driver.readOnly {
+		return &model.ErrReadOnly{Msg: "Controller is in read-only mode. Lease cannot be acquired"}
+	}
+
+	err := driver.BindProgram()
+	if err != nil {
+		driver.follower.Store(true)
+		return err
+	}
+	if driver.follower.Swap(false) {
+		driver.logger.Info("Lease has been acquired. Took over as leader", "p4name", driver.p4Name, "clientId", driver.clientId)
+	}
+
+	return nil
+}
+
+// Binds the client to the P4 program without changing the leader state.
+// Used without a lease, as digests are only sent to the bound client.
+func (driver *TofinoDriver) BindProgram() error {
+	if !driver.isConnected {
+		return &model.ErrNotReady{Msg: "Not connected to Tofino"}
+	}
+	if driver.readOnly {
+		return &model.ErrReadOnly{Msg: "Controller is in read-only mode. Client cannot be bound to the P4 program"}
+	}
+
+	bindReq := &bfruntime.SetForwardingPipelineConfigRequest{
+		DeviceId: 0,
+		ClientId: driver.clientId,
+		Action:   bfruntime.SetForwardingPipelineConfigRequest_BIND,
+		Config: []*bfruntime.ForwardingPipelineConfig{
+			{
+				P4Name: driver.p4Name,
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(driver.ctx, 5*time.Second)
+	defer cancel()
+	_, err := driver.client.SetForwardingPipelineConfig(ctx, bindReq)
+	if err != nil {
+		return err
+	}
+	driver.bound.Store(true)
+
+	return nil
+}
+
+// Checks if the client is bound to the P4 program
+func (driver *TofinoDriver) IsBound() bool {
+	return driver.bound.Load()
+}
+
+// Marks the driver as follower. All write requests are rejected until the lease has been acquired.
+func (driver *TofinoDriver) SetFollower() {
+	driver.follower.Store(true)
+}
+
+// Checks if the driver holds the lease and is allowed to write to the dataplane
+func (driver *TofinoDriver) IsLeader() bool {
+	return !driver.readOnly && !driver.follower.Load()
+}
+
+// Checks if another client holds the lease. A follower tries to take over the lease, unlike in read-only mode.
+func (driver *TofinoDriver) IsFollower() bool {
+	return !driver.readOnly && driver.follower.Load()
+}
+
+// Checks if the controller is only allowed to read from the dataplane
+func (driver *TofinoDriver) IsReadOnly() bool {
+	return !driver.IsLeader()
+}
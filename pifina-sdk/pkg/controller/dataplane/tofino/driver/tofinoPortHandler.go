@@ -0,0 +1,238 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Creates the read requests for the link state ($PORT) and the RMON counters ($PORT_STAT) of the given ports.
+func (driver *TofinoDriver) GetPortRequests(ports []string) []*bfruntime.Entity {
+	tblEntries := []*bfruntime.Entity{}
+	tblId_cfg := driver.GetTableIdByName(TABLE_NAME_PORT_INFO)
+	tblId_stat := driver.GetTableIdByName(TABLE_NAME_PORT_STAT)
+	keyId_cfg := driver.GetKeyIdByName(TABLE_NAME_PORT_INFO, PORT_KEY_NAME)
+	keyId_stat := driver.GetKeyIdByName(TABLE_NAME_PORT_STAT, PORT_KEY_NAME)
+	// Only the link state is read from $PORT. All data fields are returned for $PORT_STAT.
+	statusFields := []*bfruntime.DataField{}
+	for _, dataName := range []string{PORT_UP_DATA_NAME, PORT_SPEED_DATA_NAME, PORT_FEC_DATA_NAME} {
+		statusFields = append(statusFields, &bfruntime.DataField{FieldId: driver.GetSingletonDataIdByName(TABLE_NAME_PORT_INFO, dataName)})
+	}
+
+	for i := range ports {
+		portId, err := driver.GetPortIdByName(ports[i])
+		if err != nil {
+			continue
+		}
+		// Link state
+		tblEntries = append(tblEntries,
+			&bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						TableId: tblId_cfg,
+						Value: &bfruntime.TableEntry_Key{
+							Key: &bfruntime.TableKey{
+								Fields: []*bfruntime.KeyField{
+									{
+										FieldId: keyId_cfg,
+										MatchType: &bfruntime.KeyField_Exact_{
+											Exact: &bfruntime.KeyField_Exact{
+												Value: portId,
+											},
+										},
+									},
+								},
+							},
+						},
+						Data: &bfruntime.TableData{
+							Fields: statusFields,
+						},
+					},
+				},
+			},
+		)
+		// RMON counters
+		tblEntries = append(tblEntries,
+			&bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						TableId: tblId_stat,
+						TableFlags: &bfruntime.TableFlags{
+							FromHw: true,
+						},
+						Value: &bfruntime.TableEntry_Key{
+							Key: &bfruntime.TableKey{
+								Fields: []*bfruntime.KeyField{
+									{
+										FieldId: keyId_stat,
+										MatchType: &bfruntime.KeyField_Exact_{
+											Exact: &bfruntime.KeyField_Exact{
+												Value: portId,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		)
+	}
+
+	return tblEntries
+}
+
+// Transforms a response of $PORT or $PORT_STAT to metrics.
+// The link state is exported as PF_PORT_UP, PF_PORT_SPEED and PF_PORT_FEC,
+// each RMON counter as PF_PORT_STAT_<counter name>. The session id is the dev port.
+func (driver *TofinoDriver) ProcessPortResponse(entity *bfruntime.Entity) ([]*model.MetricItem, error) {
+	transformedMetrics := make([]*model.MetricItem, 0)
+	tblEntry := entity.GetTableEntry()
+	keyFields := tblEntry.GetKey().GetFields()
+	if len(keyFields) == 0 {
+		return nil, &model.ErrNameNotFound{Msg: "Port response without dev port", Entity: driver.GetTableNameById(tblEntry.GetTableId())}
+	}
+	portId := decodeUint32(keyFields[0].GetExact().GetValue())
+
+	if driver.GetTableTypeById(tblEntry.GetTableId()) == TABLE_TYPE_PORT_CFG {
+		status := driver.decodePortStatus(tblEntry)
+		operUp := uint64(0)
+		if status.OperUp {
+			operUp = 1
+		}
+		transformedMetrics = append(transformedMetrics,
+			&model.MetricItem{SessionId: portId, Value: operUp, Type: model.METRIC_EXT_VALUE, MetricName: PROBE_PORT_UP},
+			&model.MetricItem{SessionId: portId, Value: status.SpeedGbps, Type: model.METRIC_EXT_VALUE, MetricName: PROBE_PORT_SPEED},
+			&model.MetricItem{SessionId: portId, Value: encodePortFec(status.Fec), Type: model.METRIC_EXT_VALUE, MetricName: PROBE_PORT_FEC},
+		)
+		return transformedMetrics, nil
+	}
+
+	for name, value := range driver.decodePortStats(tblEntry) {
+		transformedMetrics = append(transformedMetrics, &model.MetricItem{
+			SessionId:  portId,
+			Value:      value,
+			Type:       model.METRIC_EXT_VALUE,
+			MetricName: PROBE_PORT_STAT_PREFIX + name,
+		})
+	}
+
+	return transformedMetrics, nil
+}
+
+// Reads the link state and the RMON counters of the given ports
+func (driver *TofinoDriver) GetPortDetails(ports []string) ([]*model.DevPort, error) {
+	devPorts := make([]*model.DevPort, 0, len(ports))
+	devPortsById := make(map[uint32]*model.DevPort, len(ports))
+	for i := range ports {
+		devPort := &model.DevPort{Name: ports[i]}
+		devPorts = append(devPorts, devPort)
+		if portId, err := driver.GetPortIdByName(ports[i]); err == nil {
+			devPort.PortId = decodeUint32(portId)
+			devPortsById[devPort.PortId] = devPort
+		}
+	}
+	if len(devPortsById) == 0 {
+		return devPorts, nil
+	}
+
+	entities, err := driver.SendReadRequest(driver.GetPortRequests(ports))
+	if err != nil {
+		return devPorts, err
+	}
+	for i := range entities {
+		tblEntry := entities[i].GetTableEntry()
+		keyFields := tblEntry.GetKey().GetFields()
+		if len(keyFields) == 0 {
+			continue
+		}
+		devPort, ok := devPortsById[decodeUint32(keyFields[0].GetExact().GetValue())]
+		if !ok {
+			continue
+		}
+		switch driver.GetTableTypeById(tblEntry.GetTableId()) {
+		case TABLE_TYPE_PORT_CFG:
+			devPort.Status = driver.decodePortStatus(tblEntry)
+		case TABLE_TYPE_PORT_STAT:
+			devPort.Stats = driver.decodePortStats(tblEntry)
+		}
+	}
+
+	return devPorts, nil
+}
+
+func (driver *TofinoDriver) decodePortStatus(tblEntry *bfruntime.TableEntry) *model.PortStatus {
+	status := &model.PortStatus{}
+	tblName := driver.GetTableNameById(tblEntry.GetTableId())
+	for _, dataField := range tblEntry.GetData().GetFields() {
+		switch driver.GetSingletonDataNameById(tblName, dataField.FieldId) {
+		case PORT_UP_DATA_NAME:
+			status.OperUp = dataField.GetBoolVal()
+		case PORT_SPEED_DATA_NAME:
+			status.Speed = dataField.GetStrVal()
+			status.SpeedGbps = parsePortSpeed(status.Speed)
+		case PORT_FEC_DATA_NAME:
+			status.Fec = dataField.GetStrVal()
+		}
+	}
+	return status
+}
+
+// Decodes all RMON counters of a $PORT_STAT entry. The leading $ of the counter name is removed.
+func (driver *TofinoDriver) decodePortStats(tblEntry *bfruntime.TableEntry) map[string]uint64 {
+	stats := make(map[string]uint64)
+	tblName := driver.GetTableNameById(tblEntry.GetTableId())
+	for _, dataField := range tblEntry.GetData().GetFields() {
+		dataName := driver.GetSingletonDataNameById(tblName, dataField.FieldId)
+		if dataName == "" {
+			continue
+		}
+		stats[strings.TrimPrefix(dataName, "$")] = decodeUint64(dataField.GetStream())
+	}
+	return stats
+}
+
+// Converts the speed enum of bf_pm to Gbit/s, e.g. BF_SPEED_100G => 100
+func parsePortSpeed(speed string) uint64 {
+	speed = strings.TrimPrefix(speed, "BF_SPEED_")
+	gbitIdx := strings.Index(speed, "G")
+	if gbitIdx <= 0 {
+		return 0
+	}
+	gbps, err := strconv.ParseUint(speed[:gbitIdx], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return gbps
+}
+
+func encodePortFec(fec string) uint64 {
+	switch fec {
+	case "BF_FEC_TYP_NONE", "BF_FEC_TYP_NO_FEC":
+		return model.PORT_FEC_NONE
+	case "BF_FEC_TYP_FIRECODE", "BF_FEC_TYP_FC":
+		return model.PORT_FEC_FIRECODE
+	case "BF_FEC_TYP_REED_SOLOMON", "BF_FEC_TYP_RS":
+		return model.PORT_FEC_REED_SOLOMON
+	}
+	return model.PORT_FEC_UNKNOWN
+}
+
+// Dataplane returns only as many bytes as needed. Decode it as uint64.
+func decodeUint64(rawValue []byte) uint64 {
+	buffer := make([]byte, 8)
+	if len(rawValue) > len(buffer) {
+		rawValue = rawValue[len(rawValue)-len(buffer):]
+	}
+	copy(buffer[len(buffer)-len(rawValue):], rawValue)
+	return binary.BigEndian.Uint64(buffer)
+}
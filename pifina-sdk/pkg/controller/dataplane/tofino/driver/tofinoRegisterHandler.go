@@ -7,7 +7,9 @@ package driver
 
 import (
 	"encoding/binary"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
 	"github.com/thushjandan/pifina/pkg/model"
@@ -127,11 +129,32 @@ func (driver *TofinoDriver) GetMetricFromRegisterRequest(appRegisters []*model.A
 }
 
 func (driver *TofinoDriver) GetResetRegisterRequest(sessionIds []uint32) []*bfruntime.Update {
-	allResetReq := make([]*bfruntime.Update, 0)
 	shortTblNames := []string{PROBE_INGRESS_START_HDR_SIZE, PROBE_INGRESS_END_HDR_SIZE, PROBE_EGRESS_END_CNT}
 	extraProbes := driver.GetExtraProbes()
 	shortTblNames = append(extraProbes, shortTblNames...)
-	// Build reset request
+	// Peak queue depth and burst counter are reset every interval
+	if driver.HasBurstProbe() {
+		shortTblNames = append(shortTblNames, PROBE_EGRESS_BURST_CNT, PROBE_EGRESS_BURST_MAX_QDEPTH)
+	}
+	allResetReq := driver.getResetRegisterRequests(shortTblNames, sessionIds)
+	// Reset all buckets of the packet size histogram
+	allResetReq = append(allResetReq, driver.GetResetPacketSizeHistogramRequests(sessionIds)...)
+
+	return allResetReq
+}
+
+// Generates reset requests of registers, which hold no counters but a value per interval like the peak queue depth.
+// Used by the delta sampling, which never resets counters.
+func (driver *TofinoDriver) GetResetGaugeRegisterRequests(sessionIds []uint32) []*bfruntime.Update {
+	if !driver.HasBurstProbe() {
+		return make([]*bfruntime.Update, 0)
+	}
+	return driver.getResetRegisterRequests([]string{PROBE_EGRESS_BURST_MAX_QDEPTH}, sessionIds)
+}
+
+// Build reset requests for the given registers indexed by sessionId
+func (driver *TofinoDriver) getResetRegisterRequests(shortTblNames []string, sessionIds []uint32) []*bfruntime.Update {
+	allResetReq := make([]*bfruntime.Update, 0)
 	for _, shortTblName := range shortTblNames {
 		tblName := driver.FindTableNameByShortName(shortTblName)
 		_, dataName := driver.GetSingletonDataIdLikeName(tblName, shortTblName)
@@ -150,6 +173,7 @@ func (driver *TofinoDriver) GetResetRegisterRequest(sessionIds []uint32) []*bfru
 			}
 		}
 	}
+
 	return allResetReq
 }
 
@@ -179,51 +203,308 @@ func (driver *TofinoDriver) transformSessionIdToAppRegister(sessionIds []uint32,
 	return registerToRequest
 }
 
-func (driver *TofinoDriver) ProcessRegisterResponse(entity *bfruntime.Entity) (*model.MetricItem, error) {
+// Transforms a register entry to metrics. Every data field is decoded according to its width and type in the schema.
+// Registers with multiple data fields like pairs result in a metric per field named <table name>.<field>.
+func (driver *TofinoDriver) ProcessRegisterResponse(entity *bfruntime.Entity) ([]*model.MetricItem, error) {
+	return driver.processRegisterResponse(entity, "")
+}
+
+// Transforms a register entry to metrics. The values of all pipes are combined according to the aggregation.
+// An empty aggregation is chosen by the probe type.
+func (driver *TofinoDriver) processRegisterResponse(entity *bfruntime.Entity, aggregation string) ([]*model.MetricItem, error) {
 	tblName := driver.GetTableNameById(entity.GetTableEntry().GetTableId())
+	keyFields := entity.GetTableEntry().GetKey().GetFields()
+	if len(keyFields) == 0 {
+		return nil, &model.ErrNameNotFound{Msg: "Register response without index", Entity: tblName}
+	}
 	// Get sessionId from key field.
-	sessionId := binary.BigEndian.Uint32(entity.GetTableEntry().GetKey().GetFields()[0].GetExact().GetValue())
-	dataEntries := entity.GetTableEntry().GetData().GetFields()
-	for data_i := range dataEntries {
-		// Dataplane could return just a single byte instead of 4 bytes.
-		// So we copy the response in a 4 byte slice.
-		rawValue := dataEntries[data_i].GetStream()
-		var decodedValue uint64
-		if len(rawValue) == 8 {
-			if strings.Contains(tblName, PROBE_INGRESS_JITTER_REGISTER) {
-				buffer := make([]byte, 4)
-				copy(buffer[:], rawValue[0:3])
-				decodedValue = uint64(binary.BigEndian.Uint32(buffer))
-			} else {
-				decodedValue = binary.BigEndian.Uint64(rawValue)
-			}
-		} else {
-			buffer := make([]byte, 4)
-			copy(buffer[len(buffer)-len(rawValue):], rawValue)
-			decodedValue = uint64(binary.BigEndian.Uint32(buffer))
-		}
+	sessionId := decodeUint32(keyFields[0].GetExact().GetValue())
+	// Replace full tblname with short name
+	metricName := tblName
+	shortTblName := driver.FindShortTableNameByName(tblName)
+	if shortTblName != "" {
+		metricName = shortTblName
+	}
 
-		// Replace full tblname with short name
-		shortTblName := driver.FindShortTableNameByName(tblName)
-		if shortTblName != "" {
-			tblName = shortTblName
+	if aggregation == "" {
+		aggregation = getPipeAggregation(metricName)
+	}
+	fieldValues := driver.decodeRegisterFields(tblName, entity.GetTableEntry().GetData().GetFields(), aggregation)
+	transformedMetrics := make([]*model.MetricItem, 0, len(fieldValues))
+	for _, fieldValue := range fieldValues {
+		// Register of the histogram is indexed by bucketId and sessionId
+		if metricName == PROBE_EGRESS_PKT_SIZE_HIST {
+			metric, err := driver.processPacketSizeHistogramResponse(sessionId, fieldValue.value)
+			if err != nil {
+				return nil, err
+			}
+			return []*model.MetricItem{metric}, nil
 		}
 
 		metricType := model.METRIC_EXT_VALUE
-		switch tblName {
+		switch metricName {
 		case PROBE_INGRESS_START_HDR_SIZE, PROBE_INGRESS_END_HDR_SIZE, PROBE_EGRESS_END_CNT:
 			metricType = model.METRIC_BYTES
+		case PROBE_EGRESS_BURST_CNT:
+			metricType = model.METRIC_PKTS
 		default:
-			metricType = model.METRIC_EXT_VALUE
+			if fieldValue.signed {
+				metricType = model.METRIC_EXT_SIGNED_VALUE
+			}
 		}
 
-		return &model.MetricItem{
+		fieldMetricName := metricName
+		if len(fieldValues) > 1 && shortTblName == "" {
+			fieldMetricName = fmt.Sprintf("%s.%s", metricName, fieldValue.name)
+		}
+
+		transformedMetrics = append(transformedMetrics, &model.MetricItem{
 			SessionId:  sessionId,
-			Value:      uint64(decodedValue),
+			Value:      fieldValue.value,
 			Type:       metricType,
-			MetricName: tblName,
-		}, nil
+			MetricName: fieldMetricName,
+		})
+		// The probes of PIFINA hold a single value. Only the first field is used.
+		if shortTblName != "" {
+			break
+		}
 	}
 
-	return nil, nil
+	return transformedMetrics, nil
+}
+
+// Decoded value of a single data field of a register
+type registerFieldValue struct {
+	name   string // Last part of the field name, e.g. lo or hi of a pair
+	value  uint64 // Two's complement, if signed. Combined value of all pipes.
+	signed bool
+}
+
+// Decodes all data fields of a register entry according to the schema.
+// The values of all pipes are combined according to the aggregation.
+func (driver *TofinoDriver) decodeRegisterFields(tblName string, dataEntries []*bfruntime.DataField, aggregation string) []*registerFieldValue {
+	fieldValues := make([]*registerFieldValue, 0, len(dataEntries))
+	for data_i := range dataEntries {
+		field := driver.getSingletonDataFieldById(tblName, dataEntries[data_i].GetFieldId())
+		if field == nil {
+			continue
+		}
+		signed := strings.HasPrefix(field.Type.Type, "int")
+		nameSplit := strings.Split(field.Name, ".")
+		pipeValues := decodeRegisterValues(dataEntries[data_i].GetStream(), field.Type.Width, signed)
+		fieldValues = append(fieldValues, &registerFieldValue{
+			name:   nameSplit[len(nameSplit)-1],
+			value:  combinePipeValues(pipeValues, aggregation, signed),
+			signed: signed,
+		})
+	}
+
+	return fieldValues
+}
+
+// Registers holding a peak value are combined by their maximum. The jitter is only measured in the pipe carrying the flow.
+// The values of all other registers like counters are summed up.
+func getPipeAggregation(shortTblName string) string {
+	switch shortTblName {
+	case PROBE_EGRESS_BURST_MAX_QDEPTH, PROBE_INGRESS_JITTER_REGISTER:
+		return model.AGGREGATION_MAX
+	}
+	return model.AGGREGATION_SUM
+}
+
+// Decodes a register value with the given bit width.
+// The dataplane returns only as many bytes as needed. If the register is read from all pipes,
+// the values of each pipe are concatenated. Returns a value per pipe.
+func decodeRegisterValues(rawValue []byte, width uint32, signed bool) []uint64 {
+	byteWidth := int(width+7) / 8
+	if byteWidth == 0 || byteWidth > 8 {
+		byteWidth = 8
+	}
+	if len(rawValue) <= byteWidth || len(rawValue)%byteWidth != 0 {
+		return []uint64{decodeRegisterChunk(rawValue, width, signed)}
+	}
+	pipeValues := make([]uint64, 0, len(rawValue)/byteWidth)
+	for i := 0; i < len(rawValue); i += byteWidth {
+		pipeValues = append(pipeValues, decodeRegisterChunk(rawValue[i:i+byteWidth], width, signed))
+	}
+	return pipeValues
+}
+
+// Combines the values of all pipes to a single value by the sum, the maximum or the minimum.
+func combinePipeValues(pipeValues []uint64, aggregation string, signed bool) uint64 {
+	if len(pipeValues) == 0 {
+		return 0
+	}
+	combined := pipeValues[0]
+	for _, value := range pipeValues[1:] {
+		switch aggregation {
+		case model.AGGREGATION_MAX:
+			if isLessRegisterValue(combined, value, signed) {
+				combined = value
+			}
+		case model.AGGREGATION_MIN:
+			if isLessRegisterValue(value, combined, signed) {
+				combined = value
+			}
+		default:
+			combined += value
+		}
+	}
+	return combined
+}
+
+// Decodes a single value. Signed values are sign-extended to 64 bits.
+func decodeRegisterChunk(rawValue []byte, width uint32, signed bool) uint64 {
+	value := decodeUint64(rawValue)
+	if width == 0 || width >= 64 {
+		return value
+	}
+	value &= (1 << width) - 1
+	if signed && value&(1<<(width-1)) != 0 {
+		value |= ^uint64(0) << width
+	}
+	return value
+}
+
+// Reads index ranges or whole arrays of registers. The values of a range are aggregated to a single metric,
+// if an aggregation is defined. The sessionId of an aggregated metric is the first index of the range.
+func (driver *TofinoDriver) GetAppRegisterRangeMetrics(appRegisters []*model.AppRegister) ([]*model.MetricItem, error) {
+	if len(appRegisters) == 0 {
+		return nil, nil
+	}
+	tblEntries := []*bfruntime.Entity{}
+	for i := range appRegisters {
+		if !appRegisters[i].All {
+			// Read each index of the range
+			rangeRequests, err := driver.GetMetricFromRegisterRequest(driver.transformIndexRangeToAppRegister(appRegisters[i]), model.METRIC_EXT_VALUE)
+			if err != nil {
+				return nil, err
+			}
+			tblEntries = append(tblEntries, rangeRequests...)
+			continue
+		}
+		tblId := driver.GetTableIdByName(appRegisters[i].Name)
+		if tblId == 0 {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: appRegisters[i].Name}
+		}
+		// Read all entries of the register array at once
+		tblEntries = append(tblEntries,
+			&bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						TableId: tblId,
+						TableFlags: &bfruntime.TableFlags{
+							FromHw: true,
+						},
+					},
+				},
+			},
+		)
+	}
+
+	entities, err := driver.SendReadRequest(tblEntries)
+	if err != nil {
+		return nil, err
+	}
+	// Group the decoded values by register
+	valuesByRegister := make([][]*model.MetricItem, len(appRegisters))
+	for i := range entities {
+		tblName := driver.GetTableNameById(entities[i].GetTableEntry().GetTableId())
+		keyFields := entities[i].GetTableEntry().GetKey().GetFields()
+		if len(keyFields) == 0 {
+			continue
+		}
+		index := decodeUint32(keyFields[0].GetExact().GetValue())
+		for reg_i, appRegister := range appRegisters {
+			if appRegister.Name != tblName {
+				continue
+			}
+			if !appRegister.All && (index < appRegister.Index || index > *appRegister.IndexEnd) {
+				continue
+			}
+			// The values of the pipes are combined like the values of the range
+			metrics, err := driver.processRegisterResponse(entities[i], appRegister.Aggregation)
+			if err != nil {
+				continue
+			}
+			valuesByRegister[reg_i] = append(valuesByRegister[reg_i], metrics...)
+		}
+	}
+
+	timeNow := time.Now()
+	transformedMetrics := make([]*model.MetricItem, 0)
+	for reg_i, appRegister := range appRegisters {
+		if appRegister.Aggregation == "" {
+			transformedMetrics = append(transformedMetrics, valuesByRegister[reg_i]...)
+			continue
+		}
+		transformedMetrics = append(transformedMetrics, aggregateRegisterValues(appRegister, valuesByRegister[reg_i])...)
+	}
+	for i := range transformedMetrics {
+		transformedMetrics[i].LastUpdated = timeNow
+	}
+
+	return transformedMetrics, nil
+}
+
+// Aggregates the values of each field of a register range to a single metric named <metric name>_<aggregation>
+func aggregateRegisterValues(appRegister *model.AppRegister, metrics []*model.MetricItem) []*model.MetricItem {
+	aggregatedMetrics := make([]*model.MetricItem, 0)
+	aggregatedByName := make(map[string]*model.MetricItem)
+	for _, metric := range metrics {
+		aggregated, ok := aggregatedByName[metric.MetricName]
+		if !ok {
+			aggregated = &model.MetricItem{
+				SessionId:  appRegister.Index,
+				Value:      metric.Value,
+				Type:       metric.Type,
+				MetricName: fmt.Sprintf("%s_%s", metric.MetricName, appRegister.Aggregation),
+			}
+			aggregatedByName[metric.MetricName] = aggregated
+			aggregatedMetrics = append(aggregatedMetrics, aggregated)
+			continue
+		}
+		signed := metric.Type == model.METRIC_EXT_SIGNED_VALUE
+		switch appRegister.Aggregation {
+		case model.AGGREGATION_SUM:
+			aggregated.Value += metric.Value
+		case model.AGGREGATION_MAX:
+			if isLessRegisterValue(aggregated.Value, metric.Value, signed) {
+				aggregated.Value = metric.Value
+			}
+		case model.AGGREGATION_MIN:
+			if isLessRegisterValue(metric.Value, aggregated.Value, signed) {
+				aggregated.Value = metric.Value
+			}
+		}
+	}
+
+	return aggregatedMetrics
+}
+
+func isLessRegisterValue(a uint64, b uint64, signed bool) bool {
+	if signed {
+		return int64(a) < int64(b)
+	}
+	return a < b
+}
+
+// Converts an index range to a list of AppRegister structs with a single index.
+func (driver *TofinoDriver) transformIndexRangeToAppRegister(appRegister *model.AppRegister) []*model.AppRegister {
+	registerToRequest := make([]*model.AppRegister, 0)
+	if appRegister.IndexEnd == nil {
+		return append(registerToRequest, appRegister)
+	}
+	for index := appRegister.Index; index <= *appRegister.IndexEnd; index++ {
+		registerToRequest = append(registerToRequest, &model.AppRegister{
+			Name:  appRegister.Name,
+			Index: index,
+		})
+		// Prevent an overflow
+		if index == ^uint32(0) {
+			break
+		}
+	}
+
+	return registerToRequest
 }
@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func TestDecodeRegisterChunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawValue []byte
+		width    uint32
+		signed   bool
+		expected uint64
+	}{
+		{name: "unsigned", rawValue: []byte{0xff, 0xfe}, width: 16, expected: 0xfffe},
+		{name: "signed negative", rawValue: []byte{0xff, 0xfe}, width: 16, signed: true, expected: uint64(0xfffffffffffffffe)},
+		{name: "signed positive", rawValue: []byte{0x7f, 0xfe}, width: 16, signed: true, expected: 0x7ffe},
+		{name: "signed odd width", rawValue: []byte{0x10}, width: 5, signed: true, expected: uint64(0xfffffffffffffff0)},
+		{name: "bits above width", rawValue: []byte{0xf0, 0x01}, width: 12, expected: 0x001},
+		{name: "shorter encoding", rawValue: []byte{0x05}, width: 32, signed: true, expected: 5},
+		{name: "64 bit", rawValue: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, width: 64, signed: true, expected: uint64(0xffffffffffffffff)},
+	}
+
+	for _, test := range tests {
+		if value := decodeRegisterChunk(test.rawValue, test.width, test.signed); value != test.expected {
+			t.Errorf("%s: expected %#x, got %#x", test.name, test.expected, value)
+		}
+	}
+}
+
+func TestDecodeRegisterValues(t *testing.T) {
+	pipeValues := decodeRegisterValues([]byte{0x00, 0x05, 0x00, 0x07, 0xff, 0xff, 0x00, 0x01}, 16, true)
+	expected := []uint64{5, 7, uint64(0xffffffffffffffff), 1}
+	if len(pipeValues) != len(expected) {
+		t.Fatalf("expected %d pipe values, got %d", len(expected), len(pipeValues))
+	}
+	for i := range expected {
+		if pipeValues[i] != expected[i] {
+			t.Errorf("pipe %d: expected %#x, got %#x", i, expected[i], pipeValues[i])
+		}
+	}
+
+	tests := []struct {
+		aggregation string
+		expected    uint64
+	}{
+		{aggregation: model.AGGREGATION_SUM, expected: 12},
+		{aggregation: model.AGGREGATION_MAX, expected: 7},
+		{aggregation: model.AGGREGATION_MIN, expected: uint64(0xffffffffffffffff)},
+	}
+	for _, test := range tests {
+		if value := combinePipeValues(pipeValues, test.aggregation, true); value != test.expected {
+			t.Errorf("%s: expected %#x, got %#x", test.aggregation, test.expected, value)
+		}
+	}
+	if value := combinePipeValues([]uint64{3, 9, 4}, model.AGGREGATION_MAX, false); value != 9 {
+		t.Errorf("max of queue depths: expected 9, got %d", value)
+	}
+}
+
+func TestAggregateRegisterValues(t *testing.T) {
+	indexEnd := uint32(12)
+	metrics := []*model.MetricItem{
+		{SessionId: 10, Value: 3, Type: model.METRIC_EXT_SIGNED_VALUE, MetricName: "reg.lo"},
+		{SessionId: 10, Value: 1, Type: model.METRIC_EXT_VALUE, MetricName: "reg.hi"},
+		{SessionId: 11, Value: uint64(0xfffffffffffffffe), Type: model.METRIC_EXT_SIGNED_VALUE, MetricName: "reg.lo"},
+		{SessionId: 11, Value: 5, Type: model.METRIC_EXT_VALUE, MetricName: "reg.hi"},
+		{SessionId: 12, Value: 7, Type: model.METRIC_EXT_SIGNED_VALUE, MetricName: "reg.lo"},
+		{SessionId: 12, Value: 2, Type: model.METRIC_EXT_VALUE, MetricName: "reg.hi"},
+	}
+
+	tests := []struct {
+		aggregation string
+		lo          uint64
+		hi          uint64
+	}{
+		{aggregation: model.AGGREGATION_SUM, lo: 8, hi: 8},
+		{aggregation: model.AGGREGATION_MAX, lo: 7, hi: 5},
+		{aggregation: model.AGGREGATION_MIN, lo: uint64(0xfffffffffffffffe), hi: 1},
+	}
+	for _, test := range tests {
+		appRegister := &model.AppRegister{Name: "reg", Index: 10, IndexEnd: &indexEnd, Aggregation: test.aggregation}
+		aggregated := aggregateRegisterValues(appRegister, metrics)
+		if len(aggregated) != 2 {
+			t.Fatalf("%s: expected a metric per field, got %d", test.aggregation, len(aggregated))
+		}
+		for _, metric := range aggregated {
+			if metric.SessionId != 10 {
+				t.Errorf("%s: expected first index as sessionId, got %d", test.aggregation, metric.SessionId)
+			}
+			switch metric.MetricName {
+			case "reg.lo_" + test.aggregation:
+				if metric.Value != test.lo {
+					t.Errorf("%s: expected lo %#x, got %#x", test.aggregation, test.lo, metric.Value)
+				}
+			case "reg.hi_" + test.aggregation:
+				if metric.Value != test.hi {
+					t.Errorf("%s: expected hi %#x, got %#x", test.aggregation, test.hi, metric.Value)
+				}
+			default:
+				t.Errorf("%s: unexpected metric %s", test.aggregation, metric.MetricName)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"time"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+)
+
+const (
+	TABLE_OPERATION_SYNC           = "Sync"          // Indirect counters and registers
+	TABLE_OPERATION_SYNC_COUNTERS  = "SyncCounters"  // Direct counters of match action tables
+	TABLE_OPERATION_SYNC_REGISTERS = "SyncRegisters" // Direct registers of match action tables
+)
+
+// Creates a sync operation for every probe table, which supports syncing its counters or registers from the hardware.
+func (driver *TofinoDriver) GetProbeSyncOperations() []*bfruntime.Update {
+	syncOperations := make([]*bfruntime.Update, 0)
+	for i := range driver.P4Tables {
+		// Only PIFINA probe tables are synced
+		if driver.FindShortTableNameByName(driver.P4Tables[i].Name) == "" {
+			continue
+		}
+		for _, operation := range driver.P4Tables[i].SupportedOperations {
+			if operation != TABLE_OPERATION_SYNC && operation != TABLE_OPERATION_SYNC_COUNTERS && operation != TABLE_OPERATION_SYNC_REGISTERS {
+				continue
+			}
+			syncOperations = append(syncOperations, &bfruntime.Update{
+				Type: bfruntime.Update_INSERT,
+				Entity: &bfruntime.Entity{
+					Entity: &bfruntime.Entity_TableOperation{
+						TableOperation: &bfruntime.TableOperation{
+							TableId:             driver.P4Tables[i].Id,
+							TableOperationsType: operation,
+						},
+					},
+				},
+			})
+		}
+	}
+
+	return syncOperations
+}
+
+// Syncs the counters and registers of all probe tables from the hardware into the software shadow of the switch.
+// Subsequent reads return the values of all probes at the same moment.
+// Returns the time, when the sync has been completed.
+func (driver *TofinoDriver) SyncProbeTables() (time.Time, error) {
+	syncOperations := driver.GetProbeSyncOperations()
+	if len(syncOperations) == 0 {
+		return time.Now(), nil
+	}
+	// The write request returns after the sync operations have been completed
+	err := driver.SendWriteRequest(syncOperations)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Now(), nil
+}
+
+// Reads the entries of the probe tables from the software shadow instead of the hardware.
+// Used after SyncProbeTables, so all probes return the values of the sync. Other tables are still read from the hardware.
+func (driver *TofinoDriver) ReadFromSyncedTables(entities []*bfruntime.Entity) {
+	syncedTables := make(map[uint32]struct{})
+	for _, operation := range driver.GetProbeSyncOperations() {
+		syncedTables[operation.GetEntity().GetTableOperation().GetTableId()] = struct{}{}
+	}
+	for _, entity := range entities {
+		tableEntry := entity.GetTableEntry()
+		if tableEntry == nil {
+			continue
+		}
+		if _, ok := syncedTables[tableEntry.GetTableId()]; !ok {
+			continue
+		}
+		if tableEntry.TableFlags == nil {
+			tableEntry.TableFlags = &bfruntime.TableFlags{}
+		}
+		tableEntry.TableFlags.FromHw = false
+	}
+}
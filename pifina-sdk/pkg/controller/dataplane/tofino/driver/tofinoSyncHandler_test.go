@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+)
+
+func TestGetProbeSyncOperations(t *testing.T) {
+	driver := newTestDriver(t)
+
+	// Only the probe tables are synced. Tables of the user application are skipped.
+	expected := map[string]string{
+		"pipe.SwitchIngress.PF_INGRESS_MATCH_CNT":                  TABLE_OPERATION_SYNC_COUNTERS,
+		"pipe.SwitchEgress.pfEgressStartProbe.PF_EGRESS_START_CNT": TABLE_OPERATION_SYNC,
+		"pipe.SwitchEgress.pfEgressEndProbe.PF_EGRESS_END_CNT":     TABLE_OPERATION_SYNC,
+	}
+	operations := driver.GetProbeSyncOperations()
+	if len(operations) != len(expected) {
+		t.Errorf("expected %d sync operations, got %d", len(expected), len(operations))
+	}
+	for _, operation := range operations {
+		tableOperation := operation.GetEntity().GetTableOperation()
+		tblName := driver.GetTableNameById(tableOperation.GetTableId())
+		expectedOperation, ok := expected[tblName]
+		if !ok {
+			t.Errorf("%s: unexpected sync operation", tblName)
+			continue
+		}
+		if tableOperation.GetTableOperationsType() != expectedOperation {
+			t.Errorf("%s: expected operation %s, got %s", tblName, expectedOperation, tableOperation.GetTableOperationsType())
+		}
+	}
+}
+
+func TestReadFromSyncedTables(t *testing.T) {
+	driver := newTestDriver(t)
+
+	tests := []struct {
+		tblName string
+		fromHw  bool
+	}{
+		{tblName: "pipe.SwitchEgress.pfEgressStartProbe.PF_EGRESS_START_CNT", fromHw: false},
+		{tblName: "pipe.SwitchEgress.pfEgressEndProbe.PF_EGRESS_END_CNT", fromHw: false},
+		{tblName: "pipe.SwitchIngress.app_counter", fromHw: true},
+	}
+	entities := make([]*bfruntime.Entity, 0, len(tests))
+	for _, test := range tests {
+		entities = append(entities, &bfruntime.Entity{
+			Entity: &bfruntime.Entity_TableEntry{
+				TableEntry: &bfruntime.TableEntry{
+					TableId:    driver.GetTableIdByName(test.tblName),
+					TableFlags: &bfruntime.TableFlags{FromHw: true},
+				},
+			},
+		})
+	}
+	driver.ReadFromSyncedTables(entities)
+
+	for i, test := range tests {
+		if fromHw := entities[i].GetTableEntry().GetTableFlags().GetFromHw(); fromHw != test.fromHw {
+			t.Errorf("%s: expected read from hardware %v, got %v", test.tblName, test.fromHw, fromHw)
+		}
+	}
+}
@@ -13,36 +13,59 @@ import (
 	"github.com/thushjandan/pifina/pkg/model"
 )
 
-// Retrieve all MatchSelectorEntries from device
-func (driver *TofinoDriver) GetMatchSelectorEntriesRequest() ([]*bfruntime.Entity, error) {
-	tblName, ok := driver.probeTableMap[PROBE_INGRESS_MATCH_CNT]
-	if !ok {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_MATCH_CNT}
+// Find full table name of a selector table given its short name.
+// An empty name refers to the default selector table.
+func (driver *TofinoDriver) GetSelectorTableName(shortName string) (string, error) {
+	if shortName == "" {
+		shortName = PROBE_INGRESS_MATCH_CNT
+	}
+	for i := range driver.selectorTableCache {
+		if driver.selectorTableCache[i] == shortName {
+			return driver.probeTableMap[shortName], nil
+		}
 	}
 
-	tblId := driver.GetTableIdByName(tblName)
-	if tblId == 0 {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
-	}
+	return "", &model.ErrNameNotFound{Msg: "Cannot find selector table", Entity: shortName}
+}
 
+// Create read requests for all selector tables
+func (driver *TofinoDriver) createSelectorTableReadRequests() ([]*bfruntime.Entity, error) {
 	tblEntries := []*bfruntime.Entity{}
 
-	tblEntries = append(tblEntries,
-		&bfruntime.Entity{
-			Entity: &bfruntime.Entity_TableEntry{
-				TableEntry: &bfruntime.TableEntry{
-					IsDefaultEntry: false,
-					TableId:        tblId,
-					TableFlags: &bfruntime.TableFlags{
-						FromHw: true,
+	for _, shortTblName := range driver.selectorTableCache {
+		tblName := driver.probeTableMap[shortTblName]
+		tblId := driver.GetTableIdByName(tblName)
+		if tblId == 0 {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+		}
+
+		tblEntries = append(tblEntries,
+			&bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						IsDefaultEntry: false,
+						TableId:        tblId,
+						TableFlags: &bfruntime.TableFlags{
+							FromHw: true,
+						},
 					},
 				},
 			},
-		},
-	)
+		)
+	}
+
+	if len(tblEntries) == 0 {
+		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_MATCH_CNT}
+	}
+
 	return tblEntries, nil
 }
 
+// Retrieve all MatchSelectorEntries from device
+func (driver *TofinoDriver) GetMatchSelectorEntriesRequest() ([]*bfruntime.Entity, error) {
+	return driver.createSelectorTableReadRequests()
+}
+
 func (driver *TofinoDriver) ProcessMatchActionResponse(entity *bfruntime.Entity) ([]*model.MetricItem, error) {
 	tblName := driver.GetTableNameById(entity.GetTableEntry().GetTableId())
 	dataEntries := entity.GetTableEntry().GetData().GetFields()
@@ -99,74 +122,39 @@ func (driver *TofinoDriver) ProcessMatchActionResponse(entity *bfruntime.Entity)
 }
 
 func (driver *TofinoDriver) GetResetTableSelectorRequests(selectorEntries []*model.MatchSelectorEntry) ([]*bfruntime.Update, error) {
-	tblName, ok := driver.probeTableMap[PROBE_INGRESS_MATCH_CNT]
-	if !ok {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_MATCH_CNT}
-	}
-
-	tblId := driver.GetTableIdByName(tblName)
-	if tblId == 0 {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
-	}
-
-	// Get key Ids
-	counterBytesKeyId := driver.GetSingletonDataIdByName(tblName, COUNTER_SPEC_BYTES)
-	counterPktsKeyId := driver.GetSingletonDataIdByName(tblName, COUNTER_SPEC_PKTS)
-
 	// Convert to byte slice
 	zeroValue := make([]byte, 8)
 
-	dataFields := []*bfruntime.DataField{
-		{
-			FieldId: counterBytesKeyId,
-			Value: &bfruntime.DataField_Stream{
-				Stream: zeroValue,
-			},
-		},
-		{
-			FieldId: counterPktsKeyId,
-			Value: &bfruntime.DataField_Stream{
-				Stream: zeroValue,
-			},
-		},
-	}
-
 	updateRequests := []*bfruntime.Update{}
 
 	for i := range selectorEntries {
-		keyFields := []*bfruntime.KeyField{}
-		for _, keyItem := range selectorEntries[i].Keys {
-			switch keyItem.MatchType {
-			case model.MATCH_TYPE_EXACT:
-				keyFields = append(keyFields, &bfruntime.KeyField{
-					FieldId: keyItem.FieldId,
-					MatchType: &bfruntime.KeyField_Exact_{
-						Exact: &bfruntime.KeyField_Exact{
-							Value: keyItem.Value,
-						},
-					},
-				})
-			case model.MATCH_TYPE_TERNARY:
-				keyFields = append(keyFields, &bfruntime.KeyField{
-					FieldId: keyItem.FieldId,
-					MatchType: &bfruntime.KeyField_Ternary_{
-						Ternary: &bfruntime.KeyField_Ternary{
-							Value: keyItem.Value,
-							Mask:  keyItem.ValueMask,
-						},
-					},
-				})
-			case model.MATCH_TYPE_LPM:
-				keyFields = append(keyFields, &bfruntime.KeyField{
-					FieldId: keyItem.FieldId,
-					MatchType: &bfruntime.KeyField_Lpm{
-						Lpm: &bfruntime.KeyField_LPM{
-							Value:     keyItem.Value,
-							PrefixLen: keyItem.PrefixLength,
-						},
-					},
-				})
-			}
+		tblName, err := driver.GetSelectorTableName(selectorEntries[i].Table)
+		if err != nil {
+			return nil, err
+		}
+
+		tblId := driver.GetTableIdByName(tblName)
+		if tblId == 0 {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+		}
+
+		// Get key Ids
+		counterBytesKeyId := driver.GetSingletonDataIdByName(tblName, COUNTER_SPEC_BYTES)
+		counterPktsKeyId := driver.GetSingletonDataIdByName(tblName, COUNTER_SPEC_PKTS)
+
+		dataFields := []*bfruntime.DataField{
+			{
+				FieldId: counterBytesKeyId,
+				Value: &bfruntime.DataField_Stream{
+					Stream: zeroValue,
+				},
+			},
+			{
+				FieldId: counterPktsKeyId,
+				Value: &bfruntime.DataField_Stream{
+					Stream: zeroValue,
+				},
+			},
 		}
 
 		tblEntry := &bfruntime.Entity{
@@ -175,7 +163,7 @@ func (driver *TofinoDriver) GetResetTableSelectorRequests(selectorEntries []*mod
 					TableId: tblId,
 					Value: &bfruntime.TableEntry_Key{
 						Key: &bfruntime.TableKey{
-							Fields: keyFields,
+							Fields: driver.transformMatchSelectorKeys(selectorEntries[i].Keys),
 						},
 					},
 					Data: &bfruntime.TableData{
@@ -194,33 +182,13 @@ func (driver *TofinoDriver) GetResetTableSelectorRequests(selectorEntries []*mod
 	return updateRequests, nil
 }
 
-// Retrieve all MatchSelectorEntries from device
+// Retrieve all MatchSelectorEntries of all selector tables from device
 func (driver *TofinoDriver) GetMatchSelectorEntries() ([]*bfruntime.Entity, error) {
-	tblName, ok := driver.probeTableMap[PROBE_INGRESS_MATCH_CNT]
-	if !ok {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_MATCH_CNT}
-	}
-
-	tblId := driver.GetTableIdByName(tblName)
-	if tblId == 0 {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+	tblEntries, err := driver.createSelectorTableReadRequests()
+	if err != nil {
+		return nil, err
 	}
 
-	tblEntries := []*bfruntime.Entity{}
-
-	tblEntries = append(tblEntries,
-		&bfruntime.Entity{
-			Entity: &bfruntime.Entity_TableEntry{
-				TableEntry: &bfruntime.TableEntry{
-					IsDefaultEntry: false,
-					TableId:        tblId,
-					TableFlags: &bfruntime.TableFlags{
-						FromHw: true,
-					},
-				},
-			},
-		},
-	)
 	entities, err := driver.SendReadRequest(tblEntries)
 	return entities, err
 }
@@ -232,27 +200,32 @@ func (driver *TofinoDriver) GetKeysFromMatchSelectors() ([]*model.MatchSelectorE
 		return nil, err
 	}
 
-	tblName, ok := driver.probeTableMap[PROBE_INGRESS_MATCH_CNT]
-	if !ok {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_MATCH_CNT}
-	}
-
-	actionName := driver.FindFullActionName(tblName, PROBE_INGRESS_MATCH_ACTION_NAME)
-	if actionName == "" {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find full action name for the match selector", Entity: PROBE_INGRESS_MATCH_ACTION_NAME}
-	}
-
-	sessionIdDataId := driver.GetDataIdByName(tblName, actionName, PROBE_INGRESS_MATCH_ACTION_NAME_SESSIONID)
-	if sessionIdDataId == 0 {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find field id for the match selector", Entity: PROBE_INGRESS_MATCH_ACTION_NAME_SESSIONID}
-	}
-
 	matchSelectorEntries := make([]*model.MatchSelectorEntry, 0, len(entries))
 	for i := range entries {
-		matchSelectorEntry := &model.MatchSelectorEntry{}
+		tblName := driver.GetTableNameById(entries[i].GetTableEntry().GetTableId())
+		actionName := driver.FindFullActionName(tblName, PROBE_INGRESS_MATCH_ACTION_NAME)
+		if actionName == "" {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find full action name for the match selector", Entity: PROBE_INGRESS_MATCH_ACTION_NAME}
+		}
+
+		sessionIdDataId := driver.GetDataIdByName(tblName, actionName, PROBE_INGRESS_MATCH_ACTION_NAME_SESSIONID)
+		if sessionIdDataId == 0 {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find field id for the match selector", Entity: PROBE_INGRESS_MATCH_ACTION_NAME_SESSIONID}
+		}
+
+		matchSelectorEntry := &model.MatchSelectorEntry{
+			Table: driver.FindShortTableNameByName(tblName),
+		}
+		// Only tables with ternary, lpm, range or optional keys have a match priority
+		priorityKeyId := driver.GetKeyIdByName(tblName, MATCH_PRIORITY_KEY_NAME)
 		keyFields := entries[i].GetTableEntry().GetKey().GetFields()
 		matchSelectorKeys := make([]*model.MatchSelectorKey, 0, len(keyFields))
 		for key_i := range keyFields {
+			if priorityKeyId != 0 && keyFields[key_i].GetFieldId() == priorityKeyId {
+				priority := decodeUint32(keyFields[key_i].GetExact().GetValue())
+				matchSelectorEntry.Priority = &priority
+				continue
+			}
 			matchSelectorKey := &model.MatchSelectorKey{
 				FieldId: keyFields[key_i].GetFieldId(),
 			}
@@ -268,6 +241,14 @@ func (driver *TofinoDriver) GetKeysFromMatchSelectors() ([]*model.MatchSelectorE
 				matchSelectorKey.Value = matchType.Lpm.GetValue()
 				matchSelectorKey.MatchType = model.MATCH_TYPE_LPM
 				matchSelectorKey.PrefixLength = matchType.Lpm.GetPrefixLen()
+			case *bfruntime.KeyField_Range_:
+				matchSelectorKey.MatchType = model.MATCH_TYPE_RANGE
+				matchSelectorKey.RangeLow = matchType.Range.GetLow()
+				matchSelectorKey.RangeHigh = matchType.Range.GetHigh()
+			case *bfruntime.KeyField_Optional_:
+				matchSelectorKey.Value = matchType.Optional.GetValue()
+				matchSelectorKey.MatchType = model.MATCH_TYPE_OPTIONAL
+				matchSelectorKey.IsValid = matchType.Optional.GetIsValid()
 			}
 			matchSelectorKeys = append(matchSelectorKeys, matchSelectorKey)
 		}
@@ -296,6 +277,7 @@ func (driver *TofinoDriver) GetKeysFromMatchSelectors() ([]*model.MatchSelectorE
 
 // Returns the width of the sessionId parameter
 // Needed to generate new sessionId or to define the size of the bufferpool
+// The sessionId has the same width in all selector tables, hence the default selector table is used.
 func (driver *TofinoDriver) GetSessionIdBitWidth() (uint32, error) {
 	tblName, ok := driver.probeTableMap[PROBE_INGRESS_MATCH_CNT]
 	if !ok {
@@ -321,72 +303,133 @@ func (driver *TofinoDriver) GetSessionIdBitWidth() (uint32, error) {
 }
 
 func (driver *TofinoDriver) AddSelectorEntry(newEntry *model.MatchSelectorEntry) error {
-	tblName, ok := driver.probeTableMap[PROBE_INGRESS_MATCH_CNT]
-	if !ok {
-		return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_MATCH_CNT}
+	tblEntry, err := driver.createSelectorEntity(newEntry)
+	if err != nil {
+		return err
+	}
+
+	updateReq := []*bfruntime.Update{
+		{
+			Type:   bfruntime.Update_INSERT,
+			Entity: tblEntry,
+		},
+	}
+
+	err = driver.SendWriteRequest(updateReq)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Adds multiple selector rules with all-or-nothing semantics in a single write request.
+func (driver *TofinoDriver) AddSelectorEntries(newEntries []*model.MatchSelectorEntry) error {
+	updateReq := make([]*bfruntime.Update, 0, len(newEntries))
+	for i := range newEntries {
+		tblEntry, err := driver.createSelectorEntity(newEntries[i])
+		if err != nil {
+			return err
+		}
+		updateReq = append(updateReq, &bfruntime.Update{
+			Type:   bfruntime.Update_INSERT,
+			Entity: tblEntry,
+		})
+	}
+
+	return driver.SendAtomicWriteRequest(updateReq)
+}
+
+// Changes the keys or the priority of an existing selector rule and keeps its sessionId.
+// The match key of an entry cannot be modified in BfRt. Hence the new entry is inserted and the old entry is deleted in an atomic request.
+// If only the action data differs, the entry will be modified in place.
+func (driver *TofinoDriver) ModifySelectorEntry(oldEntry *model.MatchSelectorEntry, newEntry *model.MatchSelectorEntry) error {
+	newTblEntry, err := driver.createSelectorEntity(newEntry)
+	if err != nil {
+		return err
+	}
+	oldTblEntry, err := driver.createSelectorEntity(oldEntry)
+	if err != nil {
+		return err
+	}
+
+	if isSameMatchKey(oldEntry, newEntry) {
+		return driver.SendWriteRequest([]*bfruntime.Update{
+			{
+				Type:   bfruntime.Update_MODIFY,
+				Entity: newTblEntry,
+			},
+		})
+	}
+
+	// Both updates are applied in a single request. Otherwise both entries would count for the same sessionId on a failure.
+	return driver.SendAtomicWriteRequest([]*bfruntime.Update{
+		{
+			Type:   bfruntime.Update_INSERT,
+			Entity: newTblEntry,
+		},
+		{
+			Type:   bfruntime.Update_DELETE,
+			Entity: oldTblEntry,
+		},
+	})
+}
+
+// Checks if two selector rules have the same keys and priority and refer to the same table entry.
+// Keys are compared by their field id, as the keys of the device cache can be ordered differently.
+func isSameMatchKey(a *model.MatchSelectorEntry, b *model.MatchSelectorEntry) bool {
+	if a.Table != b.Table || len(a.Keys) != len(b.Keys) {
+		return false
+	}
+	if (a.Priority == nil) != (b.Priority == nil) || (a.Priority != nil && *a.Priority != *b.Priority) {
+		return false
+	}
+	keysB := make(map[uint32]*model.MatchSelectorKey, len(b.Keys))
+	for _, key := range b.Keys {
+		keysB[key.FieldId] = key
+	}
+	for _, keyA := range a.Keys {
+		keyB, ok := keysB[keyA.FieldId]
+		if !ok || !keyA.Equal(keyB) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Creates a table entry of a selector table with the sessionId as action parameter
+func (driver *TofinoDriver) createSelectorEntity(entry *model.MatchSelectorEntry) (*bfruntime.Entity, error) {
+	tblName, err := driver.GetSelectorTableName(entry.Table)
+	if err != nil {
+		return nil, err
 	}
 
 	tblId := driver.GetTableIdByName(tblName)
 	if tblId == 0 {
-		return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
 	}
 
 	actionName := driver.FindFullActionName(tblName, PROBE_INGRESS_MATCH_ACTION_NAME)
 	actionId := driver.GetActionIdByName(tblName, actionName)
 	if actionId == 0 {
-		return &model.ErrNameNotFound{Msg: "Cannot find action name", Entity: PROBE_INGRESS_MATCH_ACTION_NAME}
+		return nil, &model.ErrNameNotFound{Msg: "Cannot find action name", Entity: PROBE_INGRESS_MATCH_ACTION_NAME}
 	}
 
 	dataId := driver.GetDataIdByName(tblName, actionName, PROBE_INGRESS_MATCH_ACTION_NAME_SESSIONID)
 	if dataId == 0 {
-		return &model.ErrNameNotFound{Msg: "Cannot find action param name", Entity: PROBE_INGRESS_MATCH_ACTION_NAME_SESSIONID}
-	}
-
-	keyFields := []*bfruntime.KeyField{}
-
-	for _, keyItem := range newEntry.Keys {
-		switch keyItem.MatchType {
-		case model.MATCH_TYPE_EXACT:
-			keyFields = append(keyFields, &bfruntime.KeyField{
-				FieldId: keyItem.FieldId,
-				MatchType: &bfruntime.KeyField_Exact_{
-					Exact: &bfruntime.KeyField_Exact{
-						Value: keyItem.Value,
-					},
-				},
-			})
-		case model.MATCH_TYPE_TERNARY:
-			keyFields = append(keyFields, &bfruntime.KeyField{
-				FieldId: keyItem.FieldId,
-				MatchType: &bfruntime.KeyField_Ternary_{
-					Ternary: &bfruntime.KeyField_Ternary{
-						Value: keyItem.Value,
-						Mask:  keyItem.ValueMask,
-					},
-				},
-			})
-		case model.MATCH_TYPE_LPM:
-			keyFields = append(keyFields, &bfruntime.KeyField{
-				FieldId: keyItem.FieldId,
-				MatchType: &bfruntime.KeyField_Lpm{
-					Lpm: &bfruntime.KeyField_LPM{
-						Value:     keyItem.Value,
-						PrefixLen: keyItem.PrefixLength,
-					},
-				},
-			})
-		}
+		return nil, &model.ErrNameNotFound{Msg: "Cannot find action param name", Entity: PROBE_INGRESS_MATCH_ACTION_NAME_SESSIONID}
 	}
 
 	// Convert to byte slice
 	byteSessionId := make([]byte, 4)
-	binary.BigEndian.PutUint32(byteSessionId, newEntry.SessionId)
+	binary.BigEndian.PutUint32(byteSessionId, entry.SessionId)
 	sessionIdWidth, err := driver.GetSessionIdBitWidth()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// Calculate, which from where to select the bytes
-	byteArrayWidth := len(byteSessionId) - ((int(sessionIdWidth) / 8) + 1)
+	// Calculate, which from where to select the bytes. The sessionId is encoded with the minimal amount of bytes.
+	byteArrayWidth := len(byteSessionId) - ((int(sessionIdWidth) + 7) / 8)
 
 	dataFields := []*bfruntime.DataField{
 		{
@@ -397,13 +440,13 @@ func (driver *TofinoDriver) AddSelectorEntry(newEntry *model.MatchSelectorEntry)
 		},
 	}
 
-	tblEntry := &bfruntime.Entity{
+	return &bfruntime.Entity{
 		Entity: &bfruntime.Entity_TableEntry{
 			TableEntry: &bfruntime.TableEntry{
 				TableId: tblId,
 				Value: &bfruntime.TableEntry_Key{
 					Key: &bfruntime.TableKey{
-						Fields: keyFields,
+						Fields: driver.transformMatchSelectorEntryKeys(tblName, entry),
 					},
 				},
 				Data: &bfruntime.TableData{
@@ -412,49 +455,82 @@ func (driver *TofinoDriver) AddSelectorEntry(newEntry *model.MatchSelectorEntry)
 				},
 			},
 		},
-	}
-
-	updateReq := []*bfruntime.Update{
-		{
-			Type:   bfruntime.Update_INSERT,
-			Entity: tblEntry,
-		},
-	}
-
-	err = driver.SendWriteRequest(updateReq)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	}, nil
 }
 
 func (driver *TofinoDriver) RemoveSelectorEntry(entry *model.MatchSelectorEntry) error {
-	tblName, ok := driver.probeTableMap[PROBE_INGRESS_MATCH_CNT]
-	if !ok {
-		return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_MATCH_CNT}
-	}
+	return driver.RemoveSelectorEntries([]*model.MatchSelectorEntry{entry})
+}
 
-	tblId := driver.GetTableIdByName(tblName)
-	if tblId == 0 {
-		return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+// Removes multiple selector rules in a single write request.
+func (driver *TofinoDriver) RemoveSelectorEntries(entries []*model.MatchSelectorEntry) error {
+	updateReq := make([]*bfruntime.Update, 0, len(entries))
+	for _, entry := range entries {
+		tblName, err := driver.GetSelectorTableName(entry.Table)
+		if err != nil {
+			return err
+		}
+
+		tblId := driver.GetTableIdByName(tblName)
+		if tblId == 0 {
+			return &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
+		}
+
+		updateReq = append(updateReq, &bfruntime.Update{
+			Type: bfruntime.Update_DELETE,
+			Entity: &bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableEntry{
+					TableEntry: &bfruntime.TableEntry{
+						TableId: tblId,
+						Value: &bfruntime.TableEntry_Key{
+							Key: &bfruntime.TableKey{
+								Fields: driver.transformMatchSelectorEntryKeys(tblName, entry),
+							},
+						},
+					},
+				},
+			},
+		})
 	}
 
-	actionName := driver.FindFullActionName(tblName, PROBE_INGRESS_MATCH_ACTION_NAME)
+	// Send delete request
+	return driver.SendWriteRequest(updateReq)
+}
 
-	actionId := driver.GetActionIdByName(tblName, actionName)
-	if actionId == 0 {
-		return &model.ErrNameNotFound{Msg: "Cannot find action name", Entity: PROBE_INGRESS_MATCH_ACTION_NAME}
+// Transform the keys and the match priority of a selector rule to key fields of a BfRt table entry
+func (driver *TofinoDriver) transformMatchSelectorEntryKeys(tblName string, entry *model.MatchSelectorEntry) []*bfruntime.KeyField {
+	priorityKeyId := driver.GetKeyIdByName(tblName, MATCH_PRIORITY_KEY_NAME)
+	if priorityKeyId == 0 || entry.Priority == nil {
+		return driver.transformMatchSelectorKeys(entry.Keys)
 	}
 
-	dataId := driver.GetDataIdByName(tblName, actionName, PROBE_INGRESS_MATCH_ACTION_NAME_SESSIONID)
-	if dataId == 0 {
-		return &model.ErrNameNotFound{Msg: "Cannot find action param name", Entity: PROBE_INGRESS_MATCH_ACTION_NAME_SESSIONID}
+	// An explicit priority replaces a $MATCH_PRIORITY key defined in the list of keys
+	keys := make([]*model.MatchSelectorKey, 0, len(entry.Keys))
+	for i := range entry.Keys {
+		if entry.Keys[i].FieldId != priorityKeyId {
+			keys = append(keys, entry.Keys[i])
+		}
 	}
+	bytePriority := make([]byte, 4)
+	binary.BigEndian.PutUint32(bytePriority, *entry.Priority)
+	keyFields := driver.transformMatchSelectorKeys(keys)
+	keyFields = append(keyFields, &bfruntime.KeyField{
+		FieldId: priorityKeyId,
+		MatchType: &bfruntime.KeyField_Exact_{
+			Exact: &bfruntime.KeyField_Exact{
+				Value: bytePriority,
+			},
+		},
+	})
 
+	return keyFields
+}
+
+// Transform match selector keys to key fields of a BfRt table entry
+func (driver *TofinoDriver) transformMatchSelectorKeys(keys []*model.MatchSelectorKey) []*bfruntime.KeyField {
 	keyFields := []*bfruntime.KeyField{}
 
-	for _, keyItem := range entry.Keys {
+	for _, keyItem := range keys {
 		switch keyItem.MatchType {
 		case model.MATCH_TYPE_EXACT:
 			keyFields = append(keyFields, &bfruntime.KeyField{
@@ -485,44 +561,44 @@ func (driver *TofinoDriver) RemoveSelectorEntry(entry *model.MatchSelectorEntry)
 					},
 				},
 			})
-		}
-	}
-
-	tblEntry := &bfruntime.Entity{
-		Entity: &bfruntime.Entity_TableEntry{
-			TableEntry: &bfruntime.TableEntry{
-				TableId: tblId,
-				Value: &bfruntime.TableEntry_Key{
-					Key: &bfruntime.TableKey{
-						Fields: keyFields,
+		case model.MATCH_TYPE_RANGE:
+			keyFields = append(keyFields, &bfruntime.KeyField{
+				FieldId: keyItem.FieldId,
+				MatchType: &bfruntime.KeyField_Range_{
+					Range: &bfruntime.KeyField_Range{
+						Low:  keyItem.RangeLow,
+						High: keyItem.RangeHigh,
 					},
 				},
-			},
-		},
+			})
+		case model.MATCH_TYPE_OPTIONAL:
+			keyFields = append(keyFields, &bfruntime.KeyField{
+				FieldId: keyItem.FieldId,
+				MatchType: &bfruntime.KeyField_Optional_{
+					Optional: &bfruntime.KeyField_Optional{
+						Value:   keyItem.Value,
+						IsValid: keyItem.IsValid,
+					},
+				},
+			})
+		}
 	}
 
-	updateReq := []*bfruntime.Update{
-		{
-			Type:   bfruntime.Update_DELETE,
-			Entity: tblEntry,
-		},
-	}
+	return keyFields
+}
 
-	// Send delete request
-	err := driver.SendWriteRequest(updateReq)
+// Returns the key schema of a selector table. An empty table name refers to the default selector table.
+func (driver *TofinoDriver) GetIngressStartMatchSelectorSchema(selectorTable string) ([]*model.MatchSelectorSchema, error) {
+	tblName, err := driver.GetSelectorTableName(selectorTable)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return driver.GetTableKeySchema(tblName)
 }
 
-func (driver *TofinoDriver) GetIngressStartMatchSelectorSchema() ([]*model.MatchSelectorSchema, error) {
-	tblName, ok := driver.probeTableMap[PROBE_INGRESS_MATCH_CNT]
-	if !ok {
-		return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: PROBE_INGRESS_MATCH_CNT}
-	}
-
+// Returns the key schema of any P4 table given its full name
+func (driver *TofinoDriver) GetTableKeySchema(tblName string) ([]*model.MatchSelectorSchema, error) {
 	if sliceIdx, ok := driver.indexP4Tables[tblName]; ok {
 		// Create a DTO
 		keys := make([]*model.MatchSelectorSchema, 0, len(driver.P4Tables[sliceIdx].Key))
@@ -540,3 +616,17 @@ func (driver *TofinoDriver) GetIngressStartMatchSelectorSchema() ([]*model.Match
 
 	return nil, &model.ErrNameNotFound{Msg: "Cannot find table name for the probe", Entity: tblName}
 }
+
+// Returns the key schema of all selector tables
+func (driver *TofinoDriver) GetAllMatchSelectorSchemas() ([]*model.MatchSelectorTableSchema, error) {
+	tableSchemas := make([]*model.MatchSelectorTableSchema, 0, len(driver.selectorTableCache))
+	for _, shortTblName := range driver.selectorTableCache {
+		keys, err := driver.GetIngressStartMatchSelectorSchema(shortTblName)
+		if err != nil {
+			return nil, err
+		}
+		tableSchemas = append(tableSchemas, &model.MatchSelectorTableSchema{Name: shortTblName, Keys: keys})
+	}
+
+	return tableSchemas, nil
+}
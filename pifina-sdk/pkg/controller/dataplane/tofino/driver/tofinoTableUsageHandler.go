@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package driver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Returns from cache all match action tables of the user application on device.
+func (driver *TofinoDriver) GetAllMatchActionTableNames() []string {
+	return driver.getTableNamesByType(TABLE_TYPE_MATCHACTION)
+}
+
+// Returns the full names of all selector tables.
+func (driver *TofinoDriver) GetSelectorTableNames() []string {
+	tableNames := make([]string, 0, len(driver.selectorTableCache))
+	for _, shortTblName := range driver.selectorTableCache {
+		tableNames = append(tableNames, driver.probeTableMap[shortTblName])
+	}
+
+	return tableNames
+}
+
+// Checks if the table exists and its occupancy can be monitored.
+func (driver *TofinoDriver) ValidateMonitoredTable(tblName string) error {
+	sliceIdx, ok := driver.indexP4Tables[tblName]
+	if !ok {
+		return &model.ErrNameNotFound{Msg: "Table not found", Entity: tblName}
+	}
+	if driver.P4Tables[sliceIdx].TableType != TABLE_TYPE_MATCHACTION {
+		return fmt.Errorf("table %s is not a match action table", tblName)
+	}
+	if driver.P4Tables[sliceIdx].Size == 0 {
+		return fmt.Errorf("table %s has no size", tblName)
+	}
+
+	return nil
+}
+
+// Reads the amount of installed entries of the given tables.
+// The size is taken from the BfRt info, as it is fixed at compile time.
+// $TABLE_SIZE cannot be read through BfRt gRPC. Neither TableUsage nor TableAttribute carry the size of a table.
+func (driver *TofinoDriver) GetTableOccupancy(tblNames []string) ([]*model.TableOccupancy, error) {
+	occupancies := make([]*model.TableOccupancy, 0, len(tblNames))
+	if len(tblNames) == 0 {
+		return occupancies, nil
+	}
+	occupancyById := make(map[uint32]*model.TableOccupancy, len(tblNames))
+	usageRequests := []*bfruntime.Entity{}
+	for _, tblName := range tblNames {
+		tblId := driver.GetTableIdByName(tblName)
+		if tblId == 0 {
+			return nil, &model.ErrNameNotFound{Msg: "Cannot find table", Entity: tblName}
+		}
+		occupancy := &model.TableOccupancy{Name: tblName, Size: driver.GetTableSizeByName(tblName)}
+		occupancies = append(occupancies, occupancy)
+		occupancyById[tblId] = occupancy
+		usageRequests = append(usageRequests,
+			&bfruntime.Entity{
+				Entity: &bfruntime.Entity_TableUsage{
+					TableUsage: &bfruntime.TableUsage{
+						TableId: tblId,
+						TableFlags: &bfruntime.TableFlags{
+							FromHw: true,
+						},
+					},
+				},
+			},
+		)
+	}
+
+	entities, err := driver.SendReadRequest(usageRequests)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entities {
+		tblUsage := entities[i].GetTableUsage()
+		if tblUsage == nil {
+			continue
+		}
+		occupancy, ok := occupancyById[tblUsage.GetTableId()]
+		if !ok {
+			continue
+		}
+		occupancy.Usage = tblUsage.GetUsage()
+		if occupancy.Size > 0 {
+			occupancy.Occupancy = float64(occupancy.Usage) * 100 / float64(occupancy.Size)
+		}
+	}
+
+	return occupancies, nil
+}
+
+// Reads the occupancy of the given tables and transforms it to metrics.
+// The usage is exported as PF_TABLE_USAGE_<table name> and the occupancy in percent as PF_TABLE_OCCUPANCY_<table name>.
+// The occupancy is rounded down, so a table is only reported with 100%, if it is full.
+func (driver *TofinoDriver) GetTableOccupancyMetrics(tblNames []string) ([]*model.MetricItem, error) {
+	occupancies, err := driver.GetTableOccupancy(tblNames)
+	if err != nil {
+		return nil, err
+	}
+	timeNow := time.Now()
+	transformedMetrics := make([]*model.MetricItem, 0, 2*len(occupancies))
+	for _, occupancy := range occupancies {
+		transformedMetrics = append(transformedMetrics,
+			&model.MetricItem{Value: uint64(occupancy.Usage), Type: model.METRIC_EXT_VALUE, MetricName: PROBE_TABLE_USAGE_PREFIX + occupancy.Name, LastUpdated: timeNow},
+			&model.MetricItem{Value: uint64(occupancy.Occupancy), Type: model.METRIC_EXT_VALUE, MetricName: PROBE_TABLE_OCCUPANCY_PREFIX + occupancy.Name, LastUpdated: timeNow},
+		)
+	}
+
+	return transformedMetrics, nil
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/thushjandan/pifina/internal/dataplane/tofino/protos/bfruntime"
@@ -208,15 +209,27 @@ func (driver *TofinoDriver) GetTMPipelineCounter(pipelineCount int) ([]*model.Me
 		},
 	)
 
+	// Send the read requests of all pipes at once instead of waiting for each response.
+	pipeEntities := make([][]*bfruntime.Entity, pipelineCount)
+	pipeErrors := make([]error, pipelineCount)
+	var wg sync.WaitGroup
+	for pipe_id := 0; pipe_id < pipelineCount; pipe_id++ {
+		wg.Add(1)
+		go func(pipe_id int) {
+			defer wg.Done()
+			pipeEntities[pipe_id], pipeErrors[pipe_id] = driver.SendReadRequestByPipeId(tblEntries, pipe_id)
+		}(pipe_id)
+	}
+	wg.Wait()
+
 	// Transform response
 	transformedMetrics := make([]*model.MetricItem, 0)
 	timeNow := time.Now()
 	for pipe_id := 0; pipe_id < pipelineCount; pipe_id++ {
-		// Send read request to switch.
-		entities, err := driver.SendReadRequestByPipeId(tblEntries, pipe_id)
-		if err != nil {
-			return nil, err
+		if pipeErrors[pipe_id] != nil {
+			return nil, pipeErrors[pipe_id]
 		}
+		entities := pipeEntities[pipe_id]
 		for i := range entities {
 			tblEntry := entities[i].GetTableEntry()
 			dataEntries := tblEntry.GetData().GetFields()
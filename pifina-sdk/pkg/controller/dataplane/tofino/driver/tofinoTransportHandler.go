@@ -52,6 +52,10 @@ func (driver *TofinoDriver) Connect(ctx context.Context, endpoint string, connec
 	reqSub := bfruntime.StreamMessageRequest_Subscribe{
 		Subscribe: &bfruntime.Subscribe{
 			DeviceId: 0,
+			// Digests of the flow discovery are sent as learn notifications
+			Notifications: &bfruntime.Subscribe_Notifications{
+				EnableLearnNotifications: true,
+			},
 		},
 	}
 
@@ -81,26 +85,41 @@ func (driver *TofinoDriver) Connect(ctx context.Context, endpoint string, connec
 	}
 
 	driver.logger.Info("Connection is ready to use")
-	// Parse BfrtInfo
-	driver.P4Tables, err = UnmarshalBfruntimeInfoJson(getForwardPipelineConfigResponse.Config[0].BfruntimeInfo)
+	err = driver.LoadBfrtInfo(getForwardPipelineConfigResponse.Config[0].BfruntimeInfo, getForwardPipelineConfigResponse.NonP4Config.BfruntimeInfo)
 	if err != nil {
 		driver.Disconnect()
+		return err
+	}
+
+	// Create Hash map for port cache
+	driver.portCache = make(map[string][]byte)
+
+	return nil
+}
+
+// Parses the BfrtInfo of the P4 program and of the fixed tables and builds the indices of the tables.
+func (driver *TofinoDriver) LoadBfrtInfo(p4BfrtInfo []byte, nonP4BfrtInfo []byte) error {
+	var err error
+	// Parse BfrtInfo
+	driver.P4Tables, err = UnmarshalBfruntimeInfoJson(p4BfrtInfo)
+	if err != nil {
 		return errors.New(fmt.Sprintf("Could not parse P4Table BfrtInfo payload. Error: %v", err))
 	}
 	// Create Hash table for faster retrieval of tables
 	driver.createP4TableIndex()
+	// Digests of the P4 application like the flow discovery digest
+	driver.LearnFilters, err = UnmarshalBfruntimeLearnFilters(p4BfrtInfo)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Could not parse learn filters of BfrtInfo payload. Error: %v", err))
+	}
 	// Parse NonP4Tables BfrtInfo
-	driver.NonP4Tables, err = UnmarshalBfruntimeInfoJson(getForwardPipelineConfigResponse.NonP4Config.BfruntimeInfo)
+	driver.NonP4Tables, err = UnmarshalBfruntimeInfoJson(nonP4BfrtInfo)
 	if err != nil {
-		driver.Disconnect()
 		return errors.New(fmt.Sprintf("Could not parse NonP4Table BfrtInfo payload. Error: %v", err))
 	}
 	// Create Hash table for faster retrieval of tables
 	driver.createNonP4TableIndex()
 
-	// Create Hash map for port cache
-	driver.portCache = make(map[string][]byte)
-
 	return nil
 }
 
@@ -193,11 +212,11 @@ func (driver *TofinoDriver) SendReadRequestByPipeId(tblEntries []*bfruntime.Enti
 	if !driver.isConnected {
 		return nil, &model.ErrNotReady{Msg: "Not connected to Tofino"}
 	}
-	// Only single access to device allowed.
-	// Otherwise undefined behaviour of Tofino device could occur
+	// Read requests of independent collection cycles are pipelined on the connection.
+	// Writes still need exclusive access, otherwise undefined behaviour of Tofino device could occur.
 	// pipe_mgr could complains that a batch is already in progress
-	driver.lock.Lock()
-	defer driver.lock.Unlock()
+	driver.lock.RLock()
+	defer driver.lock.RUnlock()
 
 	ctx, cancel := context.WithTimeout(driver.ctx, 5*time.Second)
 	defer cancel()
@@ -217,15 +236,30 @@ func (driver *TofinoDriver) SendReadRequestByPipeId(tblEntries []*bfruntime.Enti
 }
 
 func (driver *TofinoDriver) SendWriteRequest(updateItems []*bfruntime.Update) error {
+	return driver.sendWriteRequest(updateItems, bfruntime.WriteRequest_CONTINUE_ON_ERROR)
+}
+
+// Sends all updates in a single write request. If a single update fails, all updates are rolled back by the switch.
+func (driver *TofinoDriver) SendAtomicWriteRequest(updateItems []*bfruntime.Update) error {
+	return driver.sendWriteRequest(updateItems, bfruntime.WriteRequest_ROLLBACK_ON_ERROR)
+}
+
+func (driver *TofinoDriver) sendWriteRequest(updateItems []*bfruntime.Update, atomicity bfruntime.WriteRequest_Atomicity) error {
 	// Ignore empty write requests
 	if updateItems == nil {
 		return nil
 	}
+	if driver.readOnly {
+		return &model.ErrReadOnly{Msg: "Controller is in read-only mode. Write requests to the dataplane are not allowed"}
+	}
+	if driver.follower.Load() {
+		return &model.ErrReadOnly{Msg: "Another client is bound to the P4 program. Write requests to the dataplane are not allowed until the lease has been acquired"}
+	}
 
 	writeReq := bfruntime.WriteRequest{
 		ClientId:  driver.clientId,
 		P4Name:    driver.p4Name,
-		Atomicity: bfruntime.WriteRequest_CONTINUE_ON_ERROR,
+		Atomicity: atomicity,
 		Target: &bfruntime.TargetDevice{
 			DeviceId:  0,
 			PipeId:    TOFINO_PIPE_ID,
@@ -252,9 +286,14 @@ func (driver *TofinoDriver) SendWriteRequest(updateItems []*bfruntime.Update) er
 
 // Process metric responses and transform to metric item objects
 func (driver *TofinoDriver) ProcessMetricResponse(entities []*bfruntime.Entity) ([]*model.MetricItem, error) {
+	return driver.ProcessMetricResponseAt(entities, time.Now())
+}
+
+// Process metric responses and transform to metric item objects with the given timestamp,
+// e.g. the time, when the values have been synced from the hardware.
+func (driver *TofinoDriver) ProcessMetricResponseAt(entities []*bfruntime.Entity, timeNow time.Time) ([]*model.MetricItem, error) {
 	// Transform response
 	transformedMetrics := make([]*model.MetricItem, 0, len(entities))
-	timeNow := time.Now()
 	for i := range entities {
 		tblId := entities[i].GetTableEntry().GetTableId()
 		tableType := driver.GetTableTypeById(tblId)
@@ -273,11 +312,13 @@ func (driver *TofinoDriver) ProcessMetricResponse(entities []*bfruntime.Entity)
 		// Process register metrics
 		if tableType == TABLE_TYPE_REGISTER {
 			metric, err := driver.ProcessRegisterResponse(entities[i])
-			if err != nil || metric == nil {
+			if err != nil || len(metric) == 0 {
 				continue
 			}
-			metric.LastUpdated = timeNow
-			transformedMetrics = append(transformedMetrics, metric)
+			for metric_i := range metric {
+				metric[metric_i].LastUpdated = timeNow
+			}
+			transformedMetrics = append(transformedMetrics, metric...)
 		}
 
 		// Process Counter metrics
@@ -291,6 +332,17 @@ func (driver *TofinoDriver) ProcessMetricResponse(entities []*bfruntime.Entity)
 			}
 			transformedMetrics = append(transformedMetrics, metric...)
 		}
+		// Process meters of the user application
+		if tableType == TABLE_TYPE_METER {
+			metric, err := driver.ProcessMeterResponse(entities[i])
+			if err != nil || len(metric) == 0 {
+				continue
+			}
+			for metric_i := range metric {
+				metric[metric_i].LastUpdated = timeNow
+			}
+			transformedMetrics = append(transformedMetrics, metric...)
+		}
 		// Process TM counters
 		if tableType == TABLE_TYPE_TM_CNT_IG || tableType == TABLE_TYPE_TM_CNT_EG {
 			metric, err := driver.ProcessTMCounters(entities[i])
@@ -302,6 +354,17 @@ func (driver *TofinoDriver) ProcessMetricResponse(entities []*bfruntime.Entity)
 			}
 			transformedMetrics = append(transformedMetrics, metric...)
 		}
+		// Process link state and RMON counters of ports
+		if tableType == TABLE_TYPE_PORT_CFG || tableType == TABLE_TYPE_PORT_STAT {
+			metric, err := driver.ProcessPortResponse(entities[i])
+			if err != nil || len(metric) == 0 {
+				continue
+			}
+			for metric_i := range metric {
+				metric[metric_i].LastUpdated = timeNow
+			}
+			transformedMetrics = append(transformedMetrics, metric...)
+		}
 	}
 	return transformedMetrics, nil
 }
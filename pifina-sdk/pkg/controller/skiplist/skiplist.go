@@ -30,14 +30,18 @@ const (
 	DEFAULT_PROBABILITY float64 = 1 / math.E
 )
 
-func (sl *SkipList) getCompositeKey(key string, subKey uint32, metricType string) string {
+// Metrics with a label like the top flows share the metric name and sessionId, hence the label is part of the key.
+func (sl *SkipList) getCompositeKey(key string, subKey uint32, metricType string, label string) string {
+	if label != "" {
+		return fmt.Sprintf("%s%s%d#%s", key, metricType, subKey, label)
+	}
 	return fmt.Sprintf("%s%s%d", key, metricType, subKey)
 }
 
 // Inserts a new item in the skiplist
 // If the key exists, it ignores the request
 func (sl *SkipList) Set(key string, subKey uint32, value *model.MetricItem) {
-	compositeKey := sl.getCompositeKey(key, subKey, value.Type)
+	compositeKey := sl.getCompositeKey(key, subKey, value.Type, value.Label)
 
 	prevs := sl.getPrevElementNodes(compositeKey)
 	currentNode := prevs[0].next[0]
@@ -70,7 +74,7 @@ func (sl *SkipList) Set(key string, subKey uint32, value *model.MetricItem) {
 // Get finds an element by key. It returns element pointer if found, nil if not found.
 // Get is wait-free. Stale reads are possible, but this is accepted in our environment.
 func (sl *SkipList) Get(key string, subKey uint32, metricType string) *SkipListNode {
-	compositeKey := sl.getCompositeKey(key, subKey, metricType)
+	compositeKey := sl.getCompositeKey(key, subKey, metricType, "")
 
 	var nextNode *SkipListNode
 
@@ -105,7 +109,7 @@ func (sl *SkipList) GetAllAndReset() []*model.MetricItem {
 		newItem := *(nextNode.value)
 		// Cleanup if required
 		if nextNode.value.LastUpdated.Before(agedTime) {
-			sl.Remove(nextNode.value.MetricName, nextNode.value.SessionId, nextNode.value.Type)
+			sl.remove(nextNode.key)
 		}
 		// Reset values
 		if nextNode.value.Type != model.METRIC_EXT_VALUE {
@@ -124,8 +128,10 @@ func (sl *SkipList) GetAllAndReset() []*model.MetricItem {
 // Remove deletes an element from the list.
 // Returns removed element pointer if found, nil if not found.
 func (sl *SkipList) Remove(key string, subKey uint32, metricType string) {
-	compositeKey := sl.getCompositeKey(key, subKey, metricType)
+	sl.remove(sl.getCompositeKey(key, subKey, metricType, ""))
+}
 
+func (sl *SkipList) remove(compositeKey string) {
 	prevs := sl.getPrevElementNodes(compositeKey)
 
 	node := prevs[0].next[0]
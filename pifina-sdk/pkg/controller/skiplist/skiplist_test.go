@@ -50,6 +50,30 @@ func TestCRUD(t *testing.T) {
 
 }
 
+func TestLabelledMetrics(t *testing.T) {
+	sl, err := NewSkiplistWithMaxBound(7)
+	if err != nil {
+		t.Fatal("Cannot initialize skip list with a specific upper bound", 7)
+	}
+	sl.Set("PF_HH_FLOW", 1, &model.MetricItem{Value: 10, MetricName: "PF_HH_FLOW", SessionId: 1, Label: "ipv4_src_addr=0xa000001", Type: model.METRIC_PKTS})
+	sl.Set("PF_HH_FLOW", 1, &model.MetricItem{Value: 20, MetricName: "PF_HH_FLOW", SessionId: 1, Label: "ipv4_src_addr=0xa000002", Type: model.METRIC_PKTS})
+	sl.Set("PF_HH_FLOW", 1, &model.MetricItem{Value: 5, MetricName: "PF_HH_FLOW", SessionId: 1, Label: "ipv4_src_addr=0xa000001", Type: model.METRIC_PKTS})
+
+	values := make(map[string]uint64)
+	for _, item := range sl.GetAllAndReset() {
+		values[item.Label] = item.Value
+	}
+	if len(values) != 2 {
+		t.Fatal("Wrong number of flows. Expected 2", len(values))
+	}
+	if values["ipv4_src_addr=0xa000001"] != 15 {
+		t.Fatal("Wrong value. Expected 15", values["ipv4_src_addr=0xa000001"])
+	}
+	if values["ipv4_src_addr=0xa000002"] != 20 {
+		t.Fatal("Wrong value. Expected 20", values["ipv4_src_addr=0xa000002"])
+	}
+}
+
 func BenchmarkRandomSet(b *testing.B) {
 	b.ReportAllocs()
 	// Using 2^12
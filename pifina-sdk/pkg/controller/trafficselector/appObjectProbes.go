@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package trafficselector
+
+import (
+	"fmt"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Adds a counter, direct counter or meter of the user application to the data collection.
+// The match key of a direct counter can be given as selector expression.
+func (ts *TrafficSelector) AddAppObjectProbe(newItem *model.AppObject) error {
+	if newItem.Type == model.APP_OBJECT_TYPE_DIRECT_COUNTER && len(newItem.Keys) == 0 && newItem.Expression != "" {
+		schema, err := ts.driver.GetTableKeySchema(newItem.Name)
+		if err != nil {
+			return err
+		}
+		keys, priority, err := CompileSelectorExpression(newItem.Expression, schema)
+		if err != nil {
+			return err
+		}
+		newItem.Keys = keys
+		if newItem.Priority == nil {
+			newItem.Priority = priority
+		}
+	}
+	if err := ts.driver.ValidateAppObject(newItem); err != nil {
+		return err
+	}
+	// Check if the table entry of the direct counter exists
+	if newItem.Type == model.APP_OBJECT_TYPE_DIRECT_COUNTER {
+		if _, err := ts.driver.GetDirectCounterMetrics([]*model.AppObject{newItem}); err != nil {
+			return fmt.Errorf("cannot read direct counter of the table entry: %w", err)
+		}
+	}
+
+	ts.appObjectProbesLock.Lock()
+	defer ts.appObjectProbesLock.Unlock()
+
+	for i := range ts.appObjectProbes {
+		existingItem := ts.appObjectProbes[i]
+		if existingItem.Name != newItem.Name {
+			continue
+		}
+		// The index of a direct counter identifies the table entry. It is used as sessionId of the metrics.
+		if newItem.Type == model.APP_OBJECT_TYPE_DIRECT_COUNTER {
+			sameEntry := isSameTableEntry(existingItem, newItem)
+			if existingItem.Index == newItem.Index && !sameEntry {
+				return fmt.Errorf("index %d is already used by another table entry of %s. Use a unique index for every table entry", newItem.Index, newItem.Name)
+			}
+			if existingItem.Index != newItem.Index && sameEntry {
+				return fmt.Errorf("table entry of %s is already monitored with index %d", newItem.Name, existingItem.Index)
+			}
+		}
+		// if entry already exists, just ignore and exit already here.
+		if existingItem.Index == newItem.Index {
+			return nil
+		}
+	}
+
+	ts.appObjectProbes = append(ts.appObjectProbes, newItem)
+
+	return nil
+}
+
+// Checks if two direct counters refer to the same table entry. Keys are compared by their field id.
+func isSameTableEntry(a *model.AppObject, b *model.AppObject) bool {
+	if len(a.Keys) != len(b.Keys) {
+		return false
+	}
+	if (a.Priority == nil) != (b.Priority == nil) || (a.Priority != nil && *a.Priority != *b.Priority) {
+		return false
+	}
+	for _, keyA := range a.Keys {
+		found := false
+		for _, keyB := range b.Keys {
+			if keyA.FieldId == keyB.FieldId {
+				found = keyA.Equal(keyB)
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the names of all objects of the given type on the device
+func (ts *TrafficSelector) GetAllAppObjectsOnDevice(objectType string) []string {
+	switch objectType {
+	case model.APP_OBJECT_TYPE_COUNTER:
+		return ts.driver.GetAllCounterNames()
+	case model.APP_OBJECT_TYPE_DIRECT_COUNTER:
+		return ts.driver.GetAllDirectCounterNames()
+	case model.APP_OBJECT_TYPE_METER:
+		return ts.driver.GetAllMeterNames()
+	}
+	return make([]string, 0)
+}
+
+// Returns the monitored objects of the given type. An empty type returns all objects.
+func (ts *TrafficSelector) GetAppObjectProbes(objectType string) []*model.AppObject {
+	ts.appObjectProbesLock.RLock()
+	defer ts.appObjectProbesLock.RUnlock()
+
+	copyAppObjectProbes := make([]*model.AppObject, 0, len(ts.appObjectProbes))
+	for i := range ts.appObjectProbes {
+		if objectType == "" || ts.appObjectProbes[i].Type == objectType {
+			copyAppObjectProbes = append(copyAppObjectProbes, ts.appObjectProbes[i])
+		}
+	}
+
+	return copyAppObjectProbes
+}
+
+func (ts *TrafficSelector) RemoveAppObjectProbe(itemToRemove *model.AppObject) {
+	ts.appObjectProbesLock.Lock()
+	defer ts.appObjectProbesLock.Unlock()
+
+	newAppObjectProbes := make([]*model.AppObject, 0)
+	for i := range ts.appObjectProbes {
+		if ts.appObjectProbes[i].Name != itemToRemove.Name || ts.appObjectProbes[i].Index != itemToRemove.Index {
+			newAppObjectProbes = append(newAppObjectProbes, ts.appObjectProbes[i])
+		}
+	}
+
+	ts.appObjectProbes = newAppObjectProbes
+}
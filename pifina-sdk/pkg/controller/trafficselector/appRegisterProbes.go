@@ -5,19 +5,26 @@
 
 package trafficselector
 
-import "github.com/thushjandan/pifina/pkg/model"
+import (
+	"fmt"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
 
 func (ts *TrafficSelector) AddAppRegisterProbe(newItem *model.AppRegister) error {
 	if tblId := ts.driver.GetTableIdByName(newItem.Name); tblId == 0 {
 		return &model.ErrNameNotFound{Msg: "Register not found", Entity: newItem.Name}
 	}
+	if err := ts.validateAppRegisterRange(newItem); err != nil {
+		return err
+	}
 
 	ts.appRegisterProbesLock.Lock()
 	defer ts.appRegisterProbesLock.Unlock()
 
 	for i := range ts.appRegisterProbes {
 		// if entry already exists, just ignore and exit already here.
-		if ts.appRegisterProbes[i].Name == newItem.Name && ts.appRegisterProbes[i].Index == newItem.Index {
+		if isSameAppRegister(ts.appRegisterProbes[i], newItem) {
 			return nil
 		}
 	}
@@ -51,10 +58,51 @@ func (ts *TrafficSelector) RemoveAppRegisterProbe(itemToRemove *model.AppRegiste
 
 	newAppRegisterProbes := make([]*model.AppRegister, 0)
 	for i := range ts.appRegisterProbes {
-		if ts.appRegisterProbes[i].Name != itemToRemove.Name && ts.appRegisterProbes[i].Index != itemToRemove.Index {
+		if !isSameAppRegister(ts.appRegisterProbes[i], itemToRemove) {
 			newAppRegisterProbes = append(newAppRegisterProbes, ts.appRegisterProbes[i])
 		}
 	}
 
 	ts.appRegisterProbes = newAppRegisterProbes
 }
+
+// Validates the index range and the aggregation of a register probe
+func (ts *TrafficSelector) validateAppRegisterRange(appRegister *model.AppRegister) error {
+	switch appRegister.Aggregation {
+	case "", model.AGGREGATION_SUM, model.AGGREGATION_MAX, model.AGGREGATION_MIN:
+	default:
+		return fmt.Errorf("unknown aggregation %s. Use sum, max or min", appRegister.Aggregation)
+	}
+	if appRegister.Aggregation != "" && !appRegister.All && appRegister.IndexEnd == nil {
+		return fmt.Errorf("aggregation requires an index range or the whole register array")
+	}
+	tblSize := ts.driver.GetTableSizeByName(appRegister.Name)
+	if appRegister.All {
+		if appRegister.IndexEnd != nil {
+			return fmt.Errorf("either an index range or the whole register array can be read")
+		}
+		return nil
+	}
+	lastIndex := appRegister.Index
+	if appRegister.IndexEnd != nil {
+		if *appRegister.IndexEnd < appRegister.Index {
+			return fmt.Errorf("invalid index range. indexEnd %d is smaller than index %d", *appRegister.IndexEnd, appRegister.Index)
+		}
+		lastIndex = *appRegister.IndexEnd
+	}
+	if tblSize > 0 && lastIndex >= tblSize {
+		return fmt.Errorf("index %d is out of range. %s has a size of %d", lastIndex, appRegister.Name, tblSize)
+	}
+
+	return nil
+}
+
+func isSameAppRegister(a *model.AppRegister, b *model.AppRegister) bool {
+	if a.Name != b.Name || a.Index != b.Index || a.All != b.All || a.Aggregation != b.Aggregation {
+		return false
+	}
+	if a.IndexEnd == nil || b.IndexEnd == nil {
+		return a.IndexEnd == b.IndexEnd
+	}
+	return *a.IndexEnd == *b.IndexEnd
+}
@@ -0,0 +1,22 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package trafficselector
+
+import "github.com/thushjandan/pifina/pkg/model"
+
+// Returns the queue depth threshold of the microburst probe from the dataplane
+func (ts *TrafficSelector) GetBurstThreshold() (*model.BurstThreshold, error) {
+	threshold, err := ts.driver.GetBurstThreshold()
+	if err != nil {
+		return nil, err
+	}
+	return &model.BurstThreshold{Threshold: threshold}, nil
+}
+
+// Changes the queue depth threshold of the microburst probe at runtime
+func (ts *TrafficSelector) SetBurstThreshold(newThreshold *model.BurstThreshold) error {
+	return ts.driver.SetBurstThreshold(newThreshold.Threshold)
+}
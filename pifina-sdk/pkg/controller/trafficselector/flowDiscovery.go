@@ -0,0 +1,50 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package trafficselector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thushjandan/pifina/pkg/controller/dataplane/tofino/driver"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Proposes a selector expression for the default selector table, which matches a discovered flow.
+// A field of the digest refers to the key of the selector table with the same header field. e.g. ipv4_src_addr => hdr.ipv4.src_addr
+// Keys of the selector table, which are not part of the flow key, are wildcards.
+func (t *TrafficSelector) ProposeSelectorExpression(flowKeys []*model.DiscoveredFlowKey) (string, error) {
+	schema, err := t.GetTrafficSelectorSchema("")
+	if err != nil {
+		return "", err
+	}
+
+	terms := make([]string, 0, len(flowKeys))
+	for _, flowKey := range flowKeys {
+		for _, field := range schema {
+			if field.Name == driver.MATCH_PRIORITY_KEY_NAME {
+				continue
+			}
+			// Digest field name without the parameter name of the header struct
+			pathElements := strings.Split(field.Name, ".")
+			if strings.Join(pathElements[1:], "_") == flowKey.Name {
+				terms = append(terms, fmt.Sprintf("%s=%s", field.Name, flowKey.Value))
+				break
+			}
+		}
+	}
+	if len(terms) == 0 {
+		return "", fmt.Errorf("none of the fields of the flow key is a key of the default selector table")
+	}
+
+	expression := strings.Join(terms, " ")
+	// Exact keys of the selector table, which are not part of the flow key, cannot be compiled
+	if _, _, err := CompileSelectorExpression(expression, schema); err != nil {
+		return "", err
+	}
+
+	return expression, nil
+}
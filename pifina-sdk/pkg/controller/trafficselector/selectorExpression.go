@@ -0,0 +1,411 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package trafficselector
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/thushjandan/pifina/pkg/controller/dataplane/tofino/driver"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Human-friendly selector expression like "proto=udp dst=10.1.0.0/16 dport=4791".
+// Every term has the form <field>=<value>. A field can be referenced by its full name, by a suffix of its name like dst_addr or ipv4.dst_addr or by an alias.
+// Following values are supported:
+//   - Numbers in decimal or hex (0x) notation, IPv4, IPv6 and MAC addresses and protocol names
+//   - <value>/<prefix length> for ternary, lpm and range keys
+//   - <value>&&&<mask> for ternary keys
+//   - <low>-<high> for range keys
+//
+// Fields, which are not part of the expression, are wildcards. The term priority=<n> sets the match priority.
+const (
+	SELECTOR_EXPRESSION_PRIORITY  = "priority"
+	SELECTOR_EXPRESSION_MASK_SEP  = "&&&"
+	SELECTOR_EXPRESSION_RANGE_SEP = "-"
+)
+
+var (
+	// Alias => possible last elements of the field name
+	selectorFieldAliases = map[string][]string{
+		"proto":     {"protocol", "next_hdr", "ip_proto"},
+		"src":       {"src_addr", "srcAddr", "src_ip"},
+		"dst":       {"dst_addr", "dstAddr", "dst_ip"},
+		"sport":     {"src_port", "srcPort"},
+		"dport":     {"dst_port", "dstPort"},
+		"ethertype": {"ether_type", "etherType"},
+	}
+	selectorProtocolNames  = map[string]uint64{"icmp": 1, "igmp": 2, "tcp": 6, "udp": 17, "gre": 47, "esp": 50, "icmpv6": 58, "sctp": 132}
+	selectorEtherTypeNames = map[string]uint64{"ipv4": 0x0800, "arp": 0x0806, "vlan": 0x8100, "ipv6": 0x86dd, "mpls": 0x8847, "roce": 0x8915}
+)
+
+// Compiles a selector expression into the keys of a selector table given its key schema.
+// Returns the keys and the match priority, which is nil if not defined in the expression.
+func CompileSelectorExpression(expression string, schema []*model.MatchSelectorSchema) ([]*model.MatchSelectorKey, *uint32, error) {
+	var priority *uint32
+	values := make(map[uint32]string)
+	for _, term := range strings.Fields(expression) {
+		name, value, found := strings.Cut(term, "=")
+		if !found || name == "" || value == "" {
+			return nil, nil, fmt.Errorf("invalid term '%s'. Use <field>=<value>", term)
+		}
+		if name == SELECTOR_EXPRESSION_PRIORITY {
+			parsedPriority, err := strconv.ParseUint(value, 0, 32)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid priority '%s'", value)
+			}
+			p := uint32(parsedPriority)
+			priority = &p
+			continue
+		}
+		field, err := resolveSelectorField(name, schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := values[field.FieldId]; ok {
+			return nil, nil, fmt.Errorf("field %s has been defined more than once", field.Name)
+		}
+		values[field.FieldId] = value
+	}
+
+	keys := make([]*model.MatchSelectorKey, 0, len(schema))
+	for _, field := range schema {
+		// Match priority is not a header field
+		if field.Name == driver.MATCH_PRIORITY_KEY_NAME {
+			continue
+		}
+		value, ok := values[field.FieldId]
+		key, err := compileSelectorKey(field, value, ok)
+		if err != nil {
+			return nil, nil, err
+		}
+		if key != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, priority, nil
+}
+
+// Renders the keys of a selector rule as selector expression. Wildcard keys are omitted.
+func RenderSelectorExpression(entry *model.MatchSelectorEntry, schema []*model.MatchSelectorSchema) string {
+	terms := make([]string, 0, len(entry.Keys)+1)
+	for _, key := range entry.Keys {
+		var field *model.MatchSelectorSchema
+		for i := range schema {
+			if schema[i].FieldId == key.FieldId {
+				field = schema[i]
+				break
+			}
+		}
+		if field == nil || field.Name == driver.MATCH_PRIORITY_KEY_NAME {
+			continue
+		}
+		value := renderSelectorKey(field, key)
+		if value == "" {
+			continue
+		}
+		terms = append(terms, fmt.Sprintf("%s=%s", shortSelectorFieldName(field, schema), value))
+	}
+	if entry.Priority != nil {
+		terms = append(terms, fmt.Sprintf("%s=%d", SELECTOR_EXPRESSION_PRIORITY, *entry.Priority))
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// Finds a field of the schema by its full name, a suffix of its name or an alias
+func resolveSelectorField(name string, schema []*model.MatchSelectorSchema) (*model.MatchSelectorSchema, error) {
+	for i := range schema {
+		if schema[i].Name == name {
+			return schema[i], nil
+		}
+	}
+	candidates := []string{name}
+	candidates = append(candidates, selectorFieldAliases[name]...)
+	for _, candidate := range candidates {
+		matches := make([]*model.MatchSelectorSchema, 0)
+		for i := range schema {
+			if strings.HasSuffix(schema[i].Name, "."+candidate) {
+				matches = append(matches, schema[i])
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0], nil
+		}
+		if len(matches) > 1 {
+			names := make([]string, 0, len(matches))
+			for i := range matches {
+				names = append(names, matches[i].Name)
+			}
+			return nil, fmt.Errorf("field %s is ambiguous. Use one of %s", name, strings.Join(names, ", "))
+		}
+	}
+
+	return nil, fmt.Errorf("field %s does not exist in the selector table", name)
+}
+
+// Returns the shortest suffix of the field name, which is unique within the schema.
+func shortSelectorFieldName(field *model.MatchSelectorSchema, schema []*model.MatchSelectorSchema) string {
+	elements := strings.Split(field.Name, ".")
+	for i := len(elements) - 1; i > 0; i-- {
+		suffix := strings.Join(elements[i:], ".")
+		unique := true
+		for j := range schema {
+			if schema[j] != field && strings.HasSuffix(schema[j].Name, "."+suffix) {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return suffix
+		}
+	}
+	return field.Name
+}
+
+// Compiles the value of a single field to a key depending on its match type.
+// A field without value is compiled to a wildcard.
+func compileSelectorKey(field *model.MatchSelectorSchema, value string, hasValue bool) (*model.MatchSelectorKey, error) {
+	width := uint(field.Width)
+	key := &model.MatchSelectorKey{FieldId: field.FieldId, MatchType: field.MatchType}
+	if !hasValue {
+		switch field.MatchType {
+		case model.MATCH_TYPE_EXACT:
+			return nil, fmt.Errorf("field %s is an exact key and needs a value", field.Name)
+		case model.MATCH_TYPE_TERNARY:
+			key.Value = encodeSelectorValue(big.NewInt(0), width)
+			key.ValueMask = encodeSelectorValue(big.NewInt(0), width)
+		case model.MATCH_TYPE_LPM:
+			key.Value = encodeSelectorValue(big.NewInt(0), width)
+			key.PrefixLength = 0
+		case model.MATCH_TYPE_RANGE:
+			key.RangeLow = encodeSelectorValue(big.NewInt(0), width)
+			key.RangeHigh = encodeSelectorValue(maxSelectorValue(width), width)
+		case model.MATCH_TYPE_OPTIONAL:
+			key.Value = encodeSelectorValue(big.NewInt(0), width)
+			key.IsValid = false
+		}
+		return key, nil
+	}
+
+	// <low>-<high>
+	if field.MatchType == model.MATCH_TYPE_RANGE {
+		if low, high, found := strings.Cut(value, SELECTOR_EXPRESSION_RANGE_SEP); found {
+			lowValue, err := parseSelectorValue(field, low)
+			if err != nil {
+				return nil, err
+			}
+			highValue, err := parseSelectorValue(field, high)
+			if err != nil {
+				return nil, err
+			}
+			if lowValue.Cmp(highValue) > 0 {
+				return nil, fmt.Errorf("invalid range %s for field %s. Lower bound is bigger than upper bound", value, field.Name)
+			}
+			key.RangeLow = encodeSelectorValue(lowValue, width)
+			key.RangeHigh = encodeSelectorValue(highValue, width)
+			return key, nil
+		}
+	}
+
+	// <value>&&&<mask>
+	if rawValue, rawMask, found := strings.Cut(value, SELECTOR_EXPRESSION_MASK_SEP); found {
+		if field.MatchType != model.MATCH_TYPE_TERNARY {
+			return nil, fmt.Errorf("a mask can only be used for ternary keys. Field %s is a %s key", field.Name, field.MatchType)
+		}
+		parsedValue, err := parseSelectorValue(field, rawValue)
+		if err != nil {
+			return nil, err
+		}
+		mask, err := parseSelectorValue(field, rawMask)
+		if err != nil {
+			return nil, err
+		}
+		key.Value = encodeSelectorValue(new(big.Int).And(parsedValue, mask), width)
+		key.ValueMask = encodeSelectorValue(mask, width)
+		return key, nil
+	}
+
+	// <value>/<prefix length>
+	prefixLength := width
+	if rawValue, rawPrefix, found := strings.Cut(value, "/"); found {
+		parsedPrefix, err := strconv.ParseUint(rawPrefix, 10, 32)
+		if err != nil || uint(parsedPrefix) > width {
+			return nil, fmt.Errorf("invalid prefix length %s for field %s with %d bits", rawPrefix, field.Name, width)
+		}
+		prefixLength = uint(parsedPrefix)
+		value = rawValue
+	}
+	parsedValue, err := parseSelectorValue(field, value)
+	if err != nil {
+		return nil, err
+	}
+	mask := prefixSelectorMask(prefixLength, width)
+	parsedValue.And(parsedValue, mask)
+
+	switch field.MatchType {
+	case model.MATCH_TYPE_EXACT:
+		if prefixLength != width {
+			return nil, fmt.Errorf("field %s is an exact key and cannot have a prefix", field.Name)
+		}
+		key.Value = encodeSelectorValue(parsedValue, width)
+	case model.MATCH_TYPE_TERNARY:
+		key.Value = encodeSelectorValue(parsedValue, width)
+		key.ValueMask = encodeSelectorValue(mask, width)
+	case model.MATCH_TYPE_LPM:
+		key.Value = encodeSelectorValue(parsedValue, width)
+		key.PrefixLength = int32(prefixLength)
+	case model.MATCH_TYPE_RANGE:
+		// A prefix covers all values with the same leading bits
+		key.RangeLow = encodeSelectorValue(parsedValue, width)
+		key.RangeHigh = encodeSelectorValue(new(big.Int).Or(parsedValue, new(big.Int).Xor(mask, maxSelectorValue(width))), width)
+	case model.MATCH_TYPE_OPTIONAL:
+		if prefixLength != width {
+			return nil, fmt.Errorf("field %s is an optional key and cannot have a prefix", field.Name)
+		}
+		key.Value = encodeSelectorValue(parsedValue, width)
+		key.IsValid = true
+	default:
+		return nil, fmt.Errorf("match type %s of field %s is not supported", field.MatchType, field.Name)
+	}
+
+	return key, nil
+}
+
+// Parses a single value of a field. Supports numbers, IP and MAC addresses and protocol names.
+func parseSelectorValue(field *model.MatchSelectorSchema, value string) (*big.Int, error) {
+	width := uint(field.Width)
+	parsedValue := new(big.Int)
+	lowerValue := strings.ToLower(value)
+	if number, ok := selectorProtocolNames[lowerValue]; ok && isSelectorField(field, "proto") {
+		parsedValue.SetUint64(number)
+	} else if number, ok := selectorEtherTypeNames[lowerValue]; ok && isSelectorField(field, "ethertype") {
+		parsedValue.SetUint64(number)
+	} else if mac, err := net.ParseMAC(value); err == nil && width == 48 {
+		parsedValue.SetBytes(mac)
+	} else if ip := net.ParseIP(value); ip != nil && width == 32 && ip.To4() != nil {
+		parsedValue.SetBytes(ip.To4())
+	} else if ip != nil && width == 128 {
+		parsedValue.SetBytes(ip.To16())
+	} else if _, ok := parsedValue.SetString(lowerValue, 0); !ok {
+		return nil, fmt.Errorf("cannot parse value '%s' of field %s", value, field.Name)
+	}
+
+	if parsedValue.Sign() < 0 || parsedValue.BitLen() > int(width) {
+		return nil, fmt.Errorf("value '%s' does not fit into field %s with %d bits", value, field.Name, width)
+	}
+
+	return parsedValue, nil
+}
+
+// Renders the value of a key. Returns an empty string for wildcards.
+func renderSelectorKey(field *model.MatchSelectorSchema, key *model.MatchSelectorKey) string {
+	width := uint(field.Width)
+	switch key.MatchType {
+	case model.MATCH_TYPE_EXACT:
+		return formatSelectorValue(field, new(big.Int).SetBytes(key.Value))
+	case model.MATCH_TYPE_TERNARY:
+		mask := new(big.Int).SetBytes(key.ValueMask)
+		value := new(big.Int).SetBytes(key.Value)
+		if mask.Sign() == 0 {
+			return ""
+		}
+		for prefixLength := width; prefixLength > 0; prefixLength-- {
+			if mask.Cmp(prefixSelectorMask(prefixLength, width)) == 0 {
+				if prefixLength == width {
+					return formatSelectorValue(field, value)
+				}
+				return fmt.Sprintf("%s/%d", formatSelectorValue(field, value), prefixLength)
+			}
+		}
+		return fmt.Sprintf("%#x%s%#x", value, SELECTOR_EXPRESSION_MASK_SEP, mask)
+	case model.MATCH_TYPE_LPM:
+		if key.PrefixLength == 0 {
+			return ""
+		}
+		value := formatSelectorValue(field, new(big.Int).SetBytes(key.Value))
+		if uint(key.PrefixLength) == width {
+			return value
+		}
+		return fmt.Sprintf("%s/%d", value, key.PrefixLength)
+	case model.MATCH_TYPE_RANGE:
+		low := new(big.Int).SetBytes(key.RangeLow)
+		high := new(big.Int).SetBytes(key.RangeHigh)
+		if low.Sign() == 0 && high.Cmp(maxSelectorValue(width)) == 0 {
+			return ""
+		}
+		if low.Cmp(high) == 0 {
+			return formatSelectorValue(field, low)
+		}
+		return fmt.Sprintf("%s%s%s", formatSelectorValue(field, low), SELECTOR_EXPRESSION_RANGE_SEP, formatSelectorValue(field, high))
+	case model.MATCH_TYPE_OPTIONAL:
+		if !key.IsValid {
+			return ""
+		}
+		return formatSelectorValue(field, new(big.Int).SetBytes(key.Value))
+	}
+
+	return ""
+}
+
+// Formats a value depending on the field. Addresses are formatted in their usual notation.
+func formatSelectorValue(field *model.MatchSelectorSchema, value *big.Int) string {
+	width := uint(field.Width)
+	if isSelectorField(field, "proto") {
+		for name, number := range selectorProtocolNames {
+			if value.IsUint64() && value.Uint64() == number {
+				return name
+			}
+		}
+	}
+	if isSelectorField(field, "ethertype") {
+		for name, number := range selectorEtherTypeNames {
+			if value.IsUint64() && value.Uint64() == number {
+				return name
+			}
+		}
+		return fmt.Sprintf("%#04x", value)
+	}
+	switch {
+	case width == 32 && strings.Contains(field.Name, "addr"):
+		return net.IP(encodeSelectorValue(value, width)).String()
+	case width == 128 && strings.Contains(field.Name, "addr"):
+		return net.IP(encodeSelectorValue(value, width)).String()
+	case width == 48:
+		return net.HardwareAddr(encodeSelectorValue(value, width)).String()
+	}
+	return value.String()
+}
+
+// Checks if the last element of the field name is one of the names of the alias
+func isSelectorField(field *model.MatchSelectorSchema, alias string) bool {
+	for _, name := range selectorFieldAliases[alias] {
+		if strings.HasSuffix(field.Name, "."+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask with the leading prefixLength bits of a field with the given width set
+func prefixSelectorMask(prefixLength uint, width uint) *big.Int {
+	mask := new(big.Int).Lsh(big.NewInt(1), prefixLength)
+	mask.Sub(mask, big.NewInt(1))
+	return mask.Lsh(mask, width-prefixLength)
+}
+
+func maxSelectorValue(width uint) *big.Int {
+	return prefixSelectorMask(width, width)
+}
+
+// Encodes a value as big endian with the minimal amount of bytes for the given width
+func encodeSelectorValue(value *big.Int, width uint) []byte {
+	return value.FillBytes(make([]byte, (width+7)/8))
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package trafficselector
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+var testSelectorSchema = []*model.MatchSelectorSchema{
+	{FieldId: 1, Name: "hdr.ipv4.protocol", MatchType: model.MATCH_TYPE_EXACT, Type: "bytes", Width: 8},
+	{FieldId: 2, Name: "hdr.ipv4.src_addr", MatchType: model.MATCH_TYPE_TERNARY, Type: "bytes", Width: 32},
+	{FieldId: 3, Name: "hdr.ipv4.dst_addr", MatchType: model.MATCH_TYPE_LPM, Type: "bytes", Width: 32},
+	{FieldId: 4, Name: "hdr.udp.dst_port", MatchType: model.MATCH_TYPE_RANGE, Type: "bytes", Width: 16},
+	{FieldId: 5, Name: "$MATCH_PRIORITY", MatchType: model.MATCH_TYPE_EXACT, Type: "uint32", Width: 32},
+}
+
+func TestCompileSelectorExpression(t *testing.T) {
+	keys, priority, err := CompileSelectorExpression("proto=udp src=192.168.1.0/24 dst=10.1.0.0/16 dport=4791 priority=2", testSelectorSchema)
+	if err != nil {
+		t.Fatal("Cannot compile expression", err)
+	}
+	if priority == nil || *priority != 2 {
+		t.Fatal("Wrong priority. Expected 2", priority)
+	}
+	if len(keys) != 4 {
+		t.Fatal("Wrong number of keys. Expected 4", len(keys))
+	}
+	if !bytes.Equal(keys[0].Value, []byte{17}) {
+		t.Fatal("Wrong protocol value", keys[0].Value)
+	}
+	if !bytes.Equal(keys[1].Value, []byte{192, 168, 1, 0}) || !bytes.Equal(keys[1].ValueMask, []byte{255, 255, 255, 0}) {
+		t.Fatal("Wrong ternary key", keys[1].Value, keys[1].ValueMask)
+	}
+	if !bytes.Equal(keys[2].Value, []byte{10, 1, 0, 0}) || keys[2].PrefixLength != 16 {
+		t.Fatal("Wrong lpm key", keys[2].Value, keys[2].PrefixLength)
+	}
+	if !bytes.Equal(keys[3].RangeLow, []byte{0x12, 0xb7}) || !bytes.Equal(keys[3].RangeHigh, []byte{0x12, 0xb7}) {
+		t.Fatal("Wrong range key", keys[3].RangeLow, keys[3].RangeHigh)
+	}
+}
+
+func TestCompileSelectorExpressionWildcards(t *testing.T) {
+	keys, priority, err := CompileSelectorExpression("protocol=6", testSelectorSchema)
+	if err != nil {
+		t.Fatal("Cannot compile expression", err)
+	}
+	if priority != nil {
+		t.Fatal("Priority should not be set", *priority)
+	}
+	if !bytes.Equal(keys[1].ValueMask, []byte{0, 0, 0, 0}) || keys[2].PrefixLength != 0 {
+		t.Fatal("Fields without value should be wildcards")
+	}
+	if !bytes.Equal(keys[3].RangeLow, []byte{0, 0}) || !bytes.Equal(keys[3].RangeHigh, []byte{0xff, 0xff}) {
+		t.Fatal("Wrong wildcard range", keys[3].RangeLow, keys[3].RangeHigh)
+	}
+}
+
+func TestCompileSelectorExpressionErrors(t *testing.T) {
+	invalidExpressions := []string{
+		"dst=10.1.0.0/16",              // exact key proto is missing
+		"proto=udp dst",                // term without value
+		"proto=udp vlan=10",            // unknown field
+		"proto=300",                    // does not fit into 8 bits
+		"proto=udp dst=10.1.0.0/33",    // invalid prefix length
+		"proto=udp dport=5000-4000",    // invalid range
+		"proto=udp proto=tcp",          // defined twice
+		"proto=udp dst=1.1.1.1&&&0xff", // mask on lpm key
+	}
+	for _, expression := range invalidExpressions {
+		if _, _, err := CompileSelectorExpression(expression, testSelectorSchema); err == nil {
+			t.Fatal("Expected an error for expression", expression)
+		}
+	}
+}
+
+func TestRenderSelectorExpression(t *testing.T) {
+	expressions := []string{
+		"protocol=udp src_addr=192.168.1.0/24 dst_addr=10.1.0.0/16 dst_port=4791 priority=2",
+		"protocol=tcp dst_port=1000-2000",
+		"protocol=99 src_addr=0xa000001&&&0xff0000ff",
+	}
+	for _, expression := range expressions {
+		keys, priority, err := CompileSelectorExpression(expression, testSelectorSchema)
+		if err != nil {
+			t.Fatal("Cannot compile expression", expression, err)
+		}
+		rendered := RenderSelectorExpression(&model.MatchSelectorEntry{Keys: keys, Priority: priority}, testSelectorSchema)
+		if rendered != expression {
+			t.Fatalf("Wrong rendering. Expected '%s', got '%s'", expression, rendered)
+		}
+	}
+}
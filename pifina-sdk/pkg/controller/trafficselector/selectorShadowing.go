@@ -0,0 +1,143 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package trafficselector
+
+import (
+	"bytes"
+
+	"github.com/thushjandan/pifina/pkg/controller/dataplane/tofino/driver"
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+// Returns all existing selector rules in the same table, which match every packet of the given rule and take precedence.
+// Such a rule shadows the given rule, as a packet is only measured by the first matching rule.
+// Rules with the same priority are returned as well, as the order between them is undefined.
+func (t *TrafficSelector) FindShadowingRules(entry *model.MatchSelectorEntry) []*model.MatchSelectorEntry {
+	shadowingRules := make([]*model.MatchSelectorEntry, 0)
+	for _, existingEntry := range t.GetTrafficSelectorCache() {
+		// Skip the rule itself, if an existing rule will be modified
+		if entry.SessionId != 0 && existingEntry.SessionId == entry.SessionId {
+			continue
+		}
+		if normalizeSelectorTableName(existingEntry.Table) != normalizeSelectorTableName(entry.Table) {
+			continue
+		}
+		// Lower value has a higher priority
+		if getMatchPriority(existingEntry) > getMatchPriority(entry) {
+			continue
+		}
+		if selectorKeysCover(existingEntry.Keys, entry.Keys) {
+			shadowingRules = append(shadowingRules, existingEntry)
+		}
+	}
+
+	return shadowingRules
+}
+
+// An empty table name refers to the default selector table
+func normalizeSelectorTableName(tblName string) string {
+	if tblName == "" {
+		return driver.PROBE_INGRESS_MATCH_CNT
+	}
+	return tblName
+}
+
+// BfRt uses 0 as priority, if not defined
+func getMatchPriority(entry *model.MatchSelectorEntry) uint32 {
+	if entry.Priority == nil {
+		return 0
+	}
+	return *entry.Priority
+}
+
+// Checks if the keys of the existing rule match every packet matched by the keys of the new rule.
+func selectorKeysCover(existingKeys []*model.MatchSelectorKey, newKeys []*model.MatchSelectorKey) bool {
+	for _, existingKey := range existingKeys {
+		var newKey *model.MatchSelectorKey
+		for i := range newKeys {
+			if newKeys[i].FieldId == existingKey.FieldId {
+				newKey = newKeys[i]
+				break
+			}
+		}
+		if !selectorKeyCovers(existingKey, newKey) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Checks if a single key of an existing rule matches every value matched by the key of the new rule.
+// A missing key of the new rule is a wildcard.
+func selectorKeyCovers(existingKey *model.MatchSelectorKey, newKey *model.MatchSelectorKey) bool {
+	if newKey == nil {
+		return isWildcardKey(existingKey)
+	}
+	if existingKey.MatchType != newKey.MatchType {
+		return false
+	}
+
+	switch existingKey.MatchType {
+	case model.MATCH_TYPE_EXACT:
+		return model.EqualBytes(existingKey.Value, newKey.Value)
+	case model.MATCH_TYPE_TERNARY:
+		width := maxLen(existingKey.Value, existingKey.ValueMask, newKey.Value, newKey.ValueMask)
+		existingValue, existingMask := model.PadBytes(existingKey.Value, width), model.PadBytes(existingKey.ValueMask, width)
+		newValue, newMask := model.PadBytes(newKey.Value, width), model.PadBytes(newKey.ValueMask, width)
+		for i := 0; i < width; i++ {
+			// Every bit of the existing rule, which is not a wildcard, needs to be fixed to the same value in the new rule
+			if existingMask[i]&^newMask[i] != 0 || (existingValue[i]^newValue[i])&existingMask[i] != 0 {
+				return false
+			}
+		}
+		return true
+	case model.MATCH_TYPE_LPM:
+		if existingKey.PrefixLength > newKey.PrefixLength {
+			return false
+		}
+		width := maxLen(existingKey.Value, newKey.Value)
+		existingValue, newValue := model.PadBytes(existingKey.Value, width), model.PadBytes(newKey.Value, width)
+		for bit := 0; bit < int(existingKey.PrefixLength) && bit < width*8; bit++ {
+			mask := byte(0x80) >> (bit % 8)
+			if existingValue[bit/8]&mask != newValue[bit/8]&mask {
+				return false
+			}
+		}
+		return true
+	case model.MATCH_TYPE_RANGE:
+		width := maxLen(existingKey.RangeLow, existingKey.RangeHigh, newKey.RangeLow, newKey.RangeHigh)
+		return bytes.Compare(model.PadBytes(existingKey.RangeLow, width), model.PadBytes(newKey.RangeLow, width)) <= 0 &&
+			bytes.Compare(model.PadBytes(newKey.RangeHigh, width), model.PadBytes(existingKey.RangeHigh, width)) <= 0
+	case model.MATCH_TYPE_OPTIONAL:
+		return !existingKey.IsValid || (newKey.IsValid && model.EqualBytes(existingKey.Value, newKey.Value))
+	}
+
+	return false
+}
+
+// Checks if the key matches any value
+func isWildcardKey(key *model.MatchSelectorKey) bool {
+	switch key.MatchType {
+	case model.MATCH_TYPE_TERNARY:
+		return len(bytes.Trim(key.ValueMask, "\x00")) == 0
+	case model.MATCH_TYPE_LPM:
+		return key.PrefixLength == 0
+	case model.MATCH_TYPE_OPTIONAL:
+		return !key.IsValid
+	}
+	return false
+}
+
+func maxLen(values ...[]byte) int {
+	width := 0
+	for i := range values {
+		if len(values[i]) > width {
+			width = len(values[i])
+		}
+	}
+	return width
+}
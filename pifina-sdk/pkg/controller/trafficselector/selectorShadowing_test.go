@@ -0,0 +1,192 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package trafficselector
+
+import (
+	"testing"
+
+	"github.com/thushjandan/pifina/pkg/model"
+)
+
+func TestSelectorKeyCovers(t *testing.T) {
+	tests := []struct {
+		name        string
+		existingKey *model.MatchSelectorKey
+		newKey      *model.MatchSelectorKey
+		covers      bool
+	}{
+		{
+			name:        "exact same value",
+			existingKey: &model.MatchSelectorKey{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{17}},
+			newKey:      &model.MatchSelectorKey{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{0, 17}},
+			covers:      true,
+		},
+		{
+			name:        "exact different value",
+			existingKey: &model.MatchSelectorKey{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{17}},
+			newKey:      &model.MatchSelectorKey{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{6}},
+			covers:      false,
+		},
+		{
+			name:        "exact missing key",
+			existingKey: &model.MatchSelectorKey{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{17}},
+			covers:      false,
+		},
+		{
+			name:        "lpm shorter prefix",
+			existingKey: &model.MatchSelectorKey{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 0, 0}, PrefixLength: 16},
+			newKey:      &model.MatchSelectorKey{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 2, 0}, PrefixLength: 24},
+			covers:      true,
+		},
+		{
+			name:        "lpm longer prefix",
+			existingKey: &model.MatchSelectorKey{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 2, 0}, PrefixLength: 24},
+			newKey:      &model.MatchSelectorKey{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 0, 0}, PrefixLength: 16},
+			covers:      false,
+		},
+		{
+			name:        "lpm different prefix",
+			existingKey: &model.MatchSelectorKey{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 0, 0}, PrefixLength: 16},
+			newKey:      &model.MatchSelectorKey{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 2, 0, 0}, PrefixLength: 24},
+			covers:      false,
+		},
+		{
+			name:        "lpm wildcard",
+			existingKey: &model.MatchSelectorKey{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{0, 0, 0, 0}, PrefixLength: 0},
+			covers:      true,
+		},
+		{
+			name:        "ternary wider mask",
+			existingKey: &model.MatchSelectorKey{FieldId: 2, MatchType: model.MATCH_TYPE_TERNARY, Value: []byte{192, 168, 0, 0}, ValueMask: []byte{255, 255, 0, 0}},
+			newKey:      &model.MatchSelectorKey{FieldId: 2, MatchType: model.MATCH_TYPE_TERNARY, Value: []byte{192, 168, 1, 0}, ValueMask: []byte{255, 255, 255, 0}},
+			covers:      true,
+		},
+		{
+			name:        "ternary narrower mask",
+			existingKey: &model.MatchSelectorKey{FieldId: 2, MatchType: model.MATCH_TYPE_TERNARY, Value: []byte{192, 168, 1, 0}, ValueMask: []byte{255, 255, 255, 0}},
+			newKey:      &model.MatchSelectorKey{FieldId: 2, MatchType: model.MATCH_TYPE_TERNARY, Value: []byte{192, 168, 0, 0}, ValueMask: []byte{255, 255, 0, 0}},
+			covers:      false,
+		},
+		{
+			name:        "ternary different value",
+			existingKey: &model.MatchSelectorKey{FieldId: 2, MatchType: model.MATCH_TYPE_TERNARY, Value: []byte{192, 168, 0, 0}, ValueMask: []byte{255, 255, 0, 0}},
+			newKey:      &model.MatchSelectorKey{FieldId: 2, MatchType: model.MATCH_TYPE_TERNARY, Value: []byte{192, 169, 1, 0}, ValueMask: []byte{255, 255, 255, 0}},
+			covers:      false,
+		},
+		{
+			name:        "ternary wildcard",
+			existingKey: &model.MatchSelectorKey{FieldId: 2, MatchType: model.MATCH_TYPE_TERNARY, Value: []byte{0, 0, 0, 0}, ValueMask: []byte{0, 0, 0, 0}},
+			covers:      true,
+		},
+		{
+			name:        "range inside",
+			existingKey: &model.MatchSelectorKey{FieldId: 4, MatchType: model.MATCH_TYPE_RANGE, RangeLow: []byte{0x10, 0x00}, RangeHigh: []byte{0x20, 0x00}},
+			newKey:      &model.MatchSelectorKey{FieldId: 4, MatchType: model.MATCH_TYPE_RANGE, RangeLow: []byte{0x12, 0xb7}, RangeHigh: []byte{0x12, 0xb7}},
+			covers:      true,
+		},
+		{
+			name:        "range shorter encoding",
+			existingKey: &model.MatchSelectorKey{FieldId: 4, MatchType: model.MATCH_TYPE_RANGE, RangeLow: []byte{0x00}, RangeHigh: []byte{0xff, 0xff}},
+			newKey:      &model.MatchSelectorKey{FieldId: 4, MatchType: model.MATCH_TYPE_RANGE, RangeLow: []byte{0x50}, RangeHigh: []byte{0x01, 0x00}},
+			covers:      true,
+		},
+		{
+			name:        "range overlapping",
+			existingKey: &model.MatchSelectorKey{FieldId: 4, MatchType: model.MATCH_TYPE_RANGE, RangeLow: []byte{0x10, 0x00}, RangeHigh: []byte{0x20, 0x00}},
+			newKey:      &model.MatchSelectorKey{FieldId: 4, MatchType: model.MATCH_TYPE_RANGE, RangeLow: []byte{0x18, 0x00}, RangeHigh: []byte{0x28, 0x00}},
+			covers:      false,
+		},
+		{
+			name:        "different match type",
+			existingKey: &model.MatchSelectorKey{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{17}},
+			newKey:      &model.MatchSelectorKey{FieldId: 1, MatchType: model.MATCH_TYPE_TERNARY, Value: []byte{17}, ValueMask: []byte{0xff}},
+			covers:      false,
+		},
+	}
+
+	for _, test := range tests {
+		if covers := selectorKeyCovers(test.existingKey, test.newKey); covers != test.covers {
+			t.Errorf("%s: expected %v, got %v", test.name, test.covers, covers)
+		}
+	}
+}
+
+func TestFindShadowingRules(t *testing.T) {
+	priority := func(p uint32) *uint32 { return &p }
+	udpRule := &model.MatchSelectorEntry{
+		SessionId: 1,
+		Keys: []*model.MatchSelectorKey{
+			{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{17}},
+			{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 0, 0}, PrefixLength: 16},
+		},
+		Priority: priority(1),
+	}
+	ts := &TrafficSelector{matchSelectorEntryCache: []*model.MatchSelectorEntry{udpRule}}
+
+	tests := []struct {
+		name      string
+		entry     *model.MatchSelectorEntry
+		shadowing int
+	}{
+		{
+			name: "covered with lower priority",
+			entry: &model.MatchSelectorEntry{
+				Keys: []*model.MatchSelectorKey{
+					{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 2, 0}, PrefixLength: 24},
+					{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{17}},
+				},
+				Priority: priority(2),
+			},
+			shadowing: 1,
+		},
+		{
+			name: "covered with higher priority",
+			entry: &model.MatchSelectorEntry{
+				Keys: []*model.MatchSelectorKey{
+					{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{17}},
+					{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 2, 0}, PrefixLength: 24},
+				},
+				Priority: priority(0),
+			},
+			shadowing: 0,
+		},
+		{
+			name: "not covered",
+			entry: &model.MatchSelectorEntry{
+				Keys: []*model.MatchSelectorKey{
+					{FieldId: 1, MatchType: model.MATCH_TYPE_EXACT, Value: []byte{6}},
+					{FieldId: 3, MatchType: model.MATCH_TYPE_LPM, Value: []byte{10, 1, 2, 0}, PrefixLength: 24},
+				},
+				Priority: priority(2),
+			},
+			shadowing: 0,
+		},
+		{
+			name: "rule itself",
+			entry: &model.MatchSelectorEntry{
+				SessionId: 1,
+				Keys:      udpRule.Keys,
+				Priority:  priority(2),
+			},
+			shadowing: 0,
+		},
+		{
+			name: "other table",
+			entry: &model.MatchSelectorEntry{
+				Table:    "ROCE",
+				Keys:     udpRule.Keys,
+				Priority: priority(2),
+			},
+			shadowing: 0,
+		},
+	}
+
+	for _, test := range tests {
+		if shadowingRules := ts.FindShadowingRules(test.entry); len(shadowingRules) != test.shadowing {
+			t.Errorf("%s: expected %d shadowing rules, got %d", test.name, test.shadowing, len(shadowingRules))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package trafficselector
+
+// Returns the names of all match action tables of the user application
+func (ts *TrafficSelector) GetAllAvailableTables() []string {
+	return ts.driver.GetAllMatchActionTableNames()
+}
+
+// Adds a match action table, whose occupancy is monitored.
+func (ts *TrafficSelector) AddTableToMonitor(newItem string) error {
+	if err := ts.driver.ValidateMonitoredTable(newItem); err != nil {
+		return err
+	}
+
+	ts.monitoredTablesLock.Lock()
+	defer ts.monitoredTablesLock.Unlock()
+
+	for i := range ts.monitoredTables {
+		// if entry already exists, just ignore and exit already here.
+		if ts.monitoredTables[i] == newItem {
+			return nil
+		}
+	}
+
+	ts.monitoredTables = append(ts.monitoredTables, newItem)
+
+	return nil
+}
+
+// Returns the monitored tables. The selector tables are always monitored and come first.
+func (ts *TrafficSelector) GetMonitoredTables() []string {
+	ts.monitoredTablesLock.RLock()
+	defer ts.monitoredTablesLock.RUnlock()
+
+	copyMonitoredTables := ts.driver.GetSelectorTableNames()
+	for i := range ts.monitoredTables {
+		isSelectorTable := false
+		for j := range copyMonitoredTables {
+			if copyMonitoredTables[j] == ts.monitoredTables[i] {
+				isSelectorTable = true
+				break
+			}
+		}
+		if !isSelectorTable {
+			copyMonitoredTables = append(copyMonitoredTables, ts.monitoredTables[i])
+		}
+	}
+
+	return copyMonitoredTables
+}
+
+func (ts *TrafficSelector) RemoveTableToMonitor(itemToRemove string) {
+	ts.monitoredTablesLock.Lock()
+	defer ts.monitoredTablesLock.Unlock()
+
+	newTablesToMonitor := make([]string, 0)
+	for i := range ts.monitoredTables {
+		if ts.monitoredTables[i] != itemToRemove {
+			newTablesToMonitor = append(newTablesToMonitor, ts.monitoredTables[i])
+		}
+	}
+
+	ts.monitoredTables = newTablesToMonitor
+}
@@ -6,6 +6,7 @@
 package trafficselector
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 	"sync"
@@ -20,10 +21,16 @@ type TrafficSelector struct {
 	driver                  *driver.TofinoDriver
 	lpfTimeConst            float32
 	matchSelectorEntryCache []*model.MatchSelectorEntry
+	cacheLock               sync.RWMutex // Protects the reference to the cache of selector rules
+	selectorLock            sync.Mutex   // Serializes changes of selector rules and reloads of the cache. Rules are changed by the API, the schedule and the flow discovery.
 	appRegisterProbes       []*model.AppRegister
 	appRegisterProbesLock   sync.RWMutex
+	appObjectProbes         []*model.AppObject
+	appObjectProbesLock     sync.RWMutex
 	monitoredDevPorts       []string
 	monitoredDevPortsLock   sync.RWMutex
+	monitoredTables         []string
+	monitoredTablesLock     sync.RWMutex
 }
 
 func NewTrafficSelector(logger hclog.Logger, d *driver.TofinoDriver, lpfTimeConst float32) *TrafficSelector {
@@ -31,6 +38,7 @@ func NewTrafficSelector(logger hclog.Logger, d *driver.TofinoDriver, lpfTimeCons
 		logger:            logger.Named("traffic-sel"),
 		driver:            d,
 		appRegisterProbes: make([]*model.AppRegister, 0),
+		appObjectProbes:   make([]*model.AppObject, 0),
 		lpfTimeConst:      lpfTimeConst,
 	}
 }
@@ -38,18 +46,38 @@ func NewTrafficSelector(logger hclog.Logger, d *driver.TofinoDriver, lpfTimeCons
 // Add a new selector rule in the dataplane
 // It will generate a new sessionId for the rule.
 func (t *TrafficSelector) AddTrafficSelectorRule(newSelectorRule *model.MatchSelectorEntry) error {
+	t.selectorLock.Lock()
+	defer t.selectorLock.Unlock()
+
 	var randomSessionId uint32
+	// Use the default LPF parameter, if not defined
+	if newSelectorRule.LPF == nil {
+		newSelectorRule.LPF = t.defaultLPFConfig()
+	}
+	if err := validateLPFConfig(newSelectorRule.LPF); err != nil {
+		return err
+	}
 	sessionBitWidth, err := t.driver.GetSessionIdBitWidth()
 	if err != nil {
 		return err
 	}
 	sessionIdsMap := make(map[uint32]struct{})
-	for i := range t.matchSelectorEntryCache {
-		sessionIdsMap[t.matchSelectorEntryCache[i].SessionId] = struct{}{}
+	for _, entry := range t.getTrafficSelectorCache() {
+		sessionIdsMap[entry.SessionId] = struct{}{}
 	}
 
 	// Get the upperbound for a sessionId
 	max := int(math.Pow(2, float64(sessionBitWidth)))
+	// sessionId 0 is reserved. Without a free sessionId, the search below would never end.
+	usedSessionIds := 0
+	for sessionId := range sessionIdsMap {
+		if sessionId > 0 && int(sessionId) < max {
+			usedSessionIds++
+		}
+	}
+	if usedSessionIds >= max-1 {
+		return fmt.Errorf("cannot add selector rule. All %d sessionIds are in use. Remove an existing selector rule first", max-1)
+	}
 	// Find a unique sessionId
 	randomSessionId = uint32(rand.Intn(max-1) + 1)
 	// Check if new sessionId is unique
@@ -66,20 +94,150 @@ func (t *TrafficSelector) AddTrafficSelectorRule(newSelectorRule *model.MatchSel
 	if err != nil {
 		return err
 	}
-	t.logger.Info("A new entry has been added in the dataplane", "sessionId", newSelectorRule.SessionId)
+	t.logger.Info("A new entry has been added in the dataplane", "sessionId", newSelectorRule.SessionId, "table", newSelectorRule.Table)
 	// Configure LPF for new selector rule
-	err = t.driver.ConfigureLPF([]uint32{newSelectorRule.SessionId})
+	err = t.driver.ConfigureLPF([]uint32{newSelectorRule.SessionId}, newSelectorRule.LPF)
 	if err != nil {
 		t.logger.Error("Error occured during LPF configuration for new selector rule", "sessionId", newSelectorRule.SessionId, "err", err)
 	}
 
 	// Refresh match selector cache
-	err = t.LoadSessionsFromDevice()
+	err = t.loadSessionsFromDevice()
+	return err
+}
+
+// Add multiple selector rules in the dataplane with all-or-nothing semantics.
+// The sessionIds are allocated in ascending order after the highest sessionId in use.
+// If a rule cannot be added or the LPF cannot be configured, all rules of the batch are removed again.
+func (t *TrafficSelector) AddTrafficSelectorRules(newSelectorRules []*model.MatchSelectorEntry) error {
+	if len(newSelectorRules) == 0 {
+		return nil
+	}
+	t.selectorLock.Lock()
+	defer t.selectorLock.Unlock()
+
+	for i := range newSelectorRules {
+		// Use the default LPF parameter, if not defined
+		if newSelectorRules[i].LPF == nil {
+			newSelectorRules[i].LPF = t.defaultLPFConfig()
+		}
+		if err := validateLPFConfig(newSelectorRules[i].LPF); err != nil {
+			return fmt.Errorf("selector rule %d: %w", i, err)
+		}
+	}
+	sessionBitWidth, err := t.driver.GetSessionIdBitWidth()
+	if err != nil {
+		return err
+	}
+	sessionIds, err := t.allocateSessionIds(len(newSelectorRules), sessionBitWidth)
+	if err != nil {
+		return err
+	}
+	for i := range newSelectorRules {
+		newSelectorRules[i].SessionId = sessionIds[i]
+	}
+
+	// Create all rules in a single write request
+	err = t.driver.AddSelectorEntries(newSelectorRules)
+	if err != nil {
+		return err
+	}
+	t.logger.Info("A batch of new entries has been added in the dataplane", "sessionIds", sessionIds)
+
+	// Rules with the same LPF parameter are configured together
+	lpfGroups := make(map[model.LPFConfig][]uint32)
+	for i := range newSelectorRules {
+		lpfGroups[*newSelectorRules[i].LPF] = append(lpfGroups[*newSelectorRules[i].LPF], newSelectorRules[i].SessionId)
+	}
+	for lpf, lpfSessionIds := range lpfGroups {
+		lpf := lpf
+		err = t.driver.ConfigureLPF(lpfSessionIds, &lpf)
+		if err != nil {
+			t.logger.Error("Error occured during LPF configuration for new selector rules. Rolling back the batch", "sessionIds", lpfSessionIds, "err", err)
+			if rollbackErr := t.driver.RemoveSelectorEntries(newSelectorRules); rollbackErr != nil {
+				t.logger.Error("Rollback of the batch failed", "err", rollbackErr)
+			}
+			break
+		}
+	}
+
+	// Refresh match selector cache once for the whole batch
+	if refreshErr := t.loadSessionsFromDevice(); refreshErr != nil {
+		return refreshErr
+	}
+	return err
+}
+
+// Allocates unused sessionIds in ascending order. The search starts after the highest sessionId in use and wraps around,
+// so that recently removed sessionIds are reused as late as possible. sessionId 0 is reserved.
+// The caller needs to hold the selector lock.
+func (t *TrafficSelector) allocateSessionIds(count int, sessionBitWidth uint32) ([]uint32, error) {
+	maxSessionId := uint32(1<<sessionBitWidth) - 1
+	sessionIdsMap := make(map[uint32]struct{})
+	highestSessionId := uint32(0)
+	for _, entry := range t.getTrafficSelectorCache() {
+		sessionIdsMap[entry.SessionId] = struct{}{}
+		if entry.SessionId > highestSessionId {
+			highestSessionId = entry.SessionId
+		}
+	}
+	if count > int(maxSessionId)-len(sessionIdsMap) {
+		return nil, fmt.Errorf("cannot add %d selector rules. Only %d of %d sessionIds are available", count, int(maxSessionId)-len(sessionIdsMap), maxSessionId)
+	}
+
+	sessionIds := make([]uint32, 0, count)
+	candidate := highestSessionId
+	for len(sessionIds) < count {
+		candidate = candidate%maxSessionId + 1
+		if _, ok := sessionIdsMap[candidate]; !ok {
+			sessionIds = append(sessionIds, candidate)
+		}
+	}
+
+	return sessionIds, nil
+}
+
+// Change the keys or the priority of an existing selector rule in the dataplane.
+// The sessionId and therefore the history of the rule is kept.
+// Table and priority of the existing rule are kept, if not defined.
+func (t *TrafficSelector) UpdateTrafficSelectorRule(sessionId uint32, updatedSelectorRule *model.MatchSelectorEntry) error {
+	t.selectorLock.Lock()
+	defer t.selectorLock.Unlock()
+
+	var existingRule *model.MatchSelectorEntry
+	for _, entry := range t.getTrafficSelectorCache() {
+		if entry.SessionId == sessionId {
+			existingRule = entry
+			break
+		}
+	}
+	if existingRule == nil {
+		return &model.ErrNameNotFound{Msg: "Selector rule does not exist", Entity: fmt.Sprintf("%d", sessionId)}
+	}
+	updatedSelectorRule.SessionId = sessionId
+	if updatedSelectorRule.Table == "" {
+		updatedSelectorRule.Table = existingRule.Table
+	}
+	if updatedSelectorRule.Priority == nil {
+		updatedSelectorRule.Priority = existingRule.Priority
+	}
+
+	err := t.driver.ModifySelectorEntry(existingRule, updatedSelectorRule)
+	if err != nil {
+		return err
+	}
+	t.logger.Info("Selector rule has been modified", "sessionId", sessionId, "table", updatedSelectorRule.Table)
+
+	// Refresh match selector cache
+	err = t.loadSessionsFromDevice()
 	return err
 }
 
 // Remove an existing selector rule from dataplane
 func (t *TrafficSelector) RemoveTrafficSelectorRule(selectorRule *model.MatchSelectorEntry) error {
+	t.selectorLock.Lock()
+	defer t.selectorLock.Unlock()
+
 	// Remove rule from dataplane
 	err := t.driver.RemoveSelectorEntry(selectorRule)
 	if err != nil {
@@ -87,51 +245,206 @@ func (t *TrafficSelector) RemoveTrafficSelectorRule(selectorRule *model.MatchSel
 	}
 	t.logger.Info("Selector rule has been successfully removed", "rule", selectorRule.SessionId)
 	// Refresh match selector cache
-	err = t.LoadSessionsFromDevice()
+	err = t.loadSessionsFromDevice()
 	return err
 }
 
 // Retrieve the match selector entries and extract the session IDs.
 func (t *TrafficSelector) LoadSessionsFromDevice() error {
+	t.selectorLock.Lock()
+	defer t.selectorLock.Unlock()
+
+	return t.loadSessionsFromDevice()
+}
+
+// Refreshes the cache of selector rules. The caller needs to hold the selector lock.
+func (t *TrafficSelector) loadSessionsFromDevice() error {
 	matchSelectorEntries, err := t.driver.GetKeysFromMatchSelectors()
 	if err != nil {
 		return err
 	}
+	// LPF parameter are stored in the dataplane and survive a restart of the controller
+	sessionIds := make([]uint32, 0, len(matchSelectorEntries))
+	for i := range matchSelectorEntries {
+		sessionIds = append(sessionIds, matchSelectorEntries[i].SessionId)
+	}
+	lpfConfigs, err := t.driver.GetLPFConfigs(sessionIds)
+	if err != nil {
+		t.logger.Warn("Cannot retrieve LPF parameter from dataplane", "err", err)
+	}
+	for i := range matchSelectorEntries {
+		matchSelectorEntries[i].LPF = lpfConfigs[matchSelectorEntries[i].SessionId]
+		// Render the keys in the human-friendly notation
+		if schema, err := t.GetTrafficSelectorSchema(matchSelectorEntries[i].Table); err == nil {
+			matchSelectorEntries[i].Expression = RenderSelectorExpression(matchSelectorEntries[i], schema)
+		}
+	}
+	t.cacheLock.Lock()
 	t.matchSelectorEntryCache = matchSelectorEntries
+	t.cacheLock.Unlock()
 
 	return nil
 }
 
 func (t *TrafficSelector) GetTrafficSelectorCache() []*model.MatchSelectorEntry {
+	if cache := t.getCachedEntries(); cache != nil {
+		return cache
+	}
+	t.selectorLock.Lock()
+	defer t.selectorLock.Unlock()
+
+	return t.getTrafficSelectorCache()
+}
+
+// Returns the cache of selector rules. The caller needs to hold the selector lock.
+func (t *TrafficSelector) getTrafficSelectorCache() []*model.MatchSelectorEntry {
 	// If sessionId cache is empty, then refresh the cache
-	if t.matchSelectorEntryCache == nil {
-		err := t.LoadSessionsFromDevice()
+	if t.getCachedEntries() == nil {
+		err := t.loadSessionsFromDevice()
 		if err != nil {
 			t.logger.Error("Error occured during collection. Cannot retrieve sessionIds from Ingress Start Match table", "err", err)
 			return nil
 		}
 	}
+	return t.getCachedEntries()
+}
+
+// The cache is replaced on a reload and never changed in place.
+func (t *TrafficSelector) getCachedEntries() []*model.MatchSelectorEntry {
+	t.cacheLock.RLock()
+	defer t.cacheLock.RUnlock()
+
 	return t.matchSelectorEntryCache
 }
 
 // Returns just a list of sessionIds from the cache
 func (t *TrafficSelector) GetSessionIdCache() []uint32 {
-	sessionIds := make([]uint32, 0, len(t.matchSelectorEntryCache))
+	cache := t.getCachedEntries()
+	sessionIds := make([]uint32, 0, len(cache))
 
-	for i := range t.matchSelectorEntryCache {
-		sessionIds = append(sessionIds, t.matchSelectorEntryCache[i].SessionId)
+	for i := range cache {
+		sessionIds = append(sessionIds, cache[i].SessionId)
 	}
 
 	return sessionIds
 }
 
-// Retrieves schema of keys from the P4 schema cache.
-func (t *TrafficSelector) GetTrafficSelectorSchema() ([]*model.MatchSelectorSchema, error) {
-	return t.driver.GetIngressStartMatchSelectorSchema()
+// Retrieves schema of keys of a selector table from the P4 schema cache.
+// An empty table name refers to the default selector table.
+func (t *TrafficSelector) GetTrafficSelectorSchema(selectorTable string) ([]*model.MatchSelectorSchema, error) {
+	return t.driver.GetIngressStartMatchSelectorSchema(selectorTable)
 }
 
-// Initialize LPF instances for all configured sessionIds
+// Compiles the expression of a selector rule into keys against the schema of its selector table.
+// Rules with keys are left unchanged. The priority of the expression is only used, if the rule does not define one.
+func (t *TrafficSelector) CompileSelectorExpression(selectorRule *model.MatchSelectorEntry) error {
+	if selectorRule.Expression == "" || len(selectorRule.Keys) > 0 {
+		return nil
+	}
+	schema, err := t.GetTrafficSelectorSchema(selectorRule.Table)
+	if err != nil {
+		return err
+	}
+	keys, priority, err := CompileSelectorExpression(selectorRule.Expression, schema)
+	if err != nil {
+		return err
+	}
+	selectorRule.Keys = keys
+	if selectorRule.Priority == nil {
+		selectorRule.Priority = priority
+	}
+
+	return nil
+}
+
+// Retrieves schema of keys of all selector tables from the P4 schema cache.
+func (t *TrafficSelector) GetAllTrafficSelectorSchemas() ([]*model.MatchSelectorTableSchema, error) {
+	return t.driver.GetAllMatchSelectorSchemas()
+}
+
+// Initialize LPF instances with the default parameter for all configured sessionIds, which have not been configured yet.
+// Parameter changed through the API are kept.
 func (t *TrafficSelector) ConfigureLPF() error {
-	sessionIds := t.GetSessionIdCache()
-	return t.driver.ConfigureLPF(sessionIds)
+	t.selectorLock.Lock()
+	defer t.selectorLock.Unlock()
+
+	sessionIds := make([]uint32, 0)
+	for _, entry := range t.getTrafficSelectorCache() {
+		if entry.LPF == nil || entry.LPF.GainTimeConst == 0 {
+			sessionIds = append(sessionIds, entry.SessionId)
+		}
+	}
+	if len(sessionIds) == 0 {
+		return nil
+	}
+	err := t.driver.ConfigureLPF(sessionIds, t.defaultLPFConfig())
+	if err != nil {
+		return err
+	}
+
+	// Refresh match selector cache
+	return t.loadSessionsFromDevice()
+}
+
+// Changes the LPF parameter of an existing selector rule
+func (t *TrafficSelector) UpdateLPFConfig(sessionId uint32, lpf *model.LPFConfig) error {
+	t.selectorLock.Lock()
+	defer t.selectorLock.Unlock()
+
+	found := false
+	for _, entry := range t.getTrafficSelectorCache() {
+		if entry.SessionId == sessionId {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return &model.ErrNameNotFound{Msg: "Selector rule does not exist", Entity: fmt.Sprintf("%d", sessionId)}
+	}
+	if err := validateLPFConfig(lpf); err != nil {
+		return err
+	}
+
+	err := t.driver.ConfigureLPF([]uint32{sessionId}, lpf)
+	if err != nil {
+		return err
+	}
+
+	// Refresh match selector cache
+	return t.loadSessionsFromDevice()
+}
+
+// LPF parameter defined by the -lpf-time-ns flag
+func (t *TrafficSelector) defaultLPFConfig() *model.LPFConfig {
+	return &model.LPFConfig{
+		Type:           model.LPF_TYPE_SAMPLE,
+		GainTimeConst:  t.lpfTimeConst,
+		DecayTimeConst: t.lpfTimeConst,
+		ScaleDown:      0,
+	}
+}
+
+// Validates the LPF parameter and fills in defaults for omitted values.
+func validateLPFConfig(lpf *model.LPFConfig) error {
+	if lpf.Type == "" {
+		lpf.Type = model.LPF_TYPE_SAMPLE
+	}
+	if lpf.Type != model.LPF_TYPE_SAMPLE && lpf.Type != model.LPF_TYPE_RATE {
+		return fmt.Errorf("%s is an invalid LPF type. Use %s or %s", lpf.Type, model.LPF_TYPE_SAMPLE, model.LPF_TYPE_RATE)
+	}
+	if lpf.GainTimeConst <= 0 {
+		return fmt.Errorf("LPF gain time constant needs to be a positive number")
+	}
+	// Decay is equal to gain, if not defined
+	if lpf.DecayTimeConst == 0 {
+		lpf.DecayTimeConst = lpf.GainTimeConst
+	}
+	if lpf.DecayTimeConst < 0 {
+		return fmt.Errorf("LPF decay time constant needs to be a positive number")
+	}
+	if lpf.ScaleDown > model.LPF_MAX_SCALE_DOWN {
+		return fmt.Errorf("%d is an invalid LPF scale down factor. Needs to be between 0 and %d", lpf.ScaleDown, model.LPF_MAX_SCALE_DOWN)
+	}
+
+	return nil
 }
@@ -9,3 +9,16 @@ type ApiErrorMessage struct {
 	Message string `json:"message"`
 	Code    int    `json:"code"`
 }
+
+// Operating mode of the controller
+type ControllerStatus struct {
+	Role          string `json:"role"`     // leader holds the single-writer lease. A follower or a read-only controller never writes.
+	ReadOnly      bool   `json:"readOnly"` // No writes to the dataplane. Selector rules and probe parameter cannot be changed.
+	DeltaSampling bool   `json:"deltaSampling"`
+	HardwareSync  bool   `json:"hardwareSync"`
+}
+
+const (
+	CONTROLLER_ROLE_LEADER   = "leader"
+	CONTROLLER_ROLE_FOLLOWER = "follower"
+)
@@ -0,0 +1,22 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package model
+
+// Counter, direct counter or meter of the user application to monitor
+type AppObject struct {
+	Name       string              `json:"name"`
+	Type       string              `json:"type"`
+	Index      uint32              `json:"index"`                // Index of a counter or meter. Identifies the entry of a direct counter. Used as sessionId of the metrics.
+	Keys       []*MatchSelectorKey `json:"keys,omitempty"`       // Match key of the table entry of a direct counter
+	Priority   *uint32             `json:"priority,omitempty"`   // Match priority of the table entry of a direct counter
+	Expression string              `json:"expression,omitempty"` // Match key of a direct counter as selector expression. Only used if keys are empty.
+}
+
+const (
+	APP_OBJECT_TYPE_COUNTER        = "counter"
+	APP_OBJECT_TYPE_DIRECT_COUNTER = "direct_counter"
+	APP_OBJECT_TYPE_METER          = "meter"
+)
@@ -6,6 +6,15 @@
 package model
 
 type AppRegister struct {
-	Name  string `json:"name"`
-	Index uint32 `json:"index"`
+	Name        string  `json:"name"`
+	Index       uint32  `json:"index"`
+	IndexEnd    *uint32 `json:"indexEnd,omitempty"`    // Reads the index range from index to indexEnd inclusive
+	All         bool    `json:"all,omitempty"`         // Reads the whole register array
+	Aggregation string  `json:"aggregation,omitempty"` // Aggregates the values of a range to a single metric. Either sum, max or min. One metric per index, if empty.
 }
+
+const (
+	AGGREGATION_SUM = "sum"
+	AGGREGATION_MAX = "max"
+	AGGREGATION_MIN = "min"
+)
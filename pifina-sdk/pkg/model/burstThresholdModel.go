@@ -0,0 +1,11 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package model
+
+// Queue depth threshold in cells of the microburst probe
+type BurstThreshold struct {
+	Threshold uint32 `json:"threshold"`
+}
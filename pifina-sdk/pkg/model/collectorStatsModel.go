@@ -0,0 +1,17 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package model
+
+// Statistics of a collection group of the metric collector
+type CollectorGroupStats struct {
+	Name           string  `json:"name"`
+	IntervalMs     float64 `json:"intervalMs"`
+	Cycles         uint64  `json:"cycles"`
+	Overruns       uint64  `json:"overruns"`     // Cycles, which took longer than the interval
+	SkippedTicks   uint64  `json:"skippedTicks"` // Ticks dropped, because the previous cycle was still running
+	LastDurationMs float64 `json:"lastDurationMs"`
+	MaxDurationMs  float64 `json:"maxDurationMs"`
+}
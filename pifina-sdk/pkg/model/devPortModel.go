@@ -6,6 +6,24 @@
 package model
 
 type DevPort struct {
-	Name   string `json:"name"`
-	PortId uint32 `json:"portId,omitempty"`
+	Name   string            `json:"name"`
+	PortId uint32            `json:"portId,omitempty"`
+	Status *PortStatus       `json:"status,omitempty"`
+	Stats  map[string]uint64 `json:"stats,omitempty"` // RMON counters of the port by counter name
 }
+
+// Link state of a port
+type PortStatus struct {
+	OperUp    bool   `json:"operUp"`
+	Speed     string `json:"speed"`     // e.g. BF_SPEED_100G
+	SpeedGbps uint64 `json:"speedGbps"` // Speed in Gbit/s. 0 if unknown
+	Fec       string `json:"fec"`       // e.g. BF_FEC_TYP_REED_SOLOMON
+}
+
+// FEC modes exported as value of the port FEC metric
+const (
+	PORT_FEC_NONE         = 0
+	PORT_FEC_FIRECODE     = 1
+	PORT_FEC_REED_SOLOMON = 2
+	PORT_FEC_UNKNOWN      = 255
+)
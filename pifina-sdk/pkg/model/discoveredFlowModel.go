@@ -0,0 +1,32 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package model
+
+import "time"
+
+// Flow reported by the flow discovery digest, which is not matched by any selector rule
+type DiscoveredFlow struct {
+	Id            string               `json:"id"` // Flow key as label like ipv4_src_addr=0xa000001,ipv4_dst_addr=0xa000002
+	Keys          []*DiscoveredFlowKey `json:"keys"`
+	Expression    string               `json:"expression,omitempty"` // Proposed selector expression for the default selector table
+	Error         string               `json:"error,omitempty"`      // Reason, why no selector expression can be proposed
+	FirstSeen     time.Time            `json:"firstSeen"`
+	LastSeen      time.Time            `json:"lastSeen"`
+	Reports       uint64               `json:"reports"`                 // Amount of discovery intervals, in which the flow has been reported
+	MeasurementId uint32               `json:"measurementId,omitempty"` // Measurement of the auto-created selector rule
+}
+
+type DiscoveredFlowKey struct {
+	Name  string `json:"name"`  // Field of the digest like ipv4_src_addr
+	Value string `json:"value"` // Value in hex notation like 0xa000001
+}
+
+// Limits for the automatic creation of selector rules for discovered flows
+type FlowDiscoveryPolicy struct {
+	AutoSelectors uint32 `json:"autoSelectors"` // Selector rules created for the top new flows per discovery interval. 0 only proposes selector rules.
+	MaxSelectors  uint32 `json:"maxSelectors"`  // Upper bound of concurrent auto-created selector rules
+	TTL           uint32 `json:"ttl"`           // Duration of the measurement of an auto-created selector rule in seconds
+}
@@ -16,6 +16,10 @@ type ErrNotReady struct {
 	Msg string
 }
 
+type ErrReadOnly struct {
+	Msg string
+}
+
 func (e *ErrNameNotFound) Error() string {
 	return fmt.Sprintf("%s - Entity: %s", e.Msg, e.Entity)
 }
@@ -23,3 +27,7 @@ func (e *ErrNameNotFound) Error() string {
 func (e *ErrNotReady) Error() string {
 	return e.Msg
 }
+
+func (e *ErrReadOnly) Error() string {
+	return e.Msg
+}
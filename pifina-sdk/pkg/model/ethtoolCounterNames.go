@@ -11,5 +11,59 @@ var ETHTOOL_COUNTERS = []string{
 	"rx_discards_phy",
 	"tx_discards_phy",
 	"rx_out_of_buffer",
-	"rx_queue_0_packets",
+	"tx_pause_storm_warning_events",
+	"tx_pause_storm_error_events",
+}
+
+// Ethtool counter, which exists once per queue (ring) or per priority.
+// Pattern is a regular expression with a single capture group for the queue or priority index.
+// All matching counters are reported under MetricName with the captured index as SessionId.
+type EthtoolIndexedCounter struct {
+	Pattern    string
+	MetricName string
+}
+
+const (
+	ETHTOOL_RX_QUEUE_PACKETS         = "rx_queue_packets"
+	ETHTOOL_RX_QUEUE_BYTES           = "rx_queue_bytes"
+	ETHTOOL_RX_QUEUE_DROPS           = "rx_queue_drops"
+	ETHTOOL_TX_QUEUE_PACKETS         = "tx_queue_packets"
+	ETHTOOL_TX_QUEUE_BYTES           = "tx_queue_bytes"
+	ETHTOOL_TX_QUEUE_DROPS           = "tx_queue_drops"
+	ETHTOOL_RX_PRIO_PACKETS          = "rx_prio_packets"
+	ETHTOOL_RX_PRIO_BYTES            = "rx_prio_bytes"
+	ETHTOOL_RX_PRIO_DISCARDS         = "rx_prio_discards"
+	ETHTOOL_TX_PRIO_PACKETS          = "tx_prio_packets"
+	ETHTOOL_TX_PRIO_BYTES            = "tx_prio_bytes"
+	ETHTOOL_RX_PRIO_PAUSE            = "rx_prio_pause"
+	ETHTOOL_TX_PRIO_PAUSE            = "tx_prio_pause"
+	ETHTOOL_RX_PRIO_PAUSE_DURATION   = "rx_prio_pause_duration"
+	ETHTOOL_TX_PRIO_PAUSE_DURATION   = "tx_prio_pause_duration"
+	ETHTOOL_RX_PRIO_PAUSE_TRANSITION = "rx_prio_pause_transition"
+)
+
+// Mellanox (mlx5) names its rings rxN/txN, other drivers like ixgbe, ice or virtio_net use rx_queue_N/tx_queue_N.
+var ETHTOOL_INDEXED_COUNTERS = []EthtoolIndexedCounter{
+	{Pattern: `^rx(\d+)_packets$`, MetricName: ETHTOOL_RX_QUEUE_PACKETS},
+	{Pattern: `^rx_queue_(\d+)_packets$`, MetricName: ETHTOOL_RX_QUEUE_PACKETS},
+	{Pattern: `^rx(\d+)_bytes$`, MetricName: ETHTOOL_RX_QUEUE_BYTES},
+	{Pattern: `^rx_queue_(\d+)_bytes$`, MetricName: ETHTOOL_RX_QUEUE_BYTES},
+	{Pattern: `^rx(\d+)_xdp_drop$`, MetricName: ETHTOOL_RX_QUEUE_DROPS},
+	{Pattern: `^rx_queue_(\d+)_drops$`, MetricName: ETHTOOL_RX_QUEUE_DROPS},
+	{Pattern: `^tx(\d+)_packets$`, MetricName: ETHTOOL_TX_QUEUE_PACKETS},
+	{Pattern: `^tx_queue_(\d+)_packets$`, MetricName: ETHTOOL_TX_QUEUE_PACKETS},
+	{Pattern: `^tx(\d+)_bytes$`, MetricName: ETHTOOL_TX_QUEUE_BYTES},
+	{Pattern: `^tx_queue_(\d+)_bytes$`, MetricName: ETHTOOL_TX_QUEUE_BYTES},
+	{Pattern: `^tx(\d+)_dropped$`, MetricName: ETHTOOL_TX_QUEUE_DROPS},
+	{Pattern: `^tx_queue_(\d+)_drops$`, MetricName: ETHTOOL_TX_QUEUE_DROPS},
+	{Pattern: `^rx_prio(\d+)_packets$`, MetricName: ETHTOOL_RX_PRIO_PACKETS},
+	{Pattern: `^rx_prio(\d+)_bytes$`, MetricName: ETHTOOL_RX_PRIO_BYTES},
+	{Pattern: `^rx_prio(\d+)_discards$`, MetricName: ETHTOOL_RX_PRIO_DISCARDS},
+	{Pattern: `^tx_prio(\d+)_packets$`, MetricName: ETHTOOL_TX_PRIO_PACKETS},
+	{Pattern: `^tx_prio(\d+)_bytes$`, MetricName: ETHTOOL_TX_PRIO_BYTES},
+	{Pattern: `^rx_prio(\d+)_pause$`, MetricName: ETHTOOL_RX_PRIO_PAUSE},
+	{Pattern: `^tx_prio(\d+)_pause$`, MetricName: ETHTOOL_TX_PRIO_PAUSE},
+	{Pattern: `^rx_prio(\d+)_pause_duration$`, MetricName: ETHTOOL_RX_PRIO_PAUSE_DURATION},
+	{Pattern: `^tx_prio(\d+)_pause_duration$`, MetricName: ETHTOOL_TX_PRIO_PAUSE_DURATION},
+	{Pattern: `^rx_prio(\d+)_pause_transition$`, MetricName: ETHTOOL_RX_PRIO_PAUSE_TRANSITION},
 }
@@ -0,0 +1,21 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package model
+
+// Parameters of a LPF instance in the dataplane. Time constants are defined in nanoseconds.
+type LPFConfig struct {
+	Type           string  `json:"type"`
+	GainTimeConst  float32 `json:"gainTimeConstant"`
+	DecayTimeConst float32 `json:"decayTimeConstant"`
+	ScaleDown      uint32  `json:"scaleDown"`
+}
+
+const (
+	LPF_TYPE_RATE   = "RATE"
+	LPF_TYPE_SAMPLE = "SAMPLE"
+	// Output of the LPF can be scaled down by 2^scaleDown
+	LPF_MAX_SCALE_DOWN = 31
+)
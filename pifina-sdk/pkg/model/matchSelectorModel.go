@@ -6,8 +6,10 @@
 package model
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"time"
 )
 
 type MatchSelectorSchema struct {
@@ -18,9 +20,33 @@ type MatchSelectorSchema struct {
 	Width     uint32 `json:"width,omitempty"`
 }
 
+type MatchSelectorTableSchema struct {
+	Name string                 `json:"name"`
+	Keys []*MatchSelectorSchema `json:"keys"`
+}
+
 type MatchSelectorEntry struct {
-	SessionId uint32              `json:"sessionId"`
-	Keys      []*MatchSelectorKey `json:"keys"`
+	SessionId  uint32              `json:"sessionId"`
+	Table      string              `json:"table,omitempty"` // Short name of the selector table. Empty refers to the default selector table
+	Keys       []*MatchSelectorKey `json:"keys"`
+	Expression string              `json:"expression,omitempty"` // Human-friendly notation of the keys like "proto=udp dst=10.1.0.0/16 dport=4791". Used instead of keys, if no keys are defined.
+	Priority   *uint32             `json:"priority,omitempty"`   // $MATCH_PRIORITY of tables with ternary, lpm, range or optional keys. 0 is the highest priority.
+	LPF        *LPFConfig          `json:"lpf,omitempty"`        // Parameters of the LPF instance of the jitter probe
+	TTL        uint32              `json:"ttl,omitempty"`        // Duration of the measurement in seconds. The rule is removed afterwards.
+	Start      *time.Time          `json:"start,omitempty"`      // Install time of the rule. Installed immediately, if not defined.
+	Stop       *time.Time          `json:"stop,omitempty"`       // Removal time of the rule. Used instead of the TTL.
+}
+
+// Checks if the rule is installed and removed by the controller at given times
+func (entry *MatchSelectorEntry) IsTimeBoxed() bool {
+	return entry.TTL > 0 || entry.Start != nil || entry.Stop != nil
+}
+
+// Response of a created or modified selector rule
+type MatchSelectorResponse struct {
+	SessionId     uint32   `json:"sessionId"`
+	MeasurementId uint32   `json:"measurementId,omitempty"` // Only set for rules with a TTL or a schedule
+	Warnings      []string `json:"warnings,omitempty"`
 }
 
 type MatchSelectorKey struct {
@@ -29,12 +55,17 @@ type MatchSelectorKey struct {
 	MatchType    string `json:"matchType"`
 	ValueMask    []byte `json:"valueMask,omitempty"`
 	PrefixLength int32  `json:"prefixLength,omitempty"`
+	RangeLow     []byte `json:"low,omitempty"`
+	RangeHigh    []byte `json:"high,omitempty"`
+	IsValid      bool   `json:"isValid,omitempty"` // Only used by optional match type. False matches any value.
 }
 
 const (
-	MATCH_TYPE_EXACT   = "Exact"
-	MATCH_TYPE_TERNARY = "Ternary"
-	MATCH_TYPE_LPM     = "LPM"
+	MATCH_TYPE_EXACT    = "Exact"
+	MATCH_TYPE_TERNARY  = "Ternary"
+	MATCH_TYPE_LPM      = "LPM"
+	MATCH_TYPE_RANGE    = "Range"
+	MATCH_TYPE_OPTIONAL = "Optional"
 )
 
 func (key *MatchSelectorKey) MarshalJSON() ([]byte, error) {
@@ -42,10 +73,14 @@ func (key *MatchSelectorKey) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		Value     string `json:"value"`
 		ValueMask string `json:"valueMask,omitempty"`
+		RangeLow  string `json:"low,omitempty"`
+		RangeHigh string `json:"high,omitempty"`
 		*Alias
 	}{
 		Value:     hex.EncodeToString(key.Value),
 		ValueMask: hex.EncodeToString(key.ValueMask),
+		RangeLow:  hex.EncodeToString(key.RangeLow),
+		RangeHigh: hex.EncodeToString(key.RangeHigh),
 		Alias:     (*Alias)(key),
 	})
 }
@@ -55,10 +90,14 @@ func (key *MatchSelectorKey) UnmarshalJSON(data []byte) error {
 	aux := &struct {
 		Value     string `json:"value"`
 		ValueMask string `json:"valueMask,omitempty"`
+		RangeLow  string `json:"low,omitempty"`
+		RangeHigh string `json:"high,omitempty"`
 		*Alias
 	}{
 		Value:     hex.EncodeToString(key.Value),
 		ValueMask: hex.EncodeToString(key.ValueMask),
+		RangeLow:  hex.EncodeToString(key.RangeLow),
+		RangeHigh: hex.EncodeToString(key.RangeHigh),
 		Alias:     (*Alias)(key),
 	}
 
@@ -75,5 +114,42 @@ func (key *MatchSelectorKey) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if key.RangeLow, err = hex.DecodeString(aux.RangeLow); err != nil {
+		return err
+	}
+
+	if key.RangeHigh, err = hex.DecodeString(aux.RangeHigh); err != nil {
+		return err
+	}
+
 	return nil
 }
+
+// Checks if both keys match the same values. Values can be encoded with a different amount of bytes.
+func (key *MatchSelectorKey) Equal(other *MatchSelectorKey) bool {
+	if key.FieldId != other.FieldId || key.MatchType != other.MatchType || key.PrefixLength != other.PrefixLength || key.IsValid != other.IsValid {
+		return false
+	}
+
+	return EqualBytes(key.Value, other.Value) && EqualBytes(key.ValueMask, other.ValueMask) &&
+		EqualBytes(key.RangeLow, other.RangeLow) && EqualBytes(key.RangeHigh, other.RangeHigh)
+}
+
+// Compares two big endian values, which could be encoded with a different amount of bytes.
+func EqualBytes(a []byte, b []byte) bool {
+	width := len(a)
+	if len(b) > width {
+		width = len(b)
+	}
+	return bytes.Equal(PadBytes(a, width), PadBytes(b, width))
+}
+
+// Left pads a big endian value with zeros to the given width.
+func PadBytes(value []byte, width int) []byte {
+	if len(value) >= width {
+		return value
+	}
+	padded := make([]byte, width)
+	copy(padded[width-len(value):], value)
+	return padded
+}
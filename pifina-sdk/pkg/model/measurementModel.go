@@ -0,0 +1,38 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package model
+
+import "time"
+
+// Time-boxed measurement of a selector rule. The rule is installed at the start time and removed at the stop time or after the TTL.
+type Measurement struct {
+	Id       uint32              `json:"id"`
+	State    string              `json:"state"`
+	Selector *MatchSelectorEntry `json:"selector"`
+	Start    time.Time           `json:"start"`          // Planned install time. Actual install time, once running.
+	Stop     *time.Time          `json:"stop,omitempty"` // Planned removal time. Actual removal time, once finished. Not set, if the rule is kept.
+	Error    string              `json:"error,omitempty"`
+	Summary  *MeasurementSummary `json:"summary,omitempty"` // Updated while running and kept after removal of the rule
+}
+
+// Totals and peaks of a measurement computed from the selector counter and the jitter probe
+type MeasurementSummary struct {
+	TotalBytes      uint64  `json:"totalBytes"`
+	TotalPkts       uint64  `json:"totalPkts"`
+	PeakBytesPerSec float64 `json:"peakBytesPerSec"`
+	PeakPktsPerSec  float64 `json:"peakPktsPerSec"`
+	PeakJitter      uint64  `json:"peakJitter"`
+}
+
+const (
+	MEASUREMENT_STATE_SCHEDULED = "scheduled"
+	MEASUREMENT_STATE_RUNNING   = "running"
+	MEASUREMENT_STATE_FINISHED  = "finished"
+	MEASUREMENT_STATE_FAILED    = "failed"
+	// Events sent to the collector, when a rule has been installed or removed. The value is the measurement id.
+	MEASUREMENT_EVENT_BEGIN = "PF_MEASUREMENT_BEGIN"
+	MEASUREMENT_EVENT_END   = "PF_MEASUREMENT_END"
+)
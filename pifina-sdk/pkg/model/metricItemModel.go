@@ -5,13 +5,17 @@
 
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type MetricItem struct {
 	SessionId   uint32    `json:"sessionId"`
 	Type        string    `json:"type"`
 	Value       uint64    `json:"value"`
 	MetricName  string    `json:"metricName"`
+	Label       string    `json:"label,omitempty"` // Distinguishes several values of the same metric and session, e.g. the flow key of a top flow
 	LastUpdated time.Time `json:"timestamp"`
 }
 
@@ -22,10 +26,26 @@ type TelemetryMessage struct {
 	MetricList []*MetricItem `json:"metrics"`
 }
 
+// Values of the type METRIC_EXT_SIGNED_VALUE are transported as two's complement and encoded as signed number in JSON.
+func (metric *MetricItem) MarshalJSON() ([]byte, error) {
+	type Alias MetricItem
+	if metric.Type != METRIC_EXT_SIGNED_VALUE {
+		return json.Marshal((*Alias)(metric))
+	}
+	return json.Marshal(&struct {
+		Value int64 `json:"value"`
+		*Alias
+	}{
+		Value: int64(metric.Value),
+		Alias: (*Alias)(metric),
+	})
+}
+
 const (
-	METRIC_BYTES     = "METRIC_BYTES"
-	METRIC_PKTS      = "METRIC_PKTS"
-	METRIC_EXT_VALUE = "METRIC_EXT_VALUE"
-	HOSTTYPE_TOFINO  = "HOSTTYPE_TOFINO"
-	HOSTTYPE_NIC     = "HOSTTYPE_NIC"
+	METRIC_BYTES            = "METRIC_BYTES"
+	METRIC_PKTS             = "METRIC_PKTS"
+	METRIC_EXT_VALUE        = "METRIC_EXT_VALUE"
+	METRIC_EXT_SIGNED_VALUE = "METRIC_EXT_SIGNED_VALUE" // Signed value of a register
+	HOSTTYPE_TOFINO         = "HOSTTYPE_TOFINO"
+	HOSTTYPE_NIC            = "HOSTTYPE_NIC"
 )
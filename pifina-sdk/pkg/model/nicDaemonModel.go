@@ -0,0 +1,25 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package model
+
+type NicDevice struct {
+	Name     string `json:"name"`
+	UID      string `json:"uid,omitempty"`
+	IbDevice string `json:"ibDevice,omitempty"`
+	IfName   string `json:"ifName,omitempty"`
+}
+
+type NicCounter struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+const (
+	NIC_COUNTER_SOURCE_ETHTOOL = "ethtool"
+	NIC_COUNTER_SOURCE_NEOHOST = "neohost"
+	// Per-queue and per-priority ethtool counters by the metric name of model.ETHTOOL_INDEXED_COUNTERS like rx_queue_packets
+	NIC_COUNTER_SOURCE_ETHTOOL_INDEXED = "ethtool_indexed"
+)
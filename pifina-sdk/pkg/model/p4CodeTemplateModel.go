@@ -7,15 +7,66 @@ package model
 
 type P4CodeTemplate struct {
 	SessionIdWidth    uint
-	MatchKeys         []*P4CodeTemplateKey
+	SessionIdPadding  uint                           // Padding bits to align the bridge header to a byte boundary
+	BridgeHeaderSize  uint                           // Size of the bridge header in bytes
+	SelectorTables    []*P4CodeTemplateSelectorTable // Sorted by priority. First table is applied first.
 	IngressHeaderType string
 	EgressHeaderType  string
 	ExtraProbeList    []ExtraProbeTemplate
+	// Buckets of the packet size histogram probe. Empty if the probe is disabled.
+	PacketSizeBuckets     []*P4CodeTemplatePacketSizeBucket
+	PacketSizeBucketWidth uint                         // Width of the bucket id in bits
+	DropProbe             bool                         // Generate the ingress and egress drop probes
+	DropReasonWidth       uint                         // Width of the drop reason in bits. 0 if the drops are not counted per reason.
+	HeavyHitter           *P4CodeTemplateHeavyHitter   // Nil if the heavy hitter probe is disabled.
+	BurstThreshold        uint                         // Default queue depth threshold in cells of the microburst probe. 0 if the probe is disabled.
+	FlowDiscovery         *P4CodeTemplateFlowDiscovery // Nil if the flow discovery digest is disabled.
+}
+
+type P4CodeTemplateSelectorTable struct {
+	Name             string // Suffix of the P4 table name. Empty for the default selector table
+	ControlPlaneName string // Name of the table exposed to the control plane. e.g. PF_INGRESS_MATCH_CNT_ROCE
+	Priority         int
+	MatchKeys        []*P4CodeTemplateKey
 }
 
 type P4CodeTemplateKey struct {
 	Name      string
 	MatchType string
+	Width     uint // Bit width of the key. Only known if the header file has been parsed.
+}
+
+// Packet size range in bytes of a histogram bucket. Both boundaries are inclusive.
+type P4CodeTemplatePacketSizeBucket struct {
+	Id   uint
+	Low  uint
+	High uint
+}
+
+// Count-Min sketch with a table of top flows per session.
+type P4CodeTemplateHeavyHitter struct {
+	Keys        []*P4CodeTemplateHeavyHitterKey // Flow key used as hash input
+	SketchWidth uint                            // Width of the hash for a sketch row in bits
+	SlotWidth   uint                            // Width of the hash for the top flow slots in bits
+}
+
+type P4CodeTemplateHeavyHitterKey struct {
+	Name             string // Header field like hdr.ipv4.src_addr
+	ControlPlaneName string // Name of the register storing the key. e.g. PF_HH_KEY_0_ipv4_src_addr
+	Width            uint   // Bit width of the key. Only known if the header file has been parsed.
+}
+
+// Digest reporting the first packet of a flow, which is not matched by any selector rule.
+type P4CodeTemplateFlowDiscovery struct {
+	Keys        []*P4CodeTemplateFlowDiscoveryKey // Flow key sent in the digest
+	Filter      string                            // Condition in P4, which a packet needs to fulfill to be reported. e.g. hdr.ipv4.isValid()
+	FilterWidth uint                              // Width of the hash in bits indexing the filter of already reported flows
+}
+
+type P4CodeTemplateFlowDiscoveryKey struct {
+	Name             string // Header field like hdr.ipv4.src_addr
+	ControlPlaneName string // Name of the field in the digest. e.g. ipv4_src_addr
+	Width            uint   // Bit width of the key. Needs the header file to be parsed.
 }
 
 type ExtraProbeTemplate struct {
@@ -23,6 +23,7 @@ func ConvertMetricsToProtobuf(metrics []*MetricItem) []*pifina.PifinaMetric {
 			Value:       metrics[i].Value,
 			ValueType:   metrics[i].Type,
 			MetricName:  metrics[i].MetricName,
+			Label:       metrics[i].Label,
 			LastUpdated: timestamppb.New(metrics[i].LastUpdated),
 		})
 	}
@@ -44,6 +45,7 @@ func ConvertProtobufToMetrics(rawMetrics []*pifina.PifinaMetric) []*MetricItem {
 			Value:       rawMetrics[i].Value,
 			Type:        rawMetrics[i].ValueType,
 			MetricName:  rawMetrics[i].MetricName,
+			Label:       rawMetrics[i].Label,
 			LastUpdated: rawMetrics[i].LastUpdated.AsTime(),
 		})
 	}
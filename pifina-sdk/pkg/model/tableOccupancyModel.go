@@ -0,0 +1,14 @@
+// Copyright (c) 2023 Thushjandan Ponnudurai
+// 
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package model
+
+// Installed entries of a P4 table compared to its size
+type TableOccupancy struct {
+	Name      string  `json:"name"`
+	Usage     uint32  `json:"usage"`     // Installed entries
+	Size      uint32  `json:"size"`      // Maximum amount of entries
+	Occupancy float64 `json:"occupancy"` // Usage in percent of the size
+}
@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/thushjandan/pifina/pkg/model"
@@ -18,12 +20,16 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// Interval, in which the REST API of the probe is announced. Registers the probe again after a restart of the pifina server.
+const API_ANNOUNCE_INTERVAL = 30 * time.Second
+
 type Sink struct {
 	logger         hclog.Logger
 	pifinaEndpoint string
 	hostType       pifina.PifinaHostTypes
 	mySystemName   string
 	groupId        uint32
+	apiPort        uint32
 }
 
 // hostType needs to be one of the constants defined in metricItemModel file
@@ -57,11 +63,41 @@ func NewSink(logger hclog.Logger, hostType string, pifinaEndpoint string, groupI
 	}
 }
 
+// Announces the port of the REST API of the probe to the pifina server, which proxies the API requests to this port.
+func (s *Sink) SetApiPort(apiPort uint32) {
+	s.apiPort = apiPort
+}
+
+// Returns the port of a listen address like :8656 or 0.0.0.0:8656
+func ParseApiPort(listenAddress string) (uint32, error) {
+	_, port, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		return 0, err
+	}
+	apiPort, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %s: %w", port, err)
+	}
+
+	return uint32(apiPort), nil
+}
+
 func (s *Sink) StartSink(ctx context.Context, wg *sync.WaitGroup, c chan *model.SinkEmitCommand) error {
 	defer wg.Done()
 
+	// Probes with a REST API are announced on startup, so the API can be reached before any metrics are emitted.
+	var announceTicker <-chan time.Time
+	if s.apiPort != 0 {
+		s.announce()
+		ticker := time.NewTicker(API_ANNOUNCE_INTERVAL)
+		defer ticker.Stop()
+		announceTicker = ticker.C
+	}
+
 	for {
 		select {
+		case <-announceTicker:
+			s.announce()
 		case batch := <-c:
 			// Chunk metric slice in size of 20 items
 			// Avoids UDP fragmentation => below 1460 bytes.
@@ -84,6 +120,13 @@ func (s *Sink) StartSink(ctx context.Context, wg *sync.WaitGroup, c chan *model.
 	}
 }
 
+// Sends a message without metrics, which registers the probe and the port of its REST API at the pifina server
+func (s *Sink) announce() {
+	if err := s.emit(nil); err != nil {
+		s.logger.Warn("Cannot announce REST API to pifina server", "server", s.pifinaEndpoint, "err", err)
+	}
+}
+
 // Transforms the payload to protobuf and sends to pifina server
 func (s *Sink) emit(metrics []*model.MetricItem) error {
 	return s.emitWithSource(metrics, s.mySystemName)
@@ -98,6 +141,7 @@ func (s *Sink) emitWithSource(metrics []*model.MetricItem, sourceName string) er
 		HostType:   s.hostType,
 		GroupId:    s.groupId,
 		Metrics:    protobufMetrics,
+		ApiPort:    s.apiPort,
 	}
 
 	// Convert to byte string
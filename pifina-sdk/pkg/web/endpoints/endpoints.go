@@ -35,15 +35,20 @@ func NewPifinaEndpointDirectory(port int) *PifinaEndpointDirectory {
 	}
 }
 
-func (e *PifinaEndpointDirectory) Set(newEndpoint string, hostType string, groupId uint32, address net.IP) {
+// Registers a new endpoint with the API port announced by the probe.
+// Probes, which do not announce a port, are registered with the default controller API port.
+func (e *PifinaEndpointDirectory) Set(newEndpoint string, hostType string, groupId uint32, address net.IP, port int) {
 	if _, ok := e.endpoints[newEndpoint]; !ok {
+		if port == 0 {
+			port = e.defaultControllerApiPort
+		}
 		e.lock.Lock()
 		e.endpoints[newEndpoint] = &PifinaEndpoint{
 			Name:     newEndpoint,
 			HostType: hostType,
 			GroupId:  groupId,
 			Address:  address,
-			Port:     e.defaultControllerApiPort,
+			Port:     port,
 		}
 		e.lock.Unlock()
 	}
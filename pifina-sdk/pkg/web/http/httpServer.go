@@ -62,11 +62,34 @@ func (s *PifinaHttpServer) StartWebServer(ctx context.Context, port uint, keyFil
 	mux.HandleFunc("/api/v1/endpoints", s.HandleEndpointRequest)
 	// Proxy requests to controller
 	mux.HandleFunc("/api/v1/selectors", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/selectors/", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/selectors:batch", s.HandleProxyRequest)
 	mux.HandleFunc("/api/v1/schema", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/schema/", s.HandleProxyRequest)
 	mux.HandleFunc("/api/v1/app-registers", s.HandleProxyRequest)
 	mux.HandleFunc("/api/v1/app-registers/", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/app-counters", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/app-counters/", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/app-direct-counters", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/app-direct-counters/", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/app-meters", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/app-meters/", s.HandleProxyRequest)
 	mux.HandleFunc("/api/v1/ports", s.HandleProxyRequest)
 	mux.HandleFunc("/api/v1/ports/", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/tables", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/tables/", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/measurements", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/measurements/", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/flows", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/flows/", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/burst-threshold", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/collector/stats", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/status", s.HandleProxyRequest)
+	// Proxy requests to NIC daemon
+	mux.HandleFunc("/api/v1/devices", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/devices/", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/counters", s.HandleProxyRequest)
+	mux.HandleFunc("/api/v1/counters/", s.HandleProxyRequest)
 
 	// Static website handler for svelte frontend webapp
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -83,8 +83,11 @@ func (r *MetricReceiver) StartServer(ctx context.Context, port uint, telemetryCh
 				GroupId:    protoTelemetryMsg.GroupId,
 				MetricList: metricList,
 			}
+			// Probes with a REST API announce themselves with a message without metrics
+			if len(metricList) > 0 || protoTelemetryMsg.ApiPort != 0 {
+				r.ed.Set(protoTelemetryMsg.SourceHost, hostType, protoTelemetryMsg.GroupId, clientAddr.IP, int(protoTelemetryMsg.ApiPort))
+			}
 			if len(metricList) > 0 {
-				r.ed.Set(protoTelemetryMsg.SourceHost, hostType, protoTelemetryMsg.GroupId, clientAddr.IP)
 				telemetryChannel <- telemetryMessage
 			}
 		}